@@ -0,0 +1,48 @@
+package iptables
+
+import (
+	"regexp"
+)
+
+// Normalized actions (i.e. verdicts) that Action returns.
+const (
+	ActionAccept = "ACCEPT"
+	ActionDrop   = "DROP"
+	ActionReject = "REJECT"
+)
+
+// ActionPattern is a pattern to extract the action from the prefix: the Action is returned when the Pattern matches the prefix.
+type ActionPattern struct {
+	Pattern *regexp.Regexp
+	Action  string
+}
+
+// DefaultActionPatterns are the action patterns that are used by default.
+// These recognize the common substrings (e.g. "DROP", "DENY", and "ACCEPT") case-insensitively, which covers UFW's tags like "[UFW BLOCK]" and "[UFW ALLOW]" as well.
+var DefaultActionPatterns = []ActionPattern{
+	{Pattern: regexp.MustCompile(`(?i)reject`), Action: ActionReject},
+	{Pattern: regexp.MustCompile(`(?i)drop|deny|denied|block`), Action: ActionDrop},
+	{Pattern: regexp.MustCompile(`(?i)accept|allow|permit`), Action: ActionAccept},
+}
+
+// Action returns the action (e.g. ActionDrop) that is extracted from the prefix with DefaultActionPatterns.
+// This returns an empty string when no pattern matches.
+func (l *Log) Action() string {
+	return extractAction(l.Prefix, DefaultActionPatterns)
+}
+
+// Action returns the action that is extracted from the prefix of the given log with the action patterns of the Parser; see also WithActionPatterns.
+// This returns an empty string when no pattern matches.
+func (p *Parser) Action(l *Log) string {
+	return extractAction(l.Prefix, p.opts.actionPatterns)
+}
+
+// extractAction returns the action of the first pattern that matches the prefix.
+func extractAction(prefix string, patterns []ActionPattern) string {
+	for _, pattern := range patterns {
+		if pattern.Pattern.MatchString(prefix) {
+			return pattern.Action
+		}
+	}
+	return ""
+}
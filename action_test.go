@@ -0,0 +1,55 @@
+package iptables
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLog_Action(t *testing.T) {
+	type TestCase struct {
+		prefix         string
+		expectedAction string
+	}
+
+	testCases := []*TestCase{
+		{prefix: "[UFW BLOCK]", expectedAction: ActionDrop},
+		{prefix: "[UFW LIMIT BLOCK]", expectedAction: ActionDrop},
+		{prefix: "[UFW ALLOW]", expectedAction: ActionAccept},
+		{prefix: "[UFW AUDIT]", expectedAction: ""},
+		{prefix: "[FW-DROP]", expectedAction: ActionDrop},
+		{prefix: "DENY-IN:", expectedAction: ActionDrop},
+		{prefix: "INPUT_drop:", expectedAction: ActionDrop},
+		{prefix: "ACCEPT-OUT:", expectedAction: ActionAccept},
+		{prefix: "REJECT:", expectedAction: ActionReject},
+		{prefix: "OUT-LOG:", expectedAction: ""},
+		{prefix: "", expectedAction: ""},
+	}
+
+	for _, testCase := range testCases {
+		l := &Log{Prefix: testCase.prefix}
+		assert.Equal(t, testCase.expectedAction, l.Action(), testCase.prefix)
+	}
+}
+
+func TestParser_Action(t *testing.T) {
+	p, err := NewParser(WithActionPatterns(
+		ActionPattern{Pattern: regexp.MustCompile(`^FW-D\b`), Action: ActionDrop},
+		ActionPattern{Pattern: regexp.MustCompile(`^FW-A\b`), Action: ActionAccept},
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsedLog, err := p.Parse("Jul 21 05:38:28 ubuntu-jammy kernel: [14879.600492] FW-D IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=84 TOS=0x00 PREC=0x00 TTL=64 ID=6495 DF PROTO=ICMP TYPE=8 CODE=0 ID=1 SEQ=3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, ActionDrop, p.Action(parsedLog))
+	assert.Equal(t, ActionAccept, p.Action(&Log{Prefix: "FW-A"}))
+	assert.Equal(t, "", p.Action(&Log{Prefix: "DROP"}))
+
+	_, err = NewParser(WithActionPatterns(ActionPattern{Action: ActionDrop}))
+	assert.Error(t, err)
+}
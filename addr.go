@@ -0,0 +1,114 @@
+package iptables
+
+import (
+	"net/netip"
+)
+
+// SourceAddr returns the source address (i.e. SRC) as netip.Addr.
+// This returns zero netip.Addr without an error if the source address is empty.
+func (l *Log) SourceAddr() (netip.Addr, error) {
+	return parseAddr(l.Source)
+}
+
+// DestinationAddr returns the destination address (i.e. DST) as netip.Addr.
+// This returns zero netip.Addr without an error if the destination address is empty.
+func (l *Log) DestinationAddr() (netip.Addr, error) {
+	return parseAddr(l.Destination)
+}
+
+// ipVersion detects the version of IP by the format of the given addresses, which is zero if it is indeterminate.
+// An address that cannot be parsed doesn't count, but the addresses of the different versions make it indeterminate.
+// An IPv4-mapped IPv6 address is of IPv6, unless the other address is the plain IPv4 one; then the pair is of IPv4, as well as Validate regards it.
+func ipVersion(source, destination string) uint8 {
+	var has4, has6, hasMapped bool
+	for _, addr := range []string{source, destination} {
+		a, err := netip.ParseAddr(addr)
+		if err != nil {
+			continue
+		}
+		switch {
+		case a.Is4():
+			has4 = true
+		case a.Is4In6():
+			hasMapped = true
+		default:
+			has6 = true
+		}
+	}
+	switch {
+	case has4 && has6:
+		return 0
+	case has4:
+		return 4
+	case has6 || hasMapped:
+		return 6
+	}
+	return 0
+}
+
+// Unmap returns the source and the destination addresses with the IPv4-mapped IPv6 addresses (e.g. "::ffff:192.0.2.1", which is logged for the dual-stack sockets) unmapped into the embedded IPv4 addresses by netip.Addr.Unmap.
+// The other addresses are returned as they are, and an empty address is returned as zero netip.Addr without an error.
+// This returns an error if either address cannot be parsed.
+func (l *Log) Unmap() (source netip.Addr, destination netip.Addr, err error) {
+	if source, err = parseAddr(l.Source); err != nil {
+		return netip.Addr{}, netip.Addr{}, err
+	}
+	if destination, err = parseAddr(l.Destination); err != nil {
+		return netip.Addr{}, netip.Addr{}, err
+	}
+	return source.Unmap(), destination.Unmap(), nil
+}
+
+func parseAddr(addr string) (netip.Addr, error) {
+	if addr == "" {
+		return netip.Addr{}, nil
+	}
+	return netip.ParseAddr(addr)
+}
+
+// CanonicalSource returns the source address in the canonical form of netip.Addr, e.g. "2001:db8::1" for "2001:0DB8:0000:0000:0000:0000:0000:0001".
+// An IPv4-mapped IPv6 address is unmapped into the embedded IPv4 address (see Unmap), e.g. "192.0.2.1" for "::ffff:192.0.2.1", so that it is the same as the plain one.
+// This returns the source address as it is if it cannot be parsed. Use WithCanonicalAddresses to rewrite the stored address on parsing instead.
+func (l *Log) CanonicalSource() string {
+	return canonicalAddr(l.Source)
+}
+
+// CanonicalDestination returns the destination address in the canonical form of netip.Addr; see CanonicalSource.
+func (l *Log) CanonicalDestination() string {
+	return canonicalAddr(l.Destination)
+}
+
+func canonicalAddr(addr string) string {
+	a, err := netip.ParseAddr(addr)
+	if err != nil {
+		return addr
+	}
+	return a.Unmap().String()
+}
+
+// SourceInPrefix reports whether the source address is in the given prefix (e.g. 10.0.0.0/8).
+// An IPv4-mapped IPv6 address and the prefix are regarded as the embedded IPv4 ones, e.g. "::ffff:10.0.2.15" is in 10.0.0.0/8, and "10.0.2.15" is in ::ffff:10.0.0.0/104.
+// This returns false, rather than an error, if the source address cannot be parsed or its family differs from the prefix's one.
+func (l *Log) SourceInPrefix(p netip.Prefix) bool {
+	return addrInPrefix(l.Source, p)
+}
+
+// DestinationInPrefix reports whether the destination address is in the given prefix (e.g. 10.0.0.0/8).
+// This returns false, rather than an error, if the destination address cannot be parsed or its family differs from the prefix's one.
+func (l *Log) DestinationInPrefix(p netip.Prefix) bool {
+	return addrInPrefix(l.Destination, p)
+}
+
+func addrInPrefix(addr string, p netip.Prefix) bool {
+	a, err := netip.ParseAddr(addr)
+	if err != nil {
+		return false
+	}
+	if pa := p.Addr(); pa.Is4In6() && p.Bits() >= 96 {
+		p = netip.PrefixFrom(pa.Unmap(), p.Bits()-96)
+	}
+	if p.Addr().Is4() {
+		a = a.Unmap()
+	}
+	return p.Contains(a)
+}
@@ -0,0 +1,308 @@
+package iptables
+
+import (
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLog_SourceAddrAndDestinationAddr(t *testing.T) {
+	type TestCase struct {
+		source              string
+		destination         string
+		expectedSource      netip.Addr
+		expectedDestination netip.Addr
+	}
+
+	testCases := []*TestCase{
+		{
+			source:              "10.0.2.15",
+			destination:         "93.184.216.34",
+			expectedSource:      netip.MustParseAddr("10.0.2.15"),
+			expectedDestination: netip.MustParseAddr("93.184.216.34"),
+		},
+		{
+			source:              "2001:0db8:0000:0000:0000:0000:0000:0001",
+			destination:         "ff02::1:2",
+			expectedSource:      netip.MustParseAddr("2001:db8::1"),
+			expectedDestination: netip.MustParseAddr("ff02::1:2"),
+		},
+		{
+			source:              "",
+			destination:         "",
+			expectedSource:      netip.Addr{},
+			expectedDestination: netip.Addr{},
+		},
+	}
+
+	for _, testCase := range testCases {
+		l := &Log{Source: testCase.source, Destination: testCase.destination}
+
+		src, err := l.SourceAddr()
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, testCase.expectedSource, src)
+
+		dst, err := l.DestinationAddr()
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, testCase.expectedDestination, dst)
+	}
+}
+
+func TestLog_SourceAddrAndDestinationAddr_Invalid(t *testing.T) {
+	l := &Log{Source: "10.0.2", Destination: "2001:db8:::1"}
+
+	_, err := l.SourceAddr()
+	assert.Error(t, err)
+
+	_, err = l.DestinationAddr()
+	assert.Error(t, err)
+}
+
+func TestLog_SourceInPrefixAndDestinationInPrefix(t *testing.T) {
+	type TestCase struct {
+		source                string
+		destination           string
+		prefix                netip.Prefix
+		expectedSourceIn      bool
+		expectedDestinationIn bool
+	}
+
+	testCases := []*TestCase{
+		{
+			source:                "10.0.2.15",
+			destination:           "93.184.216.34",
+			prefix:                netip.MustParsePrefix("10.0.0.0/8"),
+			expectedSourceIn:      true,
+			expectedDestinationIn: false,
+		},
+		{
+			source:                "2001:0db8:0000:0000:0000:0000:0000:0001",
+			destination:           "ff02::1:2",
+			prefix:                netip.MustParsePrefix("2001:db8::/32"),
+			expectedSourceIn:      true,
+			expectedDestinationIn: false,
+		},
+		{
+			// the family mismatch
+			source:                "10.0.2.15",
+			destination:           "2001:db8::1",
+			prefix:                netip.MustParsePrefix("::/0"),
+			expectedSourceIn:      false,
+			expectedDestinationIn: true,
+		},
+		{
+			// the IPv4-mapped IPv6 address is regarded as the embedded IPv4 one
+			source:                "::ffff:10.0.2.15",
+			destination:           "::ffff:93.184.216.34",
+			prefix:                netip.MustParsePrefix("10.0.0.0/8"),
+			expectedSourceIn:      true,
+			expectedDestinationIn: false,
+		},
+		{
+			source:                "10.0.2.15",
+			destination:           "::ffff:10.0.2.16",
+			prefix:                netip.MustParsePrefix("::ffff:10.0.0.0/104"),
+			expectedSourceIn:      true,
+			expectedDestinationIn: true,
+		},
+		{
+			source:                "::ffff:10.0.2.15",
+			destination:           "10.0.2.15",
+			prefix:                netip.MustParsePrefix("::/0"),
+			expectedSourceIn:      true,
+			expectedDestinationIn: false,
+		},
+		{
+			// the unparsable addresses
+			source:                "",
+			destination:           "10.0.2.999",
+			prefix:                netip.MustParsePrefix("0.0.0.0/0"),
+			expectedSourceIn:      false,
+			expectedDestinationIn: false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		l := &Log{Source: testCase.source, Destination: testCase.destination}
+		assert.Equal(t, testCase.expectedSourceIn, l.SourceInPrefix(testCase.prefix))
+		assert.Equal(t, testCase.expectedDestinationIn, l.DestinationInPrefix(testCase.prefix))
+	}
+}
+
+func TestLog_Unmap(t *testing.T) {
+	type TestCase struct {
+		source              string
+		destination         string
+		expectedSource      netip.Addr
+		expectedDestination netip.Addr
+	}
+
+	testCases := []*TestCase{
+		{
+			source:              "::ffff:192.0.2.1",
+			destination:         "::ffff:10.0.2.15",
+			expectedSource:      netip.MustParseAddr("192.0.2.1"),
+			expectedDestination: netip.MustParseAddr("10.0.2.15"),
+		},
+		{
+			source:              "192.0.2.1",
+			destination:         "2001:db8::1",
+			expectedSource:      netip.MustParseAddr("192.0.2.1"),
+			expectedDestination: netip.MustParseAddr("2001:db8::1"),
+		},
+		{
+			source:              "",
+			destination:         "::ffff:10.0.2.15",
+			expectedSource:      netip.Addr{},
+			expectedDestination: netip.MustParseAddr("10.0.2.15"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		l := &Log{Source: testCase.source, Destination: testCase.destination}
+		source, destination, err := l.Unmap()
+		assert.NoError(t, err)
+		assert.Equal(t, testCase.expectedSource, source)
+		assert.Equal(t, testCase.expectedDestination, destination)
+	}
+
+	_, _, err := (&Log{Source: "::ffff:192.0.2.1", Destination: "10.0.2.999"}).Unmap()
+	assert.Error(t, err)
+}
+
+func TestParse_IPv4MappedAddresses(t *testing.T) {
+	line := "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] IN=enp0s3 OUT= SRC=::ffff:192.0.2.1 DST=::ffff:10.0.2.15 LEN=80 TC=0 HOPLIMIT=64 FLOWLBL=0 PROTO=TCP SPT=54832 DPT=80 WINDOW=64240 RES=0x00 SYN URGP=0"
+
+	parsedLog, err := Parse(line)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "::ffff:192.0.2.1", parsedLog.Source)
+	assert.Equal(t, "::ffff:10.0.2.15", parsedLog.Destination)
+	assert.True(t, parsedLog.IsIPv6)
+	assert.Equal(t, uint8(6), parsedLog.IPVersion)
+	assert.NoError(t, parsedLog.Validate())
+
+	source, destination, err := parsedLog.Unmap()
+	assert.NoError(t, err)
+	assert.Equal(t, netip.MustParseAddr("192.0.2.1"), source)
+	assert.Equal(t, netip.MustParseAddr("10.0.2.15"), destination)
+	assert.True(t, parsedLog.SourceInPrefix(netip.MustParsePrefix("192.0.2.0/24")))
+	assert.True(t, parsedLog.DestinationInPrefix(netip.MustParsePrefix("10.0.0.0/8")))
+
+	// the round trip keeps the mapped form
+	reparsedLog, err := Parse(parsedLog.Format())
+	assert.NoError(t, err)
+	assert.True(t, parsedLog.Equal(reparsedLog))
+
+	// the mapped and the plain forms are the same flow
+	plainLog := &Log{Protocol: "TCP", Source: "192.0.2.1", SourcePort: 54832, Destination: "10.0.2.15", DestinationPort: 80}
+	assert.Equal(t, "192.0.2.1", parsedLog.CanonicalSource())
+	assert.Equal(t, plainLog.FiveTuple(), parsedLog.FiveTuple())
+	assert.Equal(t, plainLog.FiveTupleBidirectional(), parsedLog.FiveTupleBidirectional())
+
+	// the canonicalized addresses are unmapped, but the IP version is the one of the logged addresses
+	p, err := NewParser(WithCanonicalAddresses(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	canonicalLog, err := p.Parse(line)
+	assert.NoError(t, err)
+	assert.Equal(t, "192.0.2.1", canonicalLog.Source)
+	assert.Equal(t, "10.0.2.15", canonicalLog.Destination)
+	assert.Equal(t, uint8(6), canonicalLog.IPVersion)
+	assert.True(t, parsedLog.Equal(canonicalLog))
+}
+
+func TestLog_CanonicalSourceAndCanonicalDestination(t *testing.T) {
+	type TestCase struct {
+		addr     string
+		expected string
+	}
+
+	testCases := []*TestCase{
+		{addr: "2001:0db8:0000:0000:0000:0000:0000:0001", expected: "2001:db8::1"},
+		{addr: "2001:DB8::1", expected: "2001:db8::1"},
+		{addr: "2001:db8:0:0:0:0:0:1", expected: "2001:db8::1"},
+		{addr: "fe80::1%eth0", expected: "fe80::1%eth0"},
+		{addr: "10.0.2.15", expected: "10.0.2.15"},
+		// the IPv4-mapped IPv6 address is unmapped
+		{addr: "::ffff:10.0.2.15", expected: "10.0.2.15"},
+		{addr: "::FFFF:0A00:020F", expected: "10.0.2.15"},
+		{addr: "", expected: ""},
+		{addr: "bogus", expected: "bogus"},
+	}
+
+	for _, testCase := range testCases {
+		l := &Log{Source: testCase.addr, Destination: testCase.addr}
+		assert.Equal(t, testCase.expected, l.CanonicalSource(), testCase.addr)
+		assert.Equal(t, testCase.expected, l.CanonicalDestination(), testCase.addr)
+	}
+}
+
+func TestParse_WithCanonicalAddresses(t *testing.T) {
+	line := "Jul 22 10:00:00 ubuntu-jammy kernel: [ 5000.423456] IN6-LOG: IN=enp0s3 OUT= SRC=2001:0db8:0000:0000:0000:0000:0000:0002 DST=2001:0db8:0000:0000:0000:0000:0000:0001 LEN=1280 TC=0 HOPLIMIT=64 FLOWLBL=0 PROTO=ICMPv6 TYPE=2 CODE=0 MTU=1280 [SRC=2001:0db8:0000:0000:0000:0000:0000:0001 DST=2001:0DB8::2 LEN=1500 TC=0 HOPLIMIT=64 FLOWLBL=0 PROTO=TCP SPT=443 DPT=54832 WINDOW=502 RES=0x00 ACK URGP=0 ]"
+
+	parsedLog, err := Parse(line)
+	assert.NoError(t, err)
+	assert.Equal(t, "2001:0db8:0000:0000:0000:0000:0000:0002", parsedLog.Source)
+
+	p, err := NewParser(WithCanonicalAddresses(true))
+	assert.NoError(t, err)
+	canonicalLog, err := p.Parse(line)
+	assert.NoError(t, err)
+	assert.Equal(t, "2001:db8::2", canonicalLog.Source)
+	assert.Equal(t, "2001:db8::1", canonicalLog.Destination)
+	assert.Equal(t, "2001:db8::1", canonicalLog.Inner.Source)
+	assert.Equal(t, "2001:db8::2", canonicalLog.Inner.Destination)
+
+	// the differently notated addresses are grouped together
+	other, err := p.Parse(strings.ReplaceAll(line, "2001:0db8:0000:0000:0000:0000:0000:0002", "2001:DB8:0:0:0:0:0:2"))
+	assert.NoError(t, err)
+	assert.True(t, canonicalLog.EqualIgnoring(other, FieldInner))
+	assert.Equal(t, map[string]int{"2001:db8::2": 2}, GroupCount(slices.Values([]*Log{canonicalLog, other}), func(l *Log) string { return l.Source }))
+}
+
+func TestParse_IPVersion(t *testing.T) {
+	type TestCase struct {
+		source      string
+		destination string
+		expected    uint8
+	}
+
+	testCases := []*TestCase{
+		{source: "10.0.2.15", destination: "8.8.8.8", expected: 4},
+		{source: "2001:db8::2", destination: "2001:0db8:0000:0000:0000:0000:0000:0001", expected: 6},
+		{source: "fe80::1%enp0s3", destination: "ff02::1:2", expected: 6},
+		// IPv4-mapped IPv6 addresses are carried by the IPv6 header
+		{source: "::ffff:192.0.2.1", destination: "::ffff:10.0.2.15", expected: 6},
+		{source: "::ffff:192.0.2.1", destination: "2001:db8::1", expected: 6},
+		// but the one with the plain IPv4 address is regarded as IPv4, as well as Validate does
+		{source: "::ffff:192.0.2.1", destination: "10.0.2.15", expected: 4},
+		{source: "10.0.2.15", destination: "::ffff:192.0.2.1", expected: 4},
+		// indeterminate
+		{source: "10.0.2.15", destination: "2001:db8::1", expected: 0},
+		{source: "bogus", destination: "bogus", expected: 0},
+		{source: "", destination: "", expected: 0},
+		// a malformed address doesn't count
+		{source: "bogus", destination: "8.8.8.8", expected: 4},
+	}
+
+	for _, testCase := range testCases {
+		line := fmt.Sprintf("Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] IN= OUT=enp0s3 SRC=%s DST=%s LEN=64 TOS=0x00 PREC=0x00 TTL=64 ID=40000 PROTO=UDP SPT=40000 DPT=53 LEN=44", testCase.source, testCase.destination)
+		parsedLog, err := Parse(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, testCase.expected, parsedLog.IPVersion, line)
+		assert.Equal(t, testCase.expected != 0, parsedLog.Has(FieldIPVersion), line)
+	}
+}
@@ -0,0 +1,55 @@
+package iptables
+
+import (
+	"fmt"
+	"iter"
+	"time"
+)
+
+// GroupCount counts the logs grouped by the given key function, e.g. the number of packets per source address.
+func GroupCount(logs iter.Seq[*Log], key func(*Log) string) map[string]int {
+	counts := make(map[string]int)
+	for l := range logs {
+		counts[key(l)]++
+	}
+	return counts
+}
+
+// SumBy sums the values of the logs grouped by the given key function, e.g. the total bytes (i.e. the sum of Length) per source address.
+func SumBy(logs iter.Seq[*Log], key func(*Log) string, val func(*Log) uint64) map[string]uint64 {
+	sums := make(map[string]uint64)
+	for l := range logs {
+		sums[key(l)] += val(l)
+	}
+	return sums
+}
+
+// BucketByTime groups the logs into the time windows by TimestampParsed, e.g. per minute for a rate graph.
+// Each key is the beginning of the window, i.e. base + n*window for an integer n (which can be negative for the logs before the base), in the location of the base.
+// The logs without TimestampParsed (i.e. it is zero) are skipped if skipUntimed is true; otherwise they are put into the separate bucket of the zero time.Time.
+// The logs in each bucket keep the order of the sequence. This returns an error if the window is not positive.
+func BucketByTime(logs iter.Seq[*Log], window time.Duration, base time.Time, skipUntimed bool) (map[time.Time][]*Log, error) {
+	if window <= 0 {
+		return nil, fmt.Errorf("window must be positive, but got %s", window)
+	}
+
+	buckets := make(map[time.Time][]*Log)
+	for l := range logs {
+		if l.TimestampParsed.IsZero() {
+			if !skipUntimed {
+				buckets[time.Time{}] = append(buckets[time.Time{}], l)
+			}
+			continue
+		}
+
+		elapsed := l.TimestampParsed.Sub(base)
+		n := elapsed / window
+		if elapsed%window < 0 {
+			// floor for the logs before the base
+			n--
+		}
+		start := base.Add(n * window)
+		buckets[start] = append(buckets[start], l)
+	}
+	return buckets, nil
+}
@@ -0,0 +1,102 @@
+package iptables
+
+import (
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupCountAndSumBy(t *testing.T) {
+	logs := []*Log{
+		{Source: "10.0.2.15", Protocol: "TCP", SourcePort: 54832, Length: 60},
+		{Source: "10.0.2.15", Protocol: "UDP", SourcePort: 40000, Length: 64},
+		{Source: "10.0.2.2", Protocol: "TCP", SourcePort: 54832, Length: 76},
+	}
+
+	bySource := func(l *Log) string { return l.Source }
+
+	assert.Equal(t, map[string]int{"10.0.2.15": 2, "10.0.2.2": 1}, GroupCount(slices.Values(logs), bySource))
+	assert.Equal(t, map[string]int{"TCP": 2, "UDP": 1}, GroupCount(slices.Values(logs), func(l *Log) string {
+		return l.Protocol
+	}))
+	assert.Equal(t, map[string]int{"54832": 2, "40000": 1}, GroupCount(slices.Values(logs), func(l *Log) string {
+		return strconv.Itoa(int(l.SourcePort))
+	}))
+
+	assert.Equal(t, map[string]uint64{"10.0.2.15": 124, "10.0.2.2": 76}, SumBy(slices.Values(logs), bySource, func(l *Log) uint64 {
+		return l.Length
+	}))
+}
+
+func TestGroupCountAndSumBy_Empty(t *testing.T) {
+	bySource := func(l *Log) string { return l.Source }
+	assert.Equal(t, map[string]int{}, GroupCount(slices.Values([]*Log{}), bySource))
+	assert.Equal(t, map[string]uint64{}, SumBy(slices.Values([]*Log{}), bySource, func(l *Log) uint64 { return l.Length }))
+}
+
+func TestBucketByTime(t *testing.T) {
+	base := time.Date(2022, time.July, 21, 5, 0, 0, 0, time.UTC)
+	at := func(minute, sec int) time.Time {
+		return base.Add(time.Duration(minute)*time.Minute + time.Duration(sec)*time.Second)
+	}
+	logs := []*Log{
+		{ID: 1, TimestampParsed: at(0, 0)},
+		{ID: 2, TimestampParsed: at(0, 59)},
+		{ID: 3, TimestampParsed: at(1, 0)},
+		{ID: 4},
+		{ID: 5, TimestampParsed: at(0, 30)},
+		{ID: 6, TimestampParsed: at(-1, 30)},
+		{ID: 7, TimestampParsed: at(-1, 0)},
+		// in a different location
+		{ID: 8, TimestampParsed: at(2, 1).In(time.FixedZone("JST", 9*60*60))},
+	}
+
+	type TestCase struct {
+		skipUntimed bool
+		expected    map[time.Time][]uint64
+	}
+
+	testCases := []*TestCase{
+		{
+			skipUntimed: false,
+			expected: map[time.Time][]uint64{
+				at(-1, 0):   {6, 7},
+				at(0, 0):    {1, 2, 5},
+				at(1, 0):    {3},
+				at(2, 0):    {8},
+				time.Time{}: {4},
+			},
+		},
+		{
+			skipUntimed: true,
+			expected: map[time.Time][]uint64{
+				at(-1, 0): {6, 7},
+				at(0, 0):  {1, 2, 5},
+				at(1, 0):  {3},
+				at(2, 0):  {8},
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		buckets, err := BucketByTime(slices.Values(logs), time.Minute, base, testCase.skipUntimed)
+		assert.NoError(t, err)
+
+		ids := make(map[time.Time][]uint64)
+		for start, bucket := range buckets {
+			for _, l := range bucket {
+				ids[start] = append(ids[start], l.ID)
+			}
+		}
+		assert.Equal(t, testCase.expected, ids, "skipUntimed = %v", testCase.skipUntimed)
+	}
+
+	for _, window := range []time.Duration{0, -time.Minute} {
+		buckets, err := BucketByTime(slices.Values(logs), window, base, false)
+		assert.Error(t, err, window)
+		assert.Nil(t, buckets, window)
+	}
+}
@@ -0,0 +1,122 @@
+package iptables
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// AnonymizeOptions configures Log.Anonymize.
+type AnonymizeOptions struct {
+	// IPv4PrefixLen is the number of the leading bits of the IPv4 addresses to keep; the host bits below it are zeroed, e.g. 24 makes 192.0.2.123 into 192.0.2.0.
+	// This must be in [0, 32]. This is not used when Hash is enabled.
+	IPv4PrefixLen int
+	// IPv6PrefixLen is the number of the leading bits of the IPv6 addresses to keep; the host bits below it are zeroed, e.g. 48 makes 2001:db8:1:2::1 into 2001:db8:1::.
+	// This must be in [0, 128]. This is not used when Hash is enabled.
+	IPv6PrefixLen int
+	// Hash replaces the addresses with the deterministic hash (HMAC-SHA256 with HashKey) instead of zeroing the host bits.
+	// The same address is always replaced with the same address of the same family, so the hashed logs can still be correlated.
+	Hash bool
+	// HashKey is the secret key of the hash. The key should be kept secret, because the addresses can be recovered by brute force otherwise.
+	HashKey []byte
+	// MAC anonymizes the MAC addresses of the MAC field as well; the hardware addresses are zeroed, or hashed when Hash is enabled, and the EtherType is kept.
+	MAC bool
+	// Prefix redacts the prefix, i.e. makes it empty, since it might contain the sensitive information (e.g. the host names).
+	Prefix bool
+}
+
+// Anonymize masks the addresses of the log in place, i.e. Source and Destination (and the ones of the inner packet), and optionally the MAC field and the prefix.
+// The original line (i.e. Raw) is dropped, since it contains the addresses as they are.
+// The family of the addresses is preserved, i.e. an IPv6 address stays IPv6. An address that cannot be parsed is emptied, so that it never leaks.
+// This returns an error if the prefix length of the options is out of range; the log is not modified in that case.
+func (l *Log) Anonymize(opts AnonymizeOptions) error {
+	if opts.IPv4PrefixLen < 0 || opts.IPv4PrefixLen > 32 {
+		return fmt.Errorf("IPv4 prefix length must be in [0, 32], but got %d", opts.IPv4PrefixLen)
+	}
+	if opts.IPv6PrefixLen < 0 || opts.IPv6PrefixLen > 128 {
+		return fmt.Errorf("IPv6 prefix length must be in [0, 128], but got %d", opts.IPv6PrefixLen)
+	}
+
+	for inner := l; inner != nil; inner = inner.Inner {
+		inner.Source = anonymizeAddr(inner.Source, &opts)
+		inner.Destination = anonymizeAddr(inner.Destination, &opts)
+		if opts.MAC {
+			inner.MACAddress = anonymizeMACField(inner.MACAddress, &opts)
+		}
+		if opts.Prefix {
+			inner.Prefix = ""
+		}
+		// the original line contains the addresses as they are
+		inner.Raw = ""
+	}
+	return nil
+}
+
+func anonymizeAddr(addr string, opts *AnonymizeOptions) string {
+	if addr == "" {
+		return ""
+	}
+	a, err := netip.ParseAddr(addr)
+	if err != nil {
+		return ""
+	}
+	// an IPv4-mapped IPv6 address is anonymized as the embedded IPv4 address, so that it results in the same as the plain one except for the form
+	mapped := a.Is4In6()
+	a = a.WithZone("").Unmap()
+
+	var anonymized netip.Addr
+	if opts.Hash {
+		sum := hashBytes(a.AsSlice(), opts.HashKey)
+		if a.Is4() {
+			anonymized = netip.AddrFrom4([4]byte(sum[:4]))
+		} else {
+			anonymized = netip.AddrFrom16([16]byte(sum[:16]))
+		}
+	} else {
+		bits := opts.IPv6PrefixLen
+		if a.Is4() {
+			bits = opts.IPv4PrefixLen
+		}
+		// the prefix length is validated in advance, so this never fails
+		p, _ := a.Prefix(bits)
+		anonymized = p.Addr()
+	}
+
+	if mapped {
+		anonymized = netip.AddrFrom16(anonymized.As16())
+	}
+	return anonymized.String()
+}
+
+func anonymizeMACField(field string, opts *AnonymizeOptions) string {
+	if field == "" {
+		return ""
+	}
+	b, err := parseMACField(field)
+	if err != nil || len(b) < hardwareAddrLen*2 {
+		return ""
+	}
+
+	for i := 0; i < hardwareAddrLen*2; i += hardwareAddrLen {
+		hw := b[i : i+hardwareAddrLen]
+		if opts.Hash {
+			copy(hw, hashBytes(hw, opts.HashKey))
+		} else {
+			clear(hw)
+		}
+	}
+
+	octets := make([]string, len(b))
+	for i, octet := range b {
+		octets[i] = fmt.Sprintf("%02x", octet)
+	}
+	return strings.Join(octets, ":")
+}
+
+func hashBytes(b []byte, key []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(b)
+	return mac.Sum(nil)
+}
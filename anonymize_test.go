@@ -0,0 +1,109 @@
+package iptables
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLog_Anonymize(t *testing.T) {
+	type TestCase struct {
+		log      *Log
+		opts     AnonymizeOptions
+		expected *Log
+	}
+
+	testCases := []*TestCase{
+		{
+			log:      &Log{Source: "192.0.2.123", Destination: "2001:db8:1:2::1", MACAddress: "00:b3:dd:bc:29:e1:52:54:00:12:35:02:08:00", Prefix: "host-a DROP"},
+			opts:     AnonymizeOptions{IPv4PrefixLen: 24, IPv6PrefixLen: 48},
+			expected: &Log{Source: "192.0.2.0", Destination: "2001:db8:1::", MACAddress: "00:b3:dd:bc:29:e1:52:54:00:12:35:02:08:00", Prefix: "host-a DROP"},
+		},
+		{
+			log:      &Log{Source: "192.0.2.123", Destination: "", MACAddress: "00:b3:dd:bc:29:e1:52:54:00:12:35:02:08:00", Prefix: "host-a DROP"},
+			opts:     AnonymizeOptions{MAC: true, Prefix: true},
+			expected: &Log{Source: "0.0.0.0", Destination: "", MACAddress: "00:00:00:00:00:00:00:00:00:00:00:00:08:00", Prefix: ""},
+		},
+		{
+			// the IPv4-mapped IPv6 address is anonymized as the embedded IPv4 one, keeping the form
+			log:      &Log{Source: "::ffff:192.0.2.123", Destination: "192.0.2.123"},
+			opts:     AnonymizeOptions{IPv4PrefixLen: 24, IPv6PrefixLen: 48},
+			expected: &Log{Source: "::ffff:192.0.2.0", Destination: "192.0.2.0"},
+		},
+		{
+			// the unparsable address never leaks
+			log:      &Log{Source: "10.0.2.999", Destination: "10.0.2.15", MACAddress: "bogus"},
+			opts:     AnonymizeOptions{IPv4PrefixLen: 32, MAC: true},
+			expected: &Log{Source: "", Destination: "10.0.2.15", MACAddress: ""},
+		},
+		{
+			// the inner packet is anonymized as well
+			log:      &Log{Source: "192.0.2.1", Destination: "10.0.2.15", Inner: &Log{Source: "10.0.2.15", Destination: "198.51.100.7"}},
+			opts:     AnonymizeOptions{IPv4PrefixLen: 16},
+			expected: &Log{Source: "192.0.0.0", Destination: "10.0.0.0", Inner: &Log{Source: "10.0.0.0", Destination: "198.51.0.0"}},
+		},
+		{
+			// the original line is dropped
+			log:      &Log{Source: "192.0.2.1", Destination: "10.0.2.15", Raw: "... SRC=192.0.2.1 DST=10.0.2.15 ..."},
+			opts:     AnonymizeOptions{IPv4PrefixLen: 24},
+			expected: &Log{Source: "192.0.2.0", Destination: "10.0.2.0"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		err := testCase.log.Anonymize(testCase.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, testCase.expected, testCase.log)
+	}
+}
+
+func TestLog_AnonymizeHash(t *testing.T) {
+	opts := AnonymizeOptions{Hash: true, HashKey: []byte("secret"), MAC: true}
+
+	l1 := &Log{Source: "192.0.2.123", Destination: "2001:db8::1", MACAddress: "00:b3:dd:bc:29:e1:52:54:00:12:35:02:08:00"}
+	l2 := &Log{Source: "2001:db8::1", Destination: "192.0.2.124", MACAddress: "52:54:00:12:35:02:00:b3:dd:bc:29:e1:08:00"}
+	assert.NoError(t, l1.Anonymize(opts))
+	assert.NoError(t, l2.Anonymize(opts))
+
+	// the family is preserved
+	src1 := netip.MustParseAddr(l1.Source)
+	dst1 := netip.MustParseAddr(l1.Destination)
+	assert.True(t, src1.Is4())
+	assert.True(t, dst1.Is6())
+	assert.NotEqual(t, "192.0.2.123", l1.Source)
+
+	// the hash is deterministic
+	assert.Equal(t, l1.Destination, l2.Source)
+	assert.NotEqual(t, l1.Source, l2.Destination)
+	macInfo1, err := l1.MAC()
+	if err != nil {
+		t.Fatal(err)
+	}
+	macInfo2, err := l2.MAC()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, macInfo1.DestMAC, macInfo2.SrcMAC)
+	assert.Equal(t, macInfo1.SrcMAC, macInfo2.DestMAC)
+	assert.Equal(t, uint16(0x0800), macInfo1.EtherType)
+
+	// the IPv4-mapped IPv6 address is hashed as the embedded IPv4 one
+	l4 := &Log{Source: "::ffff:192.0.2.123"}
+	assert.NoError(t, l4.Anonymize(opts))
+	assert.Equal(t, netip.AddrFrom16(netip.MustParseAddr(l1.Source).As16()).String(), l4.Source)
+
+	// the different key makes the different hash
+	l3 := &Log{Source: "192.0.2.123"}
+	assert.NoError(t, l3.Anonymize(AnonymizeOptions{Hash: true, HashKey: []byte("another secret")}))
+	assert.NotEqual(t, l1.Source, l3.Source)
+}
+
+func TestLog_AnonymizeInvalidOptions(t *testing.T) {
+	l := &Log{Source: "192.0.2.123"}
+	assert.Error(t, l.Anonymize(AnonymizeOptions{IPv4PrefixLen: 33}))
+	assert.Error(t, l.Anonymize(AnonymizeOptions{IPv6PrefixLen: -1}))
+	assert.Equal(t, "192.0.2.123", l.Source)
+}
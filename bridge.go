@@ -0,0 +1,213 @@
+package iptables
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// The EtherTypes of BridgeLog.EtherType.
+const (
+	EtherTypeIPv4 uint16 = 0x0800
+	EtherTypeARP  uint16 = 0x0806
+	EtherTypeIPv6 uint16 = 0x86dd
+)
+
+// BridgeLog is a structure that represents a parsed line of the bridge firewall (i.e. ebtables) log, which has the Ethernet header and the ARP or IP header.
+// The line looks like "IN=br0 OUT= MAC source = 52:54:00:12:35:02 MAC dest = ff:ff:ff:ff:ff:ff proto = 0x0806 ARP HTYPE=1, PTYPE=0x0800, OPCODE=1 ...".
+type BridgeLog struct {
+	Facility        uint8     `json:"facility"`
+	Severity        uint8     `json:"severity"`
+	HasPriority     bool      `json:"hasPriority"`
+	Timestamp       string    `json:"timestamp"`
+	TimestampParsed time.Time `json:"timestampParsed"`
+	Hostname        string    `json:"hostname"`
+	KernelTimestamp float64   `json:"kernelTimestamp"`
+	Prefix          string    `json:"prefix"`
+	InputInterface  string    `json:"inputInterface"`
+	OutputInterface string    `json:"outputInterface"`
+	SourceMAC       string    `json:"sourceMac"`
+	DestinationMAC  string    `json:"destinationMac"`
+	// EtherType is the EtherType of the frame (i.e. "proto"); see the EtherType* constants.
+	EtherType uint16 `json:"etherType"`
+	// ARPHardwareType is the hardware type of the ARP header (i.e. HTYPE), e.g. 1 for Ethernet.
+	ARPHardwareType uint16 `json:"arpHardwareType"`
+	// ARPProtocolType is the protocol type of the ARP header (i.e. PTYPE), e.g. 0x0800 for IPv4.
+	ARPProtocolType uint16 `json:"arpProtocolType"`
+	// ARPOperation is the operation of the ARP header (i.e. OPCODE), e.g. 1 for a request and 2 for a reply.
+	ARPOperation uint16 `json:"arpOperation"`
+	ARPSenderMAC string `json:"arpSenderMac"`
+	ARPSenderIP  string `json:"arpSenderIp"`
+	ARPTargetMAC string `json:"arpTargetMac"`
+	ARPTargetIP  string `json:"arpTargetIp"`
+	Source       string `json:"source"`
+	Destination  string `json:"destination"`
+	IsIPv6       bool   `json:"isIPv6"`
+	// ToS is the type of service of IPv4 (i.e. "IP tos"), or the priority of IPv6 (i.e. "IPv6 priority").
+	ToS uint8 `json:"tos"`
+	// Protocol is the protocol number of IPv4 (i.e. "IP proto"), or the next header of IPv6 (i.e. "Next Header").
+	Protocol        uint8  `json:"protocol"`
+	SourcePort      uint16 `json:"sourcePort"`
+	DestinationPort uint16 `json:"destinationPort"`
+}
+
+// IsARP reports whether the frame is an ARP packet.
+func (l *BridgeLog) IsARP() bool {
+	return l.EtherType == EtherTypeARP
+}
+
+// ParseBridge parses a line of the bridge firewall log; see Parser.ParseBridge for details.
+func ParseBridge(line string) (*BridgeLog, error) {
+	return defaultParser.ParseBridge(line)
+}
+
+// ParseBridge parses a line of the bridge firewall log (i.e. the log of ebtables) into BridgeLog.
+// The syslog preamble and the prefix are parsed as well as Parse does, and the options of the Parser are respected.
+// The ARP fields of the arptables style (e.g. "ARP HTYPE=1 PTYPE=0x0800 OPCODE=1 MACSRC=... IPSRC=...") are accepted as well.
+// This returns ErrLogFormatUnmatched (as *UnmatchedError) if the line has neither the EtherType nor the ARP header, and ErrStringToNumberConversionFailed (as *FieldConversionError) if a numeric field is malformed.
+func (p *Parser) ParseBridge(line string) (*BridgeLog, error) {
+	l, err := p.parseBridge(line)
+	if err != nil {
+		var unmatchedErr *UnmatchedError
+		if errors.As(err, &unmatchedErr) {
+			unmatchedErr.Line = line
+		}
+		return nil, err
+	}
+	return l, nil
+}
+
+func (p *Parser) parseBridge(line string) (*BridgeLog, error) {
+	// the preamble is common to the iptables lines
+	var header Log
+	fields, err := p.parsePreamble(line, &header, p.opts.preambleRe)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &BridgeLog{
+		Facility:        header.Facility,
+		Severity:        header.Severity,
+		HasPriority:     header.HasPriority,
+		Timestamp:       header.Timestamp,
+		TimestampParsed: header.TimestampParsed,
+		Hostname:        header.Hostname,
+		KernelTimestamp: header.KernelTimestamp,
+		Prefix:          header.Prefix,
+	}
+	if err := parseBridgeFields(fields, l); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// parseBridgeFields parses the fields of the bridge firewall log.
+// The keys of the fields can consist of multiple words (e.g. "MAC source = ..." and "ARP IP SRC=..."), and the values can be terminated by a comma (e.g. "HTYPE=1,").
+// So the bare words preceding "=" are joined into the key, e.g. "MAC source" and "ARP IP SRC".
+func parseBridgeFields(fields string, l *BridgeLog) error {
+	var (
+		seenEtherType bool
+		seenARP       bool
+		words         []string
+	)
+	t := &tokenizer{text: fields}
+	for {
+		token, ok := t.next()
+		if !ok {
+			break
+		}
+
+		var key, value string
+		if token == "=" {
+			// "MAC source = 52:54:00:12:35:02"
+			key = strings.Join(words, " ")
+			value, _ = t.next()
+		} else {
+			k, v, hasValue := strings.Cut(token, "=")
+			if !hasValue {
+				words = append(words, token)
+				continue
+			}
+			key = strings.Join(append(words, k), " ")
+			value = v
+		}
+		words = words[:0]
+		value = strings.TrimSuffix(value, ",")
+
+		var err error
+		switch key {
+		case "IN":
+			l.InputInterface = value
+		case "OUT":
+			l.OutputInterface = value
+		case "MAC source":
+			l.SourceMAC = value
+		case "MAC dest":
+			l.DestinationMAC = value
+		case "proto":
+			var etherType uint64
+			etherType, err = parseHexField("proto", value, 16)
+			l.EtherType = uint16(etherType)
+			seenEtherType = true
+		case "ARP HTYPE", "HTYPE":
+			var htype uint64
+			htype, err = parseUintField("htype", value, 10, 16)
+			l.ARPHardwareType = uint16(htype)
+			seenARP = true
+		case "ARP PTYPE", "PTYPE":
+			var ptype uint64
+			ptype, err = parseHexField("ptype", value, 16)
+			l.ARPProtocolType = uint16(ptype)
+		case "ARP OPCODE", "OPCODE":
+			var opcode uint64
+			opcode, err = parseUintField("opcode", value, 10, 16)
+			l.ARPOperation = uint16(opcode)
+		case "ARP MAC SRC", "MACSRC":
+			l.ARPSenderMAC = value
+		case "ARP IP SRC", "IPSRC":
+			l.ARPSenderIP = value
+		case "ARP MAC DST", "MACDST":
+			l.ARPTargetMAC = value
+		case "ARP IP DST", "IPDST":
+			l.ARPTargetIP = value
+		case "IP SRC":
+			l.Source = value
+		case "IP DST":
+			l.Destination = value
+		case "IPv6 SRC":
+			l.Source = value
+			l.IsIPv6 = true
+		case "IPv6 DST":
+			l.Destination = value
+			l.IsIPv6 = true
+		case "IP tos", "IPv6 priority":
+			var tos uint64
+			tos, err = parseHexField("tos", value, 8)
+			l.ToS = uint8(tos)
+		case "IP proto", "Next Header":
+			var protocol uint64
+			protocol, err = parseUintField("proto", value, 10, 8)
+			l.Protocol = uint8(protocol)
+		case "SPT":
+			var port uint64
+			port, err = parseUintField("spt", value, 10, 16)
+			l.SourcePort = uint16(port)
+		case "DPT":
+			var port uint64
+			port, err = parseUintField("dpt", value, 10, 16)
+			l.DestinationPort = uint16(port)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if !seenEtherType {
+		if !seenARP {
+			return &UnmatchedError{Reason: "neither the EtherType (i.e. proto) nor the ARP header is found"}
+		}
+		// the arptables style lacks the EtherType
+		l.EtherType = EtherTypeARP
+	}
+	return nil
+}
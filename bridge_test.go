@@ -0,0 +1,121 @@
+package iptables
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBridge(t *testing.T) {
+	type TestCase struct {
+		name     string
+		line     string
+		expected *BridgeLog
+	}
+
+	testCases := []*TestCase{
+		{
+			name: "arp",
+			line: "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] BR-ARP IN=br0 OUT= MAC source = 52:54:00:12:35:02 MAC dest = ff:ff:ff:ff:ff:ff proto = 0x0806 ARP HTYPE=1, PTYPE=0x0800, OPCODE=1 ARP MAC SRC=52:54:00:12:35:02 ARP IP SRC=10.0.2.2 ARP MAC DST=00:00:00:00:00:00 ARP IP DST=10.0.2.15",
+			expected: &BridgeLog{
+				Timestamp:       "Jul 21 05:31:48",
+				Hostname:        "ubuntu-jammy",
+				KernelTimestamp: 14479.122228,
+				Prefix:          "BR-ARP",
+				InputInterface:  "br0",
+				SourceMAC:       "52:54:00:12:35:02",
+				DestinationMAC:  "ff:ff:ff:ff:ff:ff",
+				EtherType:       EtherTypeARP,
+				ARPHardwareType: 1,
+				ARPProtocolType: 0x0800,
+				ARPOperation:    1,
+				ARPSenderMAC:    "52:54:00:12:35:02",
+				ARPSenderIP:     "10.0.2.2",
+				ARPTargetMAC:    "00:00:00:00:00:00",
+				ARPTargetIP:     "10.0.2.15",
+			},
+		},
+		{
+			name: "ipv4",
+			line: "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] BR-IP IN=br0 OUT=eth1 MAC source = 52:54:00:12:35:02 MAC dest = 00:b3:dd:bc:29:e1 proto = 0x0800 IP SRC=10.0.2.2 IP DST=10.0.2.15, IP tos=0x10, IP proto=6 SPT=54321 DPT=22",
+			expected: &BridgeLog{
+				Timestamp:       "Jul 21 05:31:48",
+				Hostname:        "ubuntu-jammy",
+				KernelTimestamp: 14479.122228,
+				Prefix:          "BR-IP",
+				InputInterface:  "br0",
+				OutputInterface: "eth1",
+				SourceMAC:       "52:54:00:12:35:02",
+				DestinationMAC:  "00:b3:dd:bc:29:e1",
+				EtherType:       EtherTypeIPv4,
+				Source:          "10.0.2.2",
+				Destination:     "10.0.2.15",
+				ToS:             0x10,
+				Protocol:        6,
+				SourcePort:      54321,
+				DestinationPort: 22,
+			},
+		},
+		{
+			name: "ipv6",
+			line: "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] IN=br0 OUT= MAC source = 52:54:00:12:35:02 MAC dest = 33:33:00:00:00:01 proto = 0x86dd IPv6 SRC=fe80::1 IPv6 DST=ff02::1, IPv6 priority=0x0, Next Header=17 SPT=546 DPT=547",
+			expected: &BridgeLog{
+				Timestamp:       "Jul 21 05:31:48",
+				Hostname:        "ubuntu-jammy",
+				KernelTimestamp: 14479.122228,
+				InputInterface:  "br0",
+				SourceMAC:       "52:54:00:12:35:02",
+				DestinationMAC:  "33:33:00:00:00:01",
+				EtherType:       EtherTypeIPv6,
+				Source:          "fe80::1",
+				Destination:     "ff02::1",
+				IsIPv6:          true,
+				Protocol:        17,
+				SourcePort:      546,
+				DestinationPort: 547,
+			},
+		},
+		{
+			name: "arptables style",
+			line: "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] ARP-IN IN=eth0 OUT= ARP HTYPE=1 PTYPE=0x0800 OPCODE=2 MACSRC=52:54:00:12:35:02 IPSRC=10.0.2.2 MACDST=00:b3:dd:bc:29:e1 IPDST=10.0.2.15",
+			expected: &BridgeLog{
+				Timestamp:       "Jul 21 05:31:48",
+				Hostname:        "ubuntu-jammy",
+				KernelTimestamp: 14479.122228,
+				Prefix:          "ARP-IN",
+				InputInterface:  "eth0",
+				EtherType:       EtherTypeARP,
+				ARPHardwareType: 1,
+				ARPProtocolType: 0x0800,
+				ARPOperation:    2,
+				ARPSenderMAC:    "52:54:00:12:35:02",
+				ARPSenderIP:     "10.0.2.2",
+				ARPTargetMAC:    "00:b3:dd:bc:29:e1",
+				ARPTargetIP:     "10.0.2.15",
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		parsedLog, err := ParseBridge(testCase.line)
+		assert.NoError(t, err, testCase.name)
+		assert.False(t, parsedLog.TimestampParsed.IsZero(), testCase.name)
+		parsedLog.TimestampParsed = testCase.expected.TimestampParsed
+		assert.Equal(t, testCase.expected, parsedLog, testCase.name)
+		assert.Equal(t, testCase.expected.EtherType == EtherTypeARP, parsedLog.IsARP(), testCase.name)
+	}
+}
+
+func TestParseBridge_Error(t *testing.T) {
+	_, err := ParseBridge("Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] IN=br0 OUT= SRC=10.0.2.2 DST=10.0.2.15 LEN=60 TTL=64 PROTO=UDP")
+	assert.ErrorIs(t, err, ErrLogFormatUnmatched)
+	var unmatchedErr *UnmatchedError
+	assert.ErrorAs(t, err, &unmatchedErr)
+	assert.Equal(t, "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] IN=br0 OUT= SRC=10.0.2.2 DST=10.0.2.15 LEN=60 TTL=64 PROTO=UDP", unmatchedErr.Line)
+
+	_, err = ParseBridge("Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] IN=br0 OUT= MAC source = 52:54:00:12:35:02 MAC dest = ff:ff:ff:ff:ff:ff proto = 0806")
+	assert.ErrorIs(t, err, ErrStringToNumberConversionFailed)
+	var conversionErr *FieldConversionError
+	assert.ErrorAs(t, err, &conversionErr)
+	assert.Equal(t, "proto", conversionErr.Field)
+}
@@ -0,0 +1,18 @@
+package iptables
+
+import (
+	"maps"
+)
+
+// Clone returns a deep copy of the log, i.e. Extra and the inner packet (see Log.Inner) are copied as well, so that mutating the copy never affects the original.
+// This is useful to keep a log beyond the next call of ParseInto that reuses the original (e.g. by sync.Pool), or to redact the copy (e.g. by Anonymize) while keeping the original.
+// The presence of the fields (see Log.Has) is copied as well. This returns nil if the log is nil.
+func (l *Log) Clone() *Log {
+	if l == nil {
+		return nil
+	}
+	c := *l
+	c.Extra = maps.Clone(l.Extra)
+	c.Inner = l.Inner.Clone()
+	return &c
+}
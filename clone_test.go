@@ -0,0 +1,48 @@
+package iptables
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLog_Clone(t *testing.T) {
+	p, err := NewParser(WithRetainRaw(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	original, err := p.Parse("Jul 21 05:38:28 ubuntu-jammy kernel: [14879.600492] IN=enp0s3 OUT= SRC=192.0.2.1 DST=10.0.2.15 LEN=576 TOS=0x00 PREC=0xC0 TTL=254 ID=39147 PROTO=ICMP TYPE=3 CODE=3 [SRC=10.0.2.15 DST=198.51.100.7 LEN=56 PROTO=UDP SPT=40000 DPT=0 ] MARK=0x0 CT=NEW")
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := p.Parse(original.Raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cloned := original.Clone()
+	assert.Equal(t, original, cloned)
+	assert.True(t, cloned.Has(FieldInner))
+	assert.Equal(t, original.Raw, cloned.Raw)
+
+	// mutating the copy doesn't affect the original
+	cloned.Source = "192.0.2.2"
+	cloned.Extra["CT"] = "ESTABLISHED"
+	cloned.Extra["NEW"] = "1"
+	cloned.Inner.Destination = "198.51.100.8"
+	assert.NoError(t, cloned.Anonymize(AnonymizeOptions{IPv4PrefixLen: 24}))
+	assert.Equal(t, snapshot, original)
+
+	// and vice versa
+	original.Inner.SourcePort = 1
+	assert.Equal(t, uint16(40000), cloned.Inner.SourcePort)
+
+	// the copy survives the reuse of the original by ParseInto
+	cloned = original.Clone()
+	assert.NoError(t, p.ParseInto("Jul 21 05:38:28 ubuntu-jammy kernel: [14879.600492] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=84 TOS=0x00 PREC=0x00 TTL=64 ID=6495 DF PROTO=ICMP TYPE=8 CODE=0 ID=1 SEQ=3", original))
+	assert.Equal(t, "192.0.2.1", cloned.Source)
+	assert.Equal(t, "198.51.100.7", cloned.Inner.Destination)
+
+	assert.Nil(t, (*Log)(nil).Clone())
+	assert.Nil(t, (&Log{}).Clone().Extra)
+}
@@ -0,0 +1,38 @@
+package iptables
+
+import (
+	"strings"
+)
+
+// The conntrack states that ConntrackState returns.
+const (
+	ConntrackStateNew         = "NEW"
+	ConntrackStateEstablished = "ESTABLISHED"
+	ConntrackStateRelated     = "RELATED"
+	ConntrackStateInvalid     = "INVALID"
+	ConntrackStateUntracked   = "UNTRACKED"
+)
+
+// conntrackStateKeys are the keys of Log.Extra that the stateful logging rulesets use for the conntrack state, in the order of the priority.
+var conntrackStateKeys = []string{"CTSTATE", "CT", "STATE"}
+
+// ConntrackState returns the conntrack state of the packet (e.g. "NEW" and "ESTABLISHED"), which is logged by the stateful logging rulesets as CTSTATE= (or CT= and STATE=).
+// The state is taken from Log.Extra, so it is not available when WithExtraFields is disabled. The second return value is false if there is no such field.
+func (l *Log) ConntrackState() (string, bool) {
+	for _, key := range conntrackStateKeys {
+		if state, ok := l.Extra[key]; ok {
+			return state, true
+		}
+	}
+	return "", false
+}
+
+// ConntrackStates returns the conntrack states of the packet, splitting the comma-separated state (e.g. "RELATED,ESTABLISHED"); see ConntrackState.
+// This returns nil if there is no such field.
+func (l *Log) ConntrackStates() []string {
+	state, ok := l.ConntrackState()
+	if !ok || state == "" {
+		return nil
+	}
+	return strings.Split(state, ",")
+}
@@ -0,0 +1,52 @@
+package iptables
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLog_ConntrackState(t *testing.T) {
+	type TestCase struct {
+		line           string
+		expectedState  string
+		expectedOK     bool
+		expectedStates []string
+	}
+
+	testCases := []*TestCase{
+		{
+			line:           "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] INPUT-NEW: IN=enp0s3 OUT= SRC=10.0.2.2 DST=10.0.2.15 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=1234 DF PROTO=TCP SPT=54832 DPT=22 WINDOW=64240 RES=0x00 SYN URGP=0 CTSTATE=NEW",
+			expectedState:  ConntrackStateNew,
+			expectedOK:     true,
+			expectedStates: []string{ConntrackStateNew},
+		},
+		{
+			line:           "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] INPUT-EST: IN=enp0s3 OUT= SRC=10.0.2.2 DST=10.0.2.15 LEN=52 TOS=0x00 PREC=0x00 TTL=64 ID=1235 DF PROTO=TCP SPT=54832 DPT=22 WINDOW=502 RES=0x00 ACK URGP=0 CTSTATE=RELATED,ESTABLISHED MARK=0x1",
+			expectedState:  "RELATED,ESTABLISHED",
+			expectedOK:     true,
+			expectedStates: []string{ConntrackStateRelated, ConntrackStateEstablished},
+		},
+		{
+			line:           "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] INPUT-INV: IN=enp0s3 OUT= SRC=10.0.2.2 DST=10.0.2.15 LEN=40 TOS=0x00 PREC=0x00 TTL=64 ID=1236 PROTO=TCP SPT=54832 DPT=22 WINDOW=0 RES=0x00 RST URGP=0 CT=INVALID",
+			expectedState:  ConntrackStateInvalid,
+			expectedOK:     true,
+			expectedStates: []string{ConntrackStateInvalid},
+		},
+		{
+			line:           "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] INPUT-DROP: IN=enp0s3 OUT= SRC=10.0.2.2 DST=10.0.2.15 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=1234 DF PROTO=TCP SPT=54832 DPT=22 WINDOW=64240 RES=0x00 SYN URGP=0",
+			expectedState:  "",
+			expectedOK:     false,
+			expectedStates: nil,
+		},
+	}
+
+	for _, testCase := range testCases {
+		parsedLog, err := Parse(testCase.line)
+		assert.NoError(t, err)
+		state, ok := parsedLog.ConntrackState()
+		assert.Equal(t, testCase.expectedState, state)
+		assert.Equal(t, testCase.expectedOK, ok)
+		assert.Equal(t, testCase.expectedStates, parsedLog.ConntrackStates())
+	}
+}
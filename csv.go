@@ -0,0 +1,136 @@
+package iptables
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CSVWriter writes logs to an io.Writer as CSV (or TSV with WithComma), i.e. one record per log in a stable column order.
+// The columns are the JSON names of the fields (e.g. "source" and "sourcePort"); by default, the fields of DefaultFieldOrder() (i.e. every field except "inner") are written in that order.
+// The absent fields (see Log.Has) are written as empty cells rather than zero, except that the booleans are always written as "true" or "false".
+// "extra" is written as the space-separated KEY=VALUE pairs sorted by the key, and "inner" is written as the packet fields of the inner packet (e.g. "SRC=... DST=... PROTO=UDP ...").
+// A CSVWriter is not safe for concurrent use.
+type CSVWriter struct {
+	w       *csv.Writer
+	columns []Field
+	record  []string
+}
+
+// CSVWriterOption configures the behavior of the CSVWriter.
+type CSVWriterOption func(w *CSVWriter) error
+
+// WithColumns specifies the columns to write by the JSON names of the fields, in the given order.
+// This returns an error on NewCSVWriter if a column is unknown.
+func WithColumns(columns []string) CSVWriterOption {
+	return func(w *CSVWriter) error {
+		fields := make([]Field, 0, len(columns))
+		for _, column := range columns {
+			f, ok := fieldsByName[column]
+			if !ok {
+				return fmt.Errorf("unknown column %q", column)
+			}
+			fields = append(fields, f)
+		}
+		w.columns = fields
+		return nil
+	}
+}
+
+// WithComma specifies the field delimiter, e.g. '\t' for TSV. The default is ','.
+func WithComma(comma rune) CSVWriterOption {
+	return func(w *CSVWriter) error {
+		w.w.Comma = comma
+		return nil
+	}
+}
+
+// NewCSVWriter makes a new CSVWriter that writes to the given writer.
+// This returns an error if the options are invalid.
+func NewCSVWriter(w io.Writer, opts ...CSVWriterOption) (*CSVWriter, error) {
+	cw := &CSVWriter{w: csv.NewWriter(w), columns: DefaultFieldOrder()}
+	for _, opt := range opts {
+		if err := opt(cw); err != nil {
+			return nil, err
+		}
+	}
+	cw.record = make([]string, len(cw.columns))
+	return cw, nil
+}
+
+// WriteHeader writes the record of the column names.
+func (w *CSVWriter) WriteHeader() error {
+	for i, f := range w.columns {
+		w.record[i] = f.String()
+	}
+	return w.w.Write(w.record)
+}
+
+// Write writes the given log as a record.
+// The records are buffered; call Flush to write them to the underlying writer.
+func (w *CSVWriter) Write(l *Log) error {
+	for i, f := range w.columns {
+		w.record[i] = csvCell(l, f)
+	}
+	return w.w.Write(w.record)
+}
+
+// Flush writes the buffered records to the underlying writer, and returns the error of the writing if any.
+func (w *CSVWriter) Flush() error {
+	w.w.Flush()
+	return w.w.Error()
+}
+
+// csvCell renders the given field of the log as a cell.
+func csvCell(l *Log, f Field) string {
+	switch v := fieldDefs[f].get(l).(type) {
+	case bool:
+		return strconv.FormatBool(v)
+	case string:
+		return v
+	case map[string]string:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		pairs := make([]string, len(keys))
+		for i, key := range keys {
+			pairs[i] = key + "=" + v[key]
+		}
+		return strings.Join(pairs, " ")
+	case *Log:
+		if v == nil {
+			return ""
+		}
+		var b strings.Builder
+		v.formatPacket(&b)
+		return b.String()
+	}
+
+	if !l.Has(f) {
+		return ""
+	}
+	switch v := fieldDefs[f].get(l).(type) {
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case uint32:
+		return strconv.FormatUint(uint64(v), 10)
+	case uint16:
+		return strconv.FormatUint(uint64(v), 10)
+	case uint8:
+		return strconv.FormatUint(uint64(v), 10)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,73 @@
+package iptables
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCSVWriter(t *testing.T) {
+	udpLog, err := Parse("Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=64 TOS=0x00 PREC=0x00 TTL=64 ID=40000 PROTO=UDP SPT=40000 DPT=53 CT=NEW")
+	assert.NoError(t, err)
+	icmpLog, err := Parse("Jul 21 05:38:28 ubuntu-jammy kernel: [14879.600492] IN=enp0s3 OUT= SRC=192.0.2.1 DST=10.0.2.15 LEN=576 TOS=0x00 PREC=0xC0 TTL=254 ID=39147 PROTO=ICMP TYPE=3 CODE=3 [SRC=10.0.2.15 DST=198.51.100.7 LEN=56 PROTO=UDP SPT=40000 DPT=0 ] B=2 A=1")
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	w, err := NewCSVWriter(&buf, WithColumns([]string{"source", "sourcePort", "type", "doNotFragment", "extra", "inner"}))
+	assert.NoError(t, err)
+	assert.NoError(t, w.WriteHeader())
+	assert.NoError(t, w.Write(udpLog))
+	assert.NoError(t, w.Write(icmpLog))
+	assert.NoError(t, w.Flush())
+
+	assert.Equal(t, strings.Join([]string{
+		"source,sourcePort,type,doNotFragment,extra,inner",
+		"10.0.2.15,40000,,false,CT=NEW,",
+		"192.0.2.1,,3,false,A=1 B=2,SRC=10.0.2.15 DST=198.51.100.7 LEN=56 TOS=0x00 PREC=0x00 TTL=0 ID=0 PROTO=UDP SPT=40000 DPT=0",
+		"",
+	}, "\n"), buf.String())
+}
+
+func TestCSVWriter_DefaultColumns(t *testing.T) {
+	parsedLog, err := Parse("Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=64 TOS=0x00 PREC=0x00 TTL=64 ID=40000 DF PROTO=UDP SPT=40000 DPT=53")
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	w, err := NewCSVWriter(&buf, WithComma('\t'))
+	assert.NoError(t, err)
+	assert.NoError(t, w.WriteHeader())
+	assert.NoError(t, w.Write(parsedLog))
+	assert.NoError(t, w.Flush())
+
+	r := csv.NewReader(&buf)
+	r.Comma = '\t'
+	records, err := r.ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.Len(t, records[0], len(DefaultFieldOrder()))
+	for i, f := range DefaultFieldOrder() {
+		assert.Equal(t, f.String(), records[0][i])
+	}
+
+	record := make(map[string]string, len(records[0]))
+	for i, column := range records[0] {
+		record[column] = records[1][i]
+	}
+	assert.Equal(t, "10.0.2.15", record["source"])
+	assert.Equal(t, "14479.122228", record["kernelTimestamp"])
+	assert.Equal(t, "0", record["tos"])
+	assert.Equal(t, "true", record["doNotFragment"])
+	assert.Equal(t, "false", record["syn"])
+	assert.Equal(t, "", record["sequence"])
+	assert.Equal(t, "", record["uid"])
+	assert.NotEmpty(t, record["timestampParsed"])
+}
+
+func TestNewCSVWriter_UnknownColumn(t *testing.T) {
+	w, err := NewCSVWriter(&bytes.Buffer{}, WithColumns([]string{"source", "bogus"}))
+	assert.Nil(t, w)
+	assert.EqualError(t, err, `unknown column "bogus"`)
+}
@@ -0,0 +1,48 @@
+package iptables
+
+import (
+	"iter"
+	"time"
+)
+
+// Dedup collapses the consecutive logs that have the same key into the first one of them, like "last message repeated N times" of syslog daemons.
+// This yields the representative (i.e. the first) log of each run and the number of the logs in the run.
+// A run ends when the key changes, or when a log is later than the representative by more than the window; a non-positive window means no limit.
+// The elapsed time is measured by TimestampParsed, or by KernelTimestamp when either TimestampParsed is zero.
+func Dedup(logs iter.Seq[*Log], key func(*Log) string, window time.Duration) iter.Seq2[*Log, int] {
+	return func(yield func(*Log, int) bool) {
+		var (
+			representative    *Log
+			representativeKey string
+			count             int
+		)
+		for l := range logs {
+			k := key(l)
+			if representative != nil && k == representativeKey && withinWindow(representative, l, window) {
+				count++
+				continue
+			}
+			if representative != nil && !yield(representative, count) {
+				return
+			}
+			representative, representativeKey, count = l, k, 1
+		}
+		if representative != nil {
+			yield(representative, count)
+		}
+	}
+}
+
+// withinWindow reports whether the log is later than the base by the window or less.
+func withinWindow(base *Log, l *Log, window time.Duration) bool {
+	if window <= 0 {
+		return true
+	}
+	var elapsed time.Duration
+	if base.TimestampParsed.IsZero() || l.TimestampParsed.IsZero() {
+		elapsed = time.Duration((l.KernelTimestamp - base.KernelTimestamp) * float64(time.Second))
+	} else {
+		elapsed = l.TimestampParsed.Sub(base.TimestampParsed)
+	}
+	return elapsed <= window
+}
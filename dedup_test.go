@@ -0,0 +1,70 @@
+package iptables
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDedup(t *testing.T) {
+	base := time.Date(2022, time.July, 21, 5, 31, 48, 0, time.UTC)
+	logs := []*Log{
+		{Source: "10.0.2.2", TimestampParsed: base},
+		{Source: "10.0.2.2", TimestampParsed: base.Add(1 * time.Second)},
+		{Source: "10.0.2.2", TimestampParsed: base.Add(2 * time.Second)},
+		{Source: "10.0.2.3", TimestampParsed: base.Add(3 * time.Second)},
+		{Source: "10.0.2.2", TimestampParsed: base.Add(4 * time.Second)},
+		{Source: "10.0.2.2", TimestampParsed: base.Add(20 * time.Second)},
+		// the kernel timestamp is used when the timestamp is not parsed
+		{Source: "10.0.2.4", KernelTimestamp: 100},
+		{Source: "10.0.2.4", KernelTimestamp: 105},
+		{Source: "10.0.2.4", KernelTimestamp: 120},
+	}
+	key := func(l *Log) string { return l.Source }
+
+	type TestCase struct {
+		window          time.Duration
+		expectedIndices []int
+		expectedCounts  []int
+	}
+
+	testCases := []*TestCase{
+		{
+			window:          10 * time.Second,
+			expectedIndices: []int{0, 3, 4, 5, 6, 8},
+			expectedCounts:  []int{3, 1, 1, 1, 2, 1},
+		},
+		{
+			window:          0,
+			expectedIndices: []int{0, 3, 4, 6},
+			expectedCounts:  []int{3, 1, 2, 3},
+		},
+	}
+
+	for _, testCase := range testCases {
+		var indices, counts []int
+		for l, count := range Dedup(slices.Values(logs), key, testCase.window) {
+			indices = append(indices, slices.Index(logs, l))
+			counts = append(counts, count)
+		}
+		assert.Equal(t, testCase.expectedIndices, indices, "window = %s", testCase.window)
+		assert.Equal(t, testCase.expectedCounts, counts, "window = %s", testCase.window)
+	}
+}
+
+func TestDedup_Break(t *testing.T) {
+	logs := []*Log{{Source: "10.0.2.2"}, {Source: "10.0.2.3"}, {Source: "10.0.2.4"}}
+
+	n := 0
+	for range Dedup(slices.Values(logs), func(l *Log) string { return l.Source }, 0) {
+		n++
+		break
+	}
+	assert.Equal(t, 1, n)
+
+	for range Dedup(slices.Values([]*Log{}), func(l *Log) string { return l.Source }, 0) {
+		t.Fatal("no log should be yielded")
+	}
+}
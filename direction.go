@@ -0,0 +1,16 @@
+package iptables
+
+// IsInbound reports whether the packet is inbound, i.e. it has the input interface but no output interface.
+func (l *Log) IsInbound() bool {
+	return l.InputInterface != "" && l.OutputInterface == ""
+}
+
+// IsOutbound reports whether the packet is outbound, i.e. it has the output interface but no input interface.
+func (l *Log) IsOutbound() bool {
+	return l.InputInterface == "" && l.OutputInterface != ""
+}
+
+// IsForwarded reports whether the packet is forwarded, i.e. it has both the input and output interfaces.
+func (l *Log) IsForwarded() bool {
+	return l.InputInterface != "" && l.OutputInterface != ""
+}
@@ -0,0 +1,31 @@
+package iptables
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLog_Direction(t *testing.T) {
+	type TestCase struct {
+		inputInterface    string
+		outputInterface   string
+		expectedInbound   bool
+		expectedOutbound  bool
+		expectedForwarded bool
+	}
+
+	testCases := []*TestCase{
+		{inputInterface: "eth0", outputInterface: "", expectedInbound: true, expectedOutbound: false, expectedForwarded: false},
+		{inputInterface: "", outputInterface: "eth0", expectedInbound: false, expectedOutbound: true, expectedForwarded: false},
+		{inputInterface: "eth0", outputInterface: "eth1", expectedInbound: false, expectedOutbound: false, expectedForwarded: true},
+		{inputInterface: "", outputInterface: "", expectedInbound: false, expectedOutbound: false, expectedForwarded: false},
+	}
+
+	for _, testCase := range testCases {
+		l := &Log{InputInterface: testCase.inputInterface, OutputInterface: testCase.outputInterface}
+		assert.Equal(t, testCase.expectedInbound, l.IsInbound())
+		assert.Equal(t, testCase.expectedOutbound, l.IsOutbound())
+		assert.Equal(t, testCase.expectedForwarded, l.IsForwarded())
+	}
+}
@@ -0,0 +1,33 @@
+package iptables
+
+import (
+	"regexp"
+)
+
+// dmesgPreambleRe matches the preamble of the lines of the kernel ring buffer (i.e. the output of dmesg), which is the kernel timestamp alone, e.g. "[14879.600492] ".
+// The printk caller ID (e.g. "[T1234]" of CONFIG_PRINTK_CALLER) can follow the kernel timestamp.
+var dmesgPreambleRe = func() *preambleRegexp {
+	re := regexp.MustCompile(`^\[\s*(?P<kernelTimestamp>\d+\.\d+)](?:\[\s*[TC]\d+])?\s+`)
+	return &preambleRegexp{
+		re:                 re,
+		timestampIdx:       -1,
+		hostnameIdx:        -1,
+		kernelTimestampIdx: re.SubexpIndex("kernelTimestamp"),
+		facilityMarkerIdx:  -1,
+	}
+}()
+
+// ParseDmesg parses an iptables line of the dmesg output; see Parser.ParseDmesg for details.
+func ParseDmesg(line string) (*Log, error) {
+	return defaultParser.ParseDmesg(line)
+}
+
+// ParseDmesg parses an iptables line of the dmesg output, which has no syslog framing, e.g. "[14879.600492] OUT-LOG: IN= OUT=enp0s3 ...".
+// This is the most direct source of the logs, e.g. on the systems without the syslog daemon (e.g. WSL and the containers).
+// Log.Timestamp and Log.Hostname are left empty and absent (see Log.Has), since the line doesn't have them; Log.KernelTimestamp is the seconds since the boot.
+// The raw output (i.e. dmesg -r, e.g. "<4>[14879.600492] ...") is parsed with WithPriority. The human-readable timestamps (e.g. dmesg -T) are not supported.
+// The options of the Parser are respected except WithTag and WithRegexp, which are for the syslog preamble.
+func (p *Parser) ParseDmesg(line string) (*Log, error) {
+	l := &Log{}
+	return p.result(l, p.parse(line, l, dmesgPreambleRe))
+}
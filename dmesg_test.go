@@ -0,0 +1,105 @@
+package iptables
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDmesg(t *testing.T) {
+	type TestCase struct {
+		name             string
+		line             string
+		opts             []Option
+		expectedPrefix   string
+		expectedKernelTS float64
+		expectedSeverity uint8
+	}
+
+	testCases := []*TestCase{
+		{
+			name:             "with prefix",
+			line:             "[14479.122228] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=93.184.216.34 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=64125 DF PROTO=TCP SPT=54832 DPT=80 WINDOW=64240 RES=0x00 SYN URGP=0",
+			expectedPrefix:   "OUT-LOG:",
+			expectedKernelTS: 14479.122228,
+		},
+		{
+			name:             "without prefix",
+			line:             "[    5.123456] IN= OUT=enp0s3 SRC=10.0.2.15 DST=93.184.216.34 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=64125 DF PROTO=TCP SPT=54832 DPT=80 WINDOW=64240 RES=0x00 SYN URGP=0",
+			expectedPrefix:   "",
+			expectedKernelTS: 5.123456,
+		},
+		{
+			name:             "printk caller ID",
+			line:             "[14479.122228][    T0] [UFW BLOCK] IN= OUT=enp0s3 SRC=10.0.2.15 DST=93.184.216.34 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=64125 DF PROTO=TCP SPT=54832 DPT=80 WINDOW=64240 RES=0x00 SYN URGP=0",
+			expectedPrefix:   "[UFW BLOCK]",
+			expectedKernelTS: 14479.122228,
+		},
+		{
+			name:             "raw output",
+			line:             "<4>[14479.122228] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=93.184.216.34 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=64125 DF PROTO=TCP SPT=54832 DPT=80 WINDOW=64240 RES=0x00 SYN URGP=0\r\n",
+			opts:             []Option{WithPriority(true)},
+			expectedPrefix:   "OUT-LOG:",
+			expectedKernelTS: 14479.122228,
+			expectedSeverity: 4,
+		},
+	}
+
+	for _, testCase := range testCases {
+		p, err := NewParser(testCase.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsedLog, err := p.ParseDmesg(testCase.line)
+		if err != nil {
+			t.Fatalf("%s: %v", testCase.name, err)
+		}
+		assert.Equal(t, testCase.expectedPrefix, parsedLog.Prefix, testCase.name)
+		assert.Equal(t, testCase.expectedKernelTS, parsedLog.KernelTimestamp, testCase.name)
+		assert.True(t, parsedLog.Has(FieldKernelTimestamp), testCase.name)
+		assert.Equal(t, testCase.expectedSeverity, parsedLog.Severity, testCase.name)
+		assert.Empty(t, parsedLog.Timestamp, testCase.name)
+		assert.True(t, parsedLog.TimestampParsed.IsZero(), testCase.name)
+		assert.Empty(t, parsedLog.Hostname, testCase.name)
+		assert.False(t, parsedLog.Has(FieldTimestamp), testCase.name)
+		assert.False(t, parsedLog.Has(FieldHostname), testCase.name)
+		assert.Equal(t, "10.0.2.15", parsedLog.Source, testCase.name)
+		assert.Equal(t, uint16(80), parsedLog.DestinationPort, testCase.name)
+		assert.True(t, parsedLog.Syn, testCase.name)
+	}
+}
+
+func TestParseDmesg_Unmatched(t *testing.T) {
+	lines := []string{
+		"",
+		// the syslog framing
+		"Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] IN= OUT=enp0s3 SRC=10.0.2.15 DST=93.184.216.34 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=64125 DF PROTO=TCP SPT=54832 DPT=80",
+		// the human-readable timestamp
+		"[Thu Jul 21 05:31:48 2022] IN= OUT=enp0s3 SRC=10.0.2.15 DST=93.184.216.34 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=64125 DF PROTO=TCP SPT=54832 DPT=80",
+		// not an iptables log
+		"[    0.000000] Linux version 5.15.90.1-microsoft-standard-WSL2",
+	}
+
+	for _, line := range lines {
+		_, err := ParseDmesg(line)
+		assert.ErrorIs(t, err, ErrLogFormatUnmatched, line)
+	}
+}
+
+func TestParseDmesg_WithCollectAllErrors(t *testing.T) {
+	p, err := NewParser(WithCollectAllErrors(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsedLog, err := p.ParseDmesg("[14879.600492] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=93.184.216.34 LEN=60 TOS=0x00 PREC=0x00 TTL=6A ID=64125 DF PROTO=TCP SPT=54832 DPT=8O")
+	assert.ErrorIs(t, err, ErrStringToNumberConversionFailed)
+	if assert.NotNil(t, parsedLog) {
+		assert.Equal(t, 14879.600492, parsedLog.KernelTimestamp)
+		assert.Equal(t, "OUT-LOG:", parsedLog.Prefix)
+		assert.Equal(t, uint16(54832), parsedLog.SourcePort)
+	}
+
+	parsedLog, err = p.ParseDmesg("[    0.000000] Linux version 5.15.90.1-microsoft-standard-WSL2")
+	assert.ErrorIs(t, err, ErrLogFormatUnmatched)
+	assert.Nil(t, parsedLog)
+}
@@ -0,0 +1,224 @@
+package iptables
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// Encoder writes logs to an io.Writer as newline-delimited JSON (NDJSON), i.e. one compact JSON object per line.
+// An Encoder is not safe for concurrent use.
+type Encoder struct {
+	w             io.Writer
+	buf           bytes.Buffer
+	enc           *json.Encoder
+	omitAbsent    bool
+	tcpFlagsArray bool
+	protocolAware bool
+}
+
+// NewEncoder makes a new Encoder that writes to the given writer.
+func NewEncoder(w io.Writer) *Encoder {
+	e := &Encoder{w: w}
+	e.enc = json.NewEncoder(&e.buf)
+	e.enc.SetEscapeHTML(false)
+	return e
+}
+
+// SetOmitAbsent specifies whether to omit the fields that are absent in the parsed line (see Log.Has), e.g. the ports and the TCP flags of an ICMP packet.
+// This is disabled by default, i.e. every field is written as well as json.Marshal does.
+func (e *Encoder) SetOmitAbsent(omitAbsent bool) {
+	e.omitAbsent = omitAbsent
+}
+
+// SetTCPFlagsArray specifies whether to write the TCP flags as an array of the names (e.g. "flags":["SYN","ACK"]; see Log.TCPFlags) instead of the booleans (e.g. "syn":true,"ack":true,...).
+// The array takes the place of the booleans, and it is always written even if no flag is set (i.e. "flags":[]). When SetOmitAbsent is enabled as well, the array is omitted only if every flag is absent.
+// Note that the array is not decoded by json.Unmarshal, i.e. the flags are lost by the round trip.
+// This is disabled by default for backward compatibility.
+func (e *Encoder) SetTCPFlagsArray(tcpFlagsArray bool) {
+	e.tcpFlagsArray = tcpFlagsArray
+}
+
+// SetProtocolAware specifies whether to write only the fields that are relevant to the protocol (see Log.ProtocolFields), e.g. the type and the code but neither the ports nor the TCP flags for ICMP.
+// Unlike SetOmitAbsent, this omits the fields by the protocol rather than by the line, so the fields of a protocol are always written even if they are zero; the both can be enabled together.
+// The inner packet of ICMP is written by its own protocol. This is disabled by default.
+func (e *Encoder) SetProtocolAware(protocolAware bool) {
+	e.protocolAware = protocolAware
+}
+
+// Encode writes the given log as a line of JSON.
+// The buffer is reused across the calls, and each line is written to the underlying writer by a single Write call.
+func (e *Encoder) Encode(l *Log) error {
+	e.buf.Reset()
+	if err := e.encode(l); err != nil {
+		return err
+	}
+	_, err := e.w.Write(e.buf.Bytes())
+	return err
+}
+
+// encode appends the given log as a line of JSON to the buffer.
+func (e *Encoder) encode(l *Log) error {
+	if e.omitAbsent || e.tcpFlagsArray || e.protocolAware {
+		if err := e.encodeFields(l); err != nil {
+			return err
+		}
+		e.buf.WriteByte('\n')
+		return nil
+	}
+	return e.enc.Encode(l)
+}
+
+// encodeFields writes the given log as a JSON object field by field into the buffer, skipping the absent fields if SetOmitAbsent is enabled and the irrelevant ones if SetProtocolAware is enabled.
+func (e *Encoder) encodeFields(l *Log) error {
+	var relevant FieldSet
+	if e.protocolAware {
+		relevant = l.ProtocolFields()
+	}
+
+	e.buf.WriteByte('{')
+	first := true
+	for f := Field(0); int(f) < numFields; f++ {
+		if e.protocolAware && !relevant.Has(f) {
+			// the TCP flags are relevant or irrelevant all together, so is the array of them
+			continue
+		}
+		name := fieldDefs[f].name
+		var value any
+		if e.tcpFlagsArray && isTCPFlagField(f) {
+			if f != tcpFlagFieldsBegin {
+				// the array is written at the place of the first flag
+				continue
+			}
+			if e.omitAbsent && !l.hasAnyTCPFlagField() {
+				continue
+			}
+			name, value = "flags", l.TCPFlags()
+		} else {
+			if e.omitAbsent && !l.Has(f) {
+				continue
+			}
+			value = fieldDefs[f].get(l)
+		}
+		if (f == FieldExtra && len(l.Extra) == 0) || (f == FieldInner && l.Inner == nil) {
+			// omitempty
+			continue
+		}
+
+		if !first {
+			e.buf.WriteByte(',')
+		}
+		first = false
+		e.buf.WriteByte('"')
+		e.buf.WriteString(name)
+		e.buf.WriteString(`":`)
+
+		if f == FieldInner {
+			if err := e.encodeFields(l.Inner); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := e.enc.Encode(value); err != nil {
+			return err
+		}
+		// json.Encoder terminates each value with a newline
+		e.buf.Truncate(e.buf.Len() - 1)
+	}
+	e.buf.WriteByte('}')
+	return nil
+}
+
+// The range of the fields of the TCP flags, which are contiguous in Field.
+const (
+	tcpFlagFieldsBegin = FieldUrgent
+	tcpFlagFieldsEnd   = FieldNS
+)
+
+func isTCPFlagField(f Field) bool {
+	return f >= tcpFlagFieldsBegin && f <= tcpFlagFieldsEnd
+}
+
+// hasAnyTCPFlagField reports whether any field of the TCP flags is present.
+func (l *Log) hasAnyTCPFlagField() bool {
+	for f := tcpFlagFieldsBegin; f <= tcpFlagFieldsEnd; f++ {
+		if l.Has(f) {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrEncoderClosed is an error that occurs when encoding a log by the closed ArrayEncoder.
+var ErrEncoderClosed = errors.New("encoder is closed")
+
+// ArrayEncoder writes logs to an io.Writer as a JSON array, i.e. "[", the comma-separated JSON objects (one per line), and "]".
+// Each log is written as soon as it is encoded, so the memory usage doesn't grow with the number of the logs. Close must be called to terminate the array.
+// An ArrayEncoder is not safe for concurrent use.
+type ArrayEncoder struct {
+	e       *Encoder
+	started bool
+	closed  bool
+}
+
+// NewArrayEncoder makes a new ArrayEncoder that writes to the given writer.
+func NewArrayEncoder(w io.Writer) *ArrayEncoder {
+	return &ArrayEncoder{e: NewEncoder(w)}
+}
+
+// SetOmitAbsent specifies whether to omit the fields that are absent in the parsed line; see Encoder.SetOmitAbsent.
+func (a *ArrayEncoder) SetOmitAbsent(omitAbsent bool) {
+	a.e.SetOmitAbsent(omitAbsent)
+}
+
+// SetTCPFlagsArray specifies whether to write the TCP flags as an array of the names; see Encoder.SetTCPFlagsArray.
+func (a *ArrayEncoder) SetTCPFlagsArray(tcpFlagsArray bool) {
+	a.e.SetTCPFlagsArray(tcpFlagsArray)
+}
+
+// SetProtocolAware specifies whether to write only the fields that are relevant to the protocol; see Encoder.SetProtocolAware.
+func (a *ArrayEncoder) SetProtocolAware(protocolAware bool) {
+	a.e.SetProtocolAware(protocolAware)
+}
+
+// Encode writes the given log as an element of the array, preceded by "[" for the first one or by the comma for the others.
+// This returns ErrEncoderClosed after Close is called.
+func (a *ArrayEncoder) Encode(l *Log) error {
+	if a.closed {
+		return ErrEncoderClosed
+	}
+
+	e := a.e
+	e.buf.Reset()
+	if a.started {
+		e.buf.WriteString(",\n")
+	} else {
+		e.buf.WriteString("[\n")
+	}
+	if err := e.encode(l); err != nil {
+		return err
+	}
+	// the newline is written before the next element or "]"
+	e.buf.Truncate(e.buf.Len() - 1)
+	if _, err := e.w.Write(e.buf.Bytes()); err != nil {
+		return err
+	}
+	a.started = true
+	return nil
+}
+
+// Close terminates the array by writing "]", or writes the empty array (i.e. "[]") if no log has been encoded.
+// Close doesn't close the underlying writer. Calling Close more than once is a no-op.
+func (a *ArrayEncoder) Close() error {
+	if a.closed {
+		return nil
+	}
+	a.closed = true
+	if !a.started {
+		_, err := io.WriteString(a.e.w, "[]\n")
+		return err
+	}
+	_, err := io.WriteString(a.e.w, "\n]\n")
+	return err
+}
@@ -0,0 +1,350 @@
+package iptables
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncoder_Encode(t *testing.T) {
+	input := readerTestTCPLine + "\n" + readerTestICMPLine + "\n"
+
+	var out bytes.Buffer
+	enc := NewEncoder(&out)
+	for l, err := range ParseReader(strings.NewReader(input)) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := enc.Encode(l); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	assert.Len(t, lines, 2)
+
+	expectedProtocols := []string{"TCP", "ICMP"}
+	for i, line := range lines {
+		var decoded Log
+		if err := json.Unmarshal([]byte(line), &decoded); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, expectedProtocols[i], decoded.Protocol)
+
+		var compacted bytes.Buffer
+		if err := json.Compact(&compacted, []byte(line)); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, compacted.String(), line)
+	}
+}
+
+func TestEncoder_EncodeDoesNotEscapeHTML(t *testing.T) {
+	var out bytes.Buffer
+	enc := NewEncoder(&out)
+	if err := enc.Encode(&Log{Prefix: "<DROP>"}); err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, out.String(), `"prefix":"<DROP>"`)
+}
+
+func TestEncoder_SetOmitAbsent(t *testing.T) {
+	type TestCase struct {
+		line     string
+		expected string
+	}
+
+	testCases := []*TestCase{
+		{
+			line:     "Jul 21 05:38:28 ubuntu-jammy kernel: [14879.600492] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=84 TOS=0x00 PREC=0x00 TTL=64 ID=6495 DF PROTO=ICMP TYPE=8 CODE=0 ID=1 SEQ=3",
+			expected: `{"timestamp":"Jul 21 05:38:28","timestampParsed":"2022-07-21T05:38:28Z","hostname":"ubuntu-jammy","kernelTimestamp":14879.600492,"prefix":"OUT-LOG:","inputInterface":"","outputInterface":"enp0s3","source":"10.0.2.15","destination":"8.8.8.8","length":84,"tos":0,"precedence":0,"ttl":64,"id":6495,"doNotFragment":true,"ipVersion":4,"protocol":"ICMP","type":8,"code":0,"icmpId":1,"icmpSeq":3}` + "\n",
+		},
+		{
+			// the ICMP error with the inner packet
+			line:     "Jul 21 05:38:28 ubuntu-jammy kernel: [14879.600492] IN=enp0s3 OUT= SRC=192.0.2.1 DST=10.0.2.15 LEN=576 TOS=0x00 PREC=0xC0 TTL=254 ID=39147 PROTO=ICMP TYPE=3 CODE=3 [SRC=10.0.2.15 DST=198.51.100.7 LEN=56 PROTO=UDP SPT=40000 DPT=0 ] MARK=0x0 CT=NEW",
+			expected: `{"timestamp":"Jul 21 05:38:28","timestampParsed":"2022-07-21T05:38:28Z","hostname":"ubuntu-jammy","kernelTimestamp":14879.600492,"inputInterface":"enp0s3","outputInterface":"","source":"192.0.2.1","destination":"10.0.2.15","length":576,"tos":0,"precedence":192,"ttl":254,"id":39147,"ipVersion":4,"protocol":"ICMP","type":3,"code":3,"mark":0,"hasMark":true,"extra":{"CT":"NEW"},"inner":{"source":"10.0.2.15","destination":"198.51.100.7","length":56,"ipVersion":4,"protocol":"UDP","sourcePort":40000,"destinationPort":0}}` + "\n",
+		},
+	}
+
+	p, err := NewParser(WithYear(2022), WithLocation(time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, testCase := range testCases {
+		parsedLog, err := p.Parse(testCase.line)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var out bytes.Buffer
+		enc := NewEncoder(&out)
+		enc.SetOmitAbsent(true)
+		if err := enc.Encode(parsedLog); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, testCase.expected, out.String())
+	}
+}
+
+func TestEncoder_SetOmitAbsentWithAllFieldsPresent(t *testing.T) {
+	parsedLog, err := Parse(readerTestTCPLine)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for f := Field(0); int(f) < numFields; f++ {
+		parsedLog.present.add(f)
+	}
+
+	// every field must be encoded as well as json.Marshal does
+	expected, err := json.Marshal(parsedLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	enc := NewEncoder(&out)
+	enc.SetOmitAbsent(true)
+	if err := enc.Encode(parsedLog); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, string(expected)+"\n", out.String())
+}
+
+func TestEncoder_SetTCPFlagsArray(t *testing.T) {
+	type TestCase struct {
+		name       string
+		line       string
+		omitAbsent bool
+		expected   string
+	}
+
+	testCases := []*TestCase{
+		{
+			name:       "TCP with omitAbsent",
+			line:       "Jul 20 13:24:22 ubuntu-jammy kernel: [  396.854443] IN=enp0s3 OUT= SRC=10.0.2.2 DST=10.0.2.15 LEN=76 TOS=0x00 PREC=0x00 TTL=64 ID=5525 PROTO=TCP SPT=59076 DPT=22 WINDOW=65535 RES=0x00 ACK PSH URGP=0",
+			omitAbsent: true,
+			expected:   `{"timestamp":"Jul 20 13:24:22","timestampParsed":"2022-07-20T13:24:22Z","hostname":"ubuntu-jammy","kernelTimestamp":396.854443,"inputInterface":"enp0s3","outputInterface":"","source":"10.0.2.2","destination":"10.0.2.15","length":76,"tos":0,"precedence":0,"ttl":64,"id":5525,"ipVersion":4,"protocol":"TCP","sourcePort":59076,"destinationPort":22,"windowSize":65535,"res":0,"flags":["PSH","ACK"],"urgp":0}` + "\n",
+		},
+		{
+			name:       "ICMP with omitAbsent",
+			line:       "Jul 21 05:38:28 ubuntu-jammy kernel: [14879.600492] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=84 TOS=0x00 PREC=0x00 TTL=64 ID=6495 DF PROTO=ICMP TYPE=8 CODE=0 ID=1 SEQ=3",
+			omitAbsent: true,
+			expected:   `{"timestamp":"Jul 21 05:38:28","timestampParsed":"2022-07-21T05:38:28Z","hostname":"ubuntu-jammy","kernelTimestamp":14879.600492,"prefix":"OUT-LOG:","inputInterface":"","outputInterface":"enp0s3","source":"10.0.2.15","destination":"8.8.8.8","length":84,"tos":0,"precedence":0,"ttl":64,"id":6495,"doNotFragment":true,"ipVersion":4,"protocol":"ICMP","type":8,"code":0,"icmpId":1,"icmpSeq":3}` + "\n",
+		},
+		{
+			name:       "ICMP without omitAbsent",
+			line:       "Jul 21 05:38:28 ubuntu-jammy kernel: [14879.600492] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=84 TOS=0x00 PREC=0x00 TTL=64 ID=6495 DF PROTO=ICMP TYPE=8 CODE=0 ID=1 SEQ=3",
+			omitAbsent: false,
+			expected:   `{"facility":0,"severity":0,"hasPriority":false,"timestamp":"Jul 21 05:38:28","timestampParsed":"2022-07-21T05:38:28Z","hostname":"ubuntu-jammy","kernelTimestamp":14879.600492,"prefix":"OUT-LOG:","inputInterface":"","outputInterface":"enp0s3","physInputInterface":"","physOutputInterface":"","macAddress":"","source":"10.0.2.15","destination":"8.8.8.8","length":84,"tos":0,"precedence":0,"ttl":64,"id":6495,"congestionExperienced":false,"doNotFragment":true,"moreFragmentsFollowing":false,"frag":0,"ipOptions":"","isIPv6":false,"ipVersion":4,"trafficClass":0,"flowLabel":0,"protocol":"ICMP","type":8,"code":0,"icmpId":1,"icmpSeq":3,"mtu":0,"spi":0,"ipsecSequence":0,"greKey":0,"greProtocol":0,"sourcePort":0,"destinationPort":0,"checksumCoverage":0,"sequence":0,"ackSequence":0,"windowSize":0,"res":0,"flags":[],"urgp":0,"tcpOption":"","incomplete":false,"incompleteBytes":0,"uid":-1,"gid":-1,"mark":0,"hasMark":false}` + "\n",
+		},
+	}
+
+	p, err := NewParser(WithYear(2022), WithLocation(time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, testCase := range testCases {
+		parsedLog, err := p.Parse(testCase.line)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var out bytes.Buffer
+		enc := NewEncoder(&out)
+		enc.SetOmitAbsent(testCase.omitAbsent)
+		enc.SetTCPFlagsArray(true)
+		if err := enc.Encode(parsedLog); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, testCase.expected, out.String(), testCase.name)
+	}
+}
+
+func TestEncoder_SetProtocolAware(t *testing.T) {
+	type TestCase struct {
+		name          string
+		line          string
+		tcpFlagsArray bool
+		expected      []string
+		unexpected    []string
+	}
+
+	testCases := []*TestCase{
+		{
+			name:       "TCP",
+			line:       readerTestTCPLine,
+			expected:   []string{"source", "ttl", "sourcePort", "sequence", "windowSize", "syn", "ack", "tcpOption"},
+			unexpected: []string{"type", "code", "icmpId", "mtu", "spi", "greKey", "checksumCoverage", "flags"},
+		},
+		{
+			name:          "TCP with tcpFlagsArray",
+			line:          readerTestTCPLine,
+			tcpFlagsArray: true,
+			expected:      []string{"sourcePort", "sequence", "flags"},
+			unexpected:    []string{"type", "syn", "ack"},
+		},
+		{
+			name:       "UDP",
+			line:       "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=64 TOS=0x00 PREC=0x00 TTL=64 ID=40000 PROTO=UDP SPT=40000 DPT=53 LEN=44",
+			expected:   []string{"source", "sourcePort", "destinationPort"},
+			unexpected: []string{"sequence", "syn", "urgp", "tcpOption", "type", "icmpSeq", "checksumCoverage"},
+		},
+		{
+			name:          "ICMP",
+			line:          readerTestICMPLine,
+			tcpFlagsArray: true,
+			expected:      []string{"source", "type", "code", "icmpId", "icmpSeq", "mtu"},
+			unexpected:    []string{"sourcePort", "destinationPort", "sequence", "flags", "spi", "inner"},
+		},
+		{
+			name:       "ESP",
+			line:       "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] IN=enp0s3 OUT= SRC=10.0.2.2 DST=10.0.2.15 LEN=100 TOS=0x00 PREC=0x00 TTL=64 ID=0 PROTO=ESP SPI=0x1000 SEQ=1",
+			expected:   []string{"spi", "ipsecSequence"},
+			unexpected: []string{"sourcePort", "sequence", "type", "greKey"},
+		},
+		{
+			// the protocol that has no transport layer fields
+			name:       "number",
+			line:       "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] IN=enp0s3 OUT= SRC=10.0.2.2 DST=10.0.2.15 LEN=100 TOS=0x00 PREC=0x00 TTL=64 ID=0 PROTO=253",
+			expected:   []string{"source", "protocol"},
+			unexpected: []string{"sourcePort", "sequence", "syn", "type", "spi", "greKey", "checksumCoverage"},
+		},
+		{
+			// every field is written for the unknown protocol
+			name:     "unknown",
+			line:     "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] IN=enp0s3 OUT= SRC=10.0.2.2 DST=10.0.2.15 LEN=100 TOS=0x00 PREC=0x00 TTL=64 ID=0 PROTO=EXPERIMENTAL",
+			expected: []string{"sourcePort", "sequence", "syn", "type", "spi", "greKey", "checksumCoverage"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		parsedLog, err := Parse(testCase.line)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var out bytes.Buffer
+		enc := NewEncoder(&out)
+		enc.SetProtocolAware(true)
+		enc.SetTCPFlagsArray(testCase.tcpFlagsArray)
+		if err := enc.Encode(parsedLog); err != nil {
+			t.Fatal(err)
+		}
+
+		var decoded map[string]any
+		if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+			t.Fatal(err)
+		}
+		for _, key := range testCase.expected {
+			assert.Contains(t, decoded, key, testCase.name)
+		}
+		for _, key := range testCase.unexpected {
+			assert.NotContains(t, decoded, key, testCase.name)
+		}
+	}
+}
+
+func TestArrayEncoder_SetProtocolAware(t *testing.T) {
+	// the inner packet is written by its own protocol
+	parsedLog, err := Parse("Jul 21 05:38:28 ubuntu-jammy kernel: [14879.600492] IN=enp0s3 OUT= SRC=192.0.2.1 DST=10.0.2.15 LEN=576 TOS=0x00 PREC=0xC0 TTL=254 ID=39147 PROTO=ICMP TYPE=3 CODE=3 [SRC=10.0.2.15 DST=198.51.100.7 LEN=56 TOS=0x00 PREC=0x00 TTL=64 ID=12345 PROTO=UDP SPT=40000 DPT=53 LEN=36 ]")
+	assert.NoError(t, err)
+
+	var out bytes.Buffer
+	enc := NewArrayEncoder(&out)
+	enc.SetProtocolAware(true)
+	enc.SetOmitAbsent(true)
+	assert.NoError(t, enc.Encode(parsedLog))
+	assert.NoError(t, enc.Close())
+
+	var decoded []map[string]any
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, decoded, 1)
+	assert.Contains(t, decoded[0], "type")
+	assert.NotContains(t, decoded[0], "sourcePort")
+	// absent in the line
+	assert.NotContains(t, decoded[0], "icmpId")
+
+	inner, ok := decoded[0]["inner"].(map[string]any)
+	if !ok {
+		t.Fatalf("unexpected inner: %v", decoded[0]["inner"])
+	}
+	assert.Contains(t, inner, "sourcePort")
+	assert.NotContains(t, inner, "type")
+	assert.NotContains(t, inner, "syn")
+}
+
+func TestArrayEncoder_Encode(t *testing.T) {
+	input := readerTestTCPLine + "\n" + readerTestICMPLine + "\n"
+
+	var out bytes.Buffer
+	enc := NewArrayEncoder(&out)
+	for l, err := range ParseReader(strings.NewReader(input)) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := enc.Encode(l); err != nil {
+			t.Fatal(err)
+		}
+	}
+	assert.NoError(t, enc.Close())
+	assert.NoError(t, enc.Close())
+	assert.ErrorIs(t, enc.Encode(&Log{}), ErrEncoderClosed)
+
+	var decoded []*Log
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, decoded, 2)
+	assert.Equal(t, "TCP", decoded[0].Protocol)
+	assert.Equal(t, "ICMP", decoded[1].Protocol)
+	assert.True(t, strings.HasPrefix(out.String(), "[\n{"))
+	assert.True(t, strings.HasSuffix(out.String(), "}\n]\n"))
+}
+
+func TestArrayEncoder_Empty(t *testing.T) {
+	var out bytes.Buffer
+	enc := NewArrayEncoder(&out)
+	assert.NoError(t, enc.Close())
+	assert.Equal(t, "[]\n", out.String())
+}
+
+func TestArrayEncoder_SetOmitAbsent(t *testing.T) {
+	parsedLog, err := Parse(readerTestICMPLine)
+	assert.NoError(t, err)
+
+	var out bytes.Buffer
+	enc := NewArrayEncoder(&out)
+	enc.SetOmitAbsent(true)
+	assert.NoError(t, enc.Encode(parsedLog))
+	assert.NoError(t, enc.Encode(parsedLog))
+	assert.NoError(t, enc.Close())
+
+	var decoded []map[string]any
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, decoded, 2)
+	assert.NotContains(t, decoded[0], "sourcePort")
+}
+
+type errorWriter struct {
+	err error
+}
+
+func (w *errorWriter) Write(p []byte) (int, error) {
+	return 0, w.err
+}
+
+func TestArrayEncoder_WriteError(t *testing.T) {
+	writeErr := errors.New("write error")
+	enc := NewArrayEncoder(&errorWriter{err: writeErr})
+	assert.ErrorIs(t, enc.Encode(&Log{}), writeErr)
+	assert.ErrorIs(t, enc.Close(), writeErr)
+}
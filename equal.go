@@ -0,0 +1,119 @@
+package iptables
+
+import (
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Equal reports whether the log has the same values of the fields as the other one, including the inner packet.
+// The timestamps are compared by time.Time.Equal, so the difference of the locations is ignored.
+// The addresses are compared in the canonical form (see CanonicalSource), so the differently notated ones are equal, e.g. "::ffff:192.0.2.1" and "192.0.2.1". Raw and the presence of the fields (see Has) are not compared.
+// This is safe for the nil logs; two nil logs are equal, and a nil log is not equal to a non-nil one.
+func (l *Log) Equal(other *Log) bool {
+	return l.EqualIgnoring(other)
+}
+
+// EqualIgnoring reports whether the log has the same values of the fields as the other one, ignoring the given fields (e.g. FieldTimestamp and FieldKernelTimestamp).
+// The fields are ignored in the inner packet as well. See Equal for the details of the comparison.
+func (l *Log) EqualIgnoring(other *Log, fields ...Field) bool {
+	var ignored FieldSet
+	for _, f := range fields {
+		if int(f) < numFields {
+			ignored.add(f)
+		}
+	}
+	return equalIgnoring(l, other, ignored)
+}
+
+func equalIgnoring(a, b *Log, ignored FieldSet) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	for f := Field(0); int(f) < numFields; f++ {
+		if ignored.Has(f) {
+			continue
+		}
+		if f == FieldInner {
+			if !equalIgnoring(a.Inner, b.Inner, ignored) {
+				return false
+			}
+			continue
+		}
+		if !fieldEqual(a, b, f) {
+			return false
+		}
+	}
+	return true
+}
+
+// fieldEqual reports whether the given field of the logs are equal, except Inner.
+func fieldEqual(a, b *Log, f Field) bool {
+	switch f {
+	case FieldTimestampParsed:
+		return a.TimestampParsed.Equal(b.TimestampParsed)
+	case FieldExtra:
+		return maps.Equal(a.Extra, b.Extra)
+	case FieldSource:
+		return canonicalAddr(a.Source) == canonicalAddr(b.Source)
+	case FieldDestination:
+		return canonicalAddr(a.Destination) == canonicalAddr(b.Destination)
+	default:
+		return fieldDefs[f].get(a) == fieldDefs[f].get(b)
+	}
+}
+
+// Diff returns the human-readable differences of the fields between the logs, e.g. `sourcePort: 40000 != 53`, in the order of Field.
+// The differences of the inner packet are prefixed by "inner.", e.g. `inner.source: "10.0.2.15" != "10.0.2.16"`.
+// This returns nil if the logs are equal (see Log.Equal). This is safe for the nil logs.
+func Diff(a, b *Log) []string {
+	return diff(a, b, "")
+}
+
+func diff(a, b *Log, path string) []string {
+	if a == nil || b == nil {
+		if a == b {
+			return nil
+		}
+		name := strings.TrimSuffix(path, ".")
+		if name == "" {
+			name = "log"
+		}
+		return []string{fmt.Sprintf("%s: %s != %s", name, diffValue(a), diffValue(b))}
+	}
+
+	var diffs []string
+	for f := Field(0); int(f) < numFields; f++ {
+		if f == FieldInner {
+			diffs = append(diffs, diff(a.Inner, b.Inner, path+"inner.")...)
+			continue
+		}
+		if !fieldEqual(a, b, f) {
+			diffs = append(diffs, fmt.Sprintf("%s%s: %s != %s", path, f, diffValue(fieldDefs[f].get(a)), diffValue(fieldDefs[f].get(b))))
+		}
+	}
+	return diffs
+}
+
+// diffValue renders the value of a field for Diff.
+func diffValue(v any) string {
+	switch v := v.(type) {
+	case string:
+		return strconv.Quote(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case *Log:
+		if v == nil {
+			return "<nil>"
+		}
+		var b strings.Builder
+		b.WriteByte('[')
+		v.formatPacket(&b)
+		b.WriteByte(']')
+		return b.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
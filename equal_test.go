@@ -0,0 +1,72 @@
+package iptables
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLog_Equal(t *testing.T) {
+	line := "Jul 21 05:38:28 ubuntu-jammy kernel: [14879.600492] IN=enp0s3 OUT= SRC=192.0.2.1 DST=10.0.2.15 LEN=576 TOS=0x00 PREC=0xC0 TTL=254 ID=39147 PROTO=ICMP TYPE=3 CODE=3 [SRC=10.0.2.15 DST=198.51.100.7 LEN=56 PROTO=UDP SPT=40000 DPT=0 ] CT=NEW"
+	a, err := Parse(line)
+	assert.NoError(t, err)
+	b, err := Parse(line)
+	assert.NoError(t, err)
+
+	assert.True(t, a.Equal(b))
+	assert.Nil(t, Diff(a, b))
+
+	// the location of the timestamp doesn't matter
+	b.TimestampParsed = b.TimestampParsed.In(time.FixedZone("", 9*60*60))
+	assert.True(t, a.Equal(b))
+
+	b.Timestamp = "Jul 21 05:38:29"
+	b.KernelTimestamp = 14879.700492
+	b.Inner.DestinationPort = 53
+	b.Extra["CT"] = "ESTABLISHED"
+	assert.False(t, a.Equal(b))
+	assert.False(t, a.EqualIgnoring(b, FieldTimestamp, FieldKernelTimestamp))
+	assert.False(t, a.EqualIgnoring(b, FieldTimestamp, FieldKernelTimestamp, FieldExtra))
+	assert.True(t, a.EqualIgnoring(b, FieldTimestamp, FieldKernelTimestamp, FieldExtra, FieldDestinationPort))
+	assert.True(t, a.EqualIgnoring(b, FieldTimestamp, FieldKernelTimestamp, FieldExtra, FieldInner))
+
+	assert.Equal(t, []string{
+		`timestamp: "Jul 21 05:38:28" != "Jul 21 05:38:29"`,
+		`kernelTimestamp: 14879.600492 != 14879.700492`,
+		`extra: map[CT:NEW] != map[CT:ESTABLISHED]`,
+		`inner.destinationPort: 0 != 53`,
+	}, Diff(a, b))
+}
+
+func TestLog_Equal_Nil(t *testing.T) {
+	var nilLog *Log
+	l := &Log{Source: "10.0.2.15", Inner: &Log{Source: "10.0.2.2", Protocol: "UDP"}}
+
+	assert.True(t, nilLog.Equal(nil))
+	assert.False(t, nilLog.Equal(l))
+	assert.False(t, l.Equal(nil))
+	assert.True(t, l.Equal(&Log{Source: "10.0.2.15", Inner: &Log{Source: "10.0.2.2", Protocol: "UDP"}}))
+
+	assert.Nil(t, Diff(nil, nil))
+	assert.Equal(t, []string{"log: <nil> != [SRC=10.0.2.15 DST= LEN=0 TOS=0x00 PREC=0x00 TTL=0 ID=0 PROTO=]"}, Diff(nil, &Log{Source: "10.0.2.15"}))
+	assert.Equal(t, []string{"inner: [SRC=10.0.2.2 DST= LEN=0 TOS=0x00 PREC=0x00 TTL=0 ID=0 PROTO=UDP SPT=0 DPT=0] != <nil>"}, Diff(l, &Log{Source: "10.0.2.15"}))
+}
+
+func TestLog_Equal_Addresses(t *testing.T) {
+	a := &Log{Source: "::ffff:192.0.2.1", Destination: "2001:0DB8::1", Inner: &Log{Source: "::ffff:10.0.2.15"}}
+	b := &Log{Source: "192.0.2.1", Destination: "2001:db8::1", Inner: &Log{Source: "10.0.2.15"}}
+
+	// the addresses are compared in the canonical form
+	assert.True(t, a.Equal(b))
+	assert.Nil(t, Diff(a, b))
+
+	b.Source = "192.0.2.2"
+	assert.False(t, a.Equal(b))
+	assert.True(t, a.EqualIgnoring(b, FieldSource))
+	assert.Equal(t, []string{`source: "::ffff:192.0.2.1" != "192.0.2.2"`}, Diff(a, b))
+
+	// the malformed addresses are compared as they are
+	assert.True(t, (&Log{Source: "bogus"}).Equal(&Log{Source: "bogus"}))
+	assert.False(t, (&Log{Source: "bogus"}).Equal(&Log{Source: "BOGUS"}))
+}
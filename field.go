@@ -0,0 +1,372 @@
+package iptables
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"math/bits"
+	"slices"
+	"time"
+)
+
+// Field identifies a field of Log.
+type Field uint8
+
+// The identifiers of the fields of Log, which are in the order of the fields.
+// The comment of each identifier is the type of the value that Log.Get returns and Log.Set takes.
+const (
+	FieldFacility               Field = iota // uint8
+	FieldSeverity                            // uint8
+	FieldHasPriority                         // bool
+	FieldTimestamp                           // string
+	FieldTimestampParsed                     // time.Time
+	FieldHostname                            // string
+	FieldKernelTimestamp                     // float64
+	FieldPrefix                              // string
+	FieldInputInterface                      // string
+	FieldOutputInterface                     // string
+	FieldPhysInputInterface                  // string
+	FieldPhysOutputInterface                 // string
+	FieldMACAddress                          // string
+	FieldSource                              // string
+	FieldDestination                         // string
+	FieldLength                              // uint64
+	FieldToS                                 // uint8
+	FieldPrecedence                          // uint8
+	FieldTTL                                 // uint64
+	FieldID                                  // uint64
+	FieldCongestionExperienced               // bool
+	FieldDoNotFragment                       // bool
+	FieldMoreFragmentsFollowing              // bool
+	FieldFrag                                // int64
+	FieldIPOptions                           // string
+	FieldIsIPv6                              // bool
+	FieldIPVersion                           // uint8
+	FieldTrafficClass                        // uint8
+	FieldFlowLabel                           // uint32
+	FieldProtocol                            // string
+	FieldType                                // int64
+	FieldCode                                // int64
+	FieldICMPID                              // uint16
+	FieldICMPSeq                             // uint16
+	FieldMTU                                 // uint16
+	FieldSPI                                 // uint32
+	FieldIPsecSequence                       // uint32
+	FieldGREKey                              // uint32
+	FieldGREProtocol                         // uint16
+	FieldSourcePort                          // uint16
+	FieldDestinationPort                     // uint16
+	FieldChecksumCoverage                    // uint16
+	FieldSequence                            // uint64
+	FieldAckSequence                         // uint64
+	FieldWindowSize                          // uint64
+	FieldRes                                 // uint64
+	FieldUrgent                              // bool
+	FieldAck                                 // bool
+	FieldPush                                // bool
+	FieldReset                               // bool
+	FieldSyn                                 // bool
+	FieldFin                                 // bool
+	FieldECE                                 // bool
+	FieldCWR                                 // bool
+	FieldNS                                  // bool
+	FieldUrgp                                // uint64
+	FieldTCPOption                           // string
+	FieldIncomplete                          // bool
+	FieldIncompleteBytes                     // uint64
+	FieldUID                                 // int64
+	FieldGID                                 // int64
+	FieldMark                                // uint32
+	FieldHasMark                             // bool
+	FieldExtra                               // map[string]string
+	FieldInner                               // *Log
+)
+
+// ErrFieldTypeMismatch is an error that occurs when Log.Set is given the value whose type differs from the one of the field.
+var ErrFieldTypeMismatch = errors.New("type of the value mismatches the field")
+
+// ErrUnknownField is an error that occurs when Log.Set is given the unknown Field.
+var ErrUnknownField = errors.New("unknown field")
+
+// numFields is the number of the fields of Log.
+const numFields = int(FieldInner) + 1
+
+// DefaultFieldOrder returns the canonical order of the fields for the tabular outputs, e.g. the default columns of CSVWriter (and TSV by it).
+// This order is stable across the versions, so the consumers can rely on the positions of the columns: a new field is appended to the end, and changing the order of the existing fields is a documented breaking change.
+// Note that this can differ from the order of Field, which follows the order of the fields of Log.
+// FieldInner is not included, since it is a nested packet rather than a column.
+// This returns a new slice on each call, so the caller can modify it without affecting the others.
+func DefaultFieldOrder() []Field {
+	return slices.Clone(defaultFieldOrder)
+}
+
+// defaultFieldOrder is the order of DefaultFieldOrder, which must not be modified.
+var defaultFieldOrder = []Field{
+	FieldFacility,
+	FieldSeverity,
+	FieldHasPriority,
+	FieldTimestamp,
+	FieldTimestampParsed,
+	FieldHostname,
+	FieldKernelTimestamp,
+	FieldPrefix,
+	FieldInputInterface,
+	FieldOutputInterface,
+	FieldPhysInputInterface,
+	FieldPhysOutputInterface,
+	FieldMACAddress,
+	FieldSource,
+	FieldDestination,
+	FieldLength,
+	FieldToS,
+	FieldPrecedence,
+	FieldTTL,
+	FieldID,
+	FieldCongestionExperienced,
+	FieldDoNotFragment,
+	FieldMoreFragmentsFollowing,
+	FieldFrag,
+	FieldIPOptions,
+	FieldIsIPv6,
+	FieldTrafficClass,
+	FieldFlowLabel,
+	FieldProtocol,
+	FieldType,
+	FieldCode,
+	FieldICMPID,
+	FieldICMPSeq,
+	FieldMTU,
+	FieldSPI,
+	FieldIPsecSequence,
+	FieldGREKey,
+	FieldGREProtocol,
+	FieldSourcePort,
+	FieldDestinationPort,
+	FieldChecksumCoverage,
+	FieldSequence,
+	FieldAckSequence,
+	FieldWindowSize,
+	FieldRes,
+	FieldUrgent,
+	FieldAck,
+	FieldPush,
+	FieldReset,
+	FieldSyn,
+	FieldFin,
+	FieldECE,
+	FieldCWR,
+	FieldNS,
+	FieldUrgp,
+	FieldTCPOption,
+	FieldIncomplete,
+	FieldIncompleteBytes,
+	FieldUID,
+	FieldGID,
+	FieldMark,
+	FieldHasMark,
+	FieldExtra,
+	FieldIPVersion,
+}
+
+// fieldDef defines the JSON name and the accessors of a field.
+type fieldDef struct {
+	name string
+	get  func(l *Log) any
+	set  func(l *Log, v any) error
+	// ptr returns the pointer to the field, e.g. *uint16 of SourcePort
+	ptr func(l *Log) any
+}
+
+// newFieldDef makes a fieldDef of the field that the given function points to; the value of the field is accessed as T.
+func newFieldDef[T any](name string, ptr func(l *Log) *T) fieldDef {
+	return fieldDef{
+		name: name,
+		get:  func(l *Log) any { return *ptr(l) },
+		set: func(l *Log, v any) error {
+			t, ok := v.(T)
+			if !ok {
+				return fmt.Errorf("field %q takes %T, but got %T: %w", name, *new(T), v, ErrFieldTypeMismatch)
+			}
+			*ptr(l) = t
+			return nil
+		},
+		ptr: func(l *Log) any { return ptr(l) },
+	}
+}
+
+// fieldDefs defines the JSON name and the accessors of each field, which are indexed by Field.
+var fieldDefs = [numFields]fieldDef{
+	FieldFacility:               newFieldDef("facility", func(l *Log) *uint8 { return &l.Facility }),
+	FieldSeverity:               newFieldDef("severity", func(l *Log) *uint8 { return &l.Severity }),
+	FieldHasPriority:            newFieldDef("hasPriority", func(l *Log) *bool { return &l.HasPriority }),
+	FieldTimestamp:              newFieldDef("timestamp", func(l *Log) *string { return &l.Timestamp }),
+	FieldTimestampParsed:        newFieldDef("timestampParsed", func(l *Log) *time.Time { return &l.TimestampParsed }),
+	FieldHostname:               newFieldDef("hostname", func(l *Log) *string { return &l.Hostname }),
+	FieldKernelTimestamp:        newFieldDef("kernelTimestamp", func(l *Log) *float64 { return &l.KernelTimestamp }),
+	FieldPrefix:                 newFieldDef("prefix", func(l *Log) *string { return &l.Prefix }),
+	FieldInputInterface:         newFieldDef("inputInterface", func(l *Log) *string { return &l.InputInterface }),
+	FieldOutputInterface:        newFieldDef("outputInterface", func(l *Log) *string { return &l.OutputInterface }),
+	FieldPhysInputInterface:     newFieldDef("physInputInterface", func(l *Log) *string { return &l.PhysInputInterface }),
+	FieldPhysOutputInterface:    newFieldDef("physOutputInterface", func(l *Log) *string { return &l.PhysOutputInterface }),
+	FieldMACAddress:             newFieldDef("macAddress", func(l *Log) *string { return &l.MACAddress }),
+	FieldSource:                 newFieldDef("source", func(l *Log) *string { return &l.Source }),
+	FieldDestination:            newFieldDef("destination", func(l *Log) *string { return &l.Destination }),
+	FieldLength:                 newFieldDef("length", func(l *Log) *uint64 { return &l.Length }),
+	FieldToS:                    newFieldDef("tos", func(l *Log) *uint8 { return &l.ToS }),
+	FieldPrecedence:             newFieldDef("precedence", func(l *Log) *uint8 { return &l.Precedence }),
+	FieldTTL:                    newFieldDef("ttl", func(l *Log) *uint64 { return &l.TTL }),
+	FieldID:                     newFieldDef("id", func(l *Log) *uint64 { return &l.ID }),
+	FieldCongestionExperienced:  newFieldDef("congestionExperienced", func(l *Log) *bool { return &l.CongestionExperienced }),
+	FieldDoNotFragment:          newFieldDef("doNotFragment", func(l *Log) *bool { return &l.DoNotFragment }),
+	FieldMoreFragmentsFollowing: newFieldDef("moreFragmentsFollowing", func(l *Log) *bool { return &l.MoreFragmentsFollowing }),
+	FieldFrag:                   newFieldDef("frag", func(l *Log) *int64 { return &l.Frag }),
+	FieldIPOptions:              newFieldDef("ipOptions", func(l *Log) *string { return &l.IPOptions }),
+	FieldIsIPv6:                 newFieldDef("isIPv6", func(l *Log) *bool { return &l.IsIPv6 }),
+	FieldIPVersion:              newFieldDef("ipVersion", func(l *Log) *uint8 { return &l.IPVersion }),
+	FieldTrafficClass:           newFieldDef("trafficClass", func(l *Log) *uint8 { return &l.TrafficClass }),
+	FieldFlowLabel:              newFieldDef("flowLabel", func(l *Log) *uint32 { return &l.FlowLabel }),
+	FieldProtocol:               newFieldDef("protocol", func(l *Log) *string { return &l.Protocol }),
+	FieldType:                   newFieldDef("type", func(l *Log) *int64 { return &l.Type }),
+	FieldCode:                   newFieldDef("code", func(l *Log) *int64 { return &l.Code }),
+	FieldICMPID:                 newFieldDef("icmpId", func(l *Log) *uint16 { return &l.ICMPID }),
+	FieldICMPSeq:                newFieldDef("icmpSeq", func(l *Log) *uint16 { return &l.ICMPSeq }),
+	FieldMTU:                    newFieldDef("mtu", func(l *Log) *uint16 { return &l.MTU }),
+	FieldSPI:                    newFieldDef("spi", func(l *Log) *uint32 { return &l.SPI }),
+	FieldIPsecSequence:          newFieldDef("ipsecSequence", func(l *Log) *uint32 { return &l.IPsecSequence }),
+	FieldGREKey:                 newFieldDef("greKey", func(l *Log) *uint32 { return &l.GREKey }),
+	FieldGREProtocol:            newFieldDef("greProtocol", func(l *Log) *uint16 { return &l.GREProtocol }),
+	FieldSourcePort:             newFieldDef("sourcePort", func(l *Log) *uint16 { return &l.SourcePort }),
+	FieldDestinationPort:        newFieldDef("destinationPort", func(l *Log) *uint16 { return &l.DestinationPort }),
+	FieldChecksumCoverage:       newFieldDef("checksumCoverage", func(l *Log) *uint16 { return &l.ChecksumCoverage }),
+	FieldSequence:               newFieldDef("sequence", func(l *Log) *uint64 { return &l.Sequence }),
+	FieldAckSequence:            newFieldDef("ackSequence", func(l *Log) *uint64 { return &l.AckSequence }),
+	FieldWindowSize:             newFieldDef("windowSize", func(l *Log) *uint64 { return &l.WindowSize }),
+	FieldRes:                    newFieldDef("res", func(l *Log) *uint64 { return &l.Res }),
+	FieldUrgent:                 newFieldDef("urgent", func(l *Log) *bool { return &l.Urgent }),
+	FieldAck:                    newFieldDef("ack", func(l *Log) *bool { return &l.Ack }),
+	FieldPush:                   newFieldDef("push", func(l *Log) *bool { return &l.Push }),
+	FieldReset:                  newFieldDef("reset", func(l *Log) *bool { return &l.Reset }),
+	FieldSyn:                    newFieldDef("syn", func(l *Log) *bool { return &l.Syn }),
+	FieldFin:                    newFieldDef("fin", func(l *Log) *bool { return &l.Fin }),
+	FieldECE:                    newFieldDef("ece", func(l *Log) *bool { return &l.ECE }),
+	FieldCWR:                    newFieldDef("cwr", func(l *Log) *bool { return &l.CWR }),
+	FieldNS:                     newFieldDef("ns", func(l *Log) *bool { return &l.NS }),
+	FieldUrgp:                   newFieldDef("urgp", func(l *Log) *uint64 { return &l.Urgp }),
+	FieldTCPOption:              newFieldDef("tcpOption", func(l *Log) *string { return &l.TCPOption }),
+	FieldIncomplete:             newFieldDef("incomplete", func(l *Log) *bool { return &l.Incomplete }),
+	FieldIncompleteBytes:        newFieldDef("incompleteBytes", func(l *Log) *uint64 { return &l.IncompleteBytes }),
+	FieldUID:                    newFieldDef("uid", func(l *Log) *int64 { return &l.UID }),
+	FieldGID:                    newFieldDef("gid", func(l *Log) *int64 { return &l.GID }),
+	FieldMark:                   newFieldDef("mark", func(l *Log) *uint32 { return &l.Mark }),
+	FieldHasMark:                newFieldDef("hasMark", func(l *Log) *bool { return &l.HasMark }),
+	FieldExtra:                  newFieldDef("extra", func(l *Log) *map[string]string { return &l.Extra }),
+	FieldInner:                  newFieldDef("inner", func(l *Log) **Log { return &l.Inner }),
+}
+
+// String returns the JSON name of the field, e.g. "sourcePort".
+func (f Field) String() string {
+	if int(f) >= numFields {
+		return ""
+	}
+	return fieldDefs[f].name
+}
+
+// fieldsByName maps the JSON name of each field to the Field.
+var fieldsByName = func() map[string]Field {
+	m := make(map[string]Field, numFields)
+	for f := Field(0); int(f) < numFields; f++ {
+		m[fieldDefs[f].name] = f
+	}
+	return m
+}()
+
+// FieldSet is a set of Fields, e.g. the fields that are present in a parsed line.
+// The zero value is an empty set.
+type FieldSet struct {
+	bits [(numFields + 63) / 64]uint64
+}
+
+// newFieldSet makes a FieldSet of the given fields.
+func newFieldSet(fields ...Field) FieldSet {
+	var s FieldSet
+	for _, f := range fields {
+		s.add(f)
+	}
+	return s
+}
+
+func (s *FieldSet) add(f Field) {
+	s.bits[f/64] |= 1 << (f % 64)
+}
+
+// Has reports whether the set contains the given field.
+func (s FieldSet) Has(f Field) bool {
+	if int(f) >= numFields {
+		return false
+	}
+	return s.bits[f/64]&(1<<(f%64)) != 0
+}
+
+// Len returns the number of the fields in the set.
+func (s FieldSet) Len() int {
+	n := 0
+	for _, b := range s.bits {
+		n += bits.OnesCount64(b)
+	}
+	return n
+}
+
+// All returns an iterator over the fields in the set, in the order of Field.
+func (s FieldSet) All() iter.Seq[Field] {
+	return func(yield func(Field) bool) {
+		for f := Field(0); int(f) < numFields; f++ {
+			if s.Has(f) && !yield(f) {
+				return
+			}
+		}
+	}
+}
+
+// Has reports whether the given field is present in the parsed line, which distinguishes the zero value from the absence (e.g. "SPT=0" and no SPT).
+// The flags (e.g. DF and SYN) and the derived fields (e.g. IsIPv6 and IPVersion) are present only when they are true or nonzero.
+// Note that this reports false for every field of the Log that is not populated by parsing (e.g. a struct literal).
+func (l *Log) Has(f Field) bool {
+	return l.present.Has(f)
+}
+
+// Fields returns the set of the fields that are present in the parsed line; see Has.
+func (l *Log) Fields() FieldSet {
+	return l.present
+}
+
+// AllFields returns all the fields of Log in the order of Field, which is stable.
+func AllFields() []Field {
+	fields := make([]Field, numFields)
+	for i := range fields {
+		fields[i] = Field(i)
+	}
+	return fields
+}
+
+// Get returns the value of the given field without reflection, e.g. uint16 for FieldSourcePort; see the comments of the Field identifiers for the types.
+// This returns nil if the field is unknown.
+func (l *Log) Get(f Field) any {
+	if int(f) >= numFields {
+		return nil
+	}
+	return fieldDefs[f].get(l)
+}
+
+// Set sets the value of the given field without reflection, and marks the field present (see Has).
+// The type of the value must be exactly the one of the field (e.g. uint16 for FieldSourcePort), otherwise this returns ErrFieldTypeMismatch; see the comments of the Field identifiers for the types.
+// This returns ErrUnknownField if the field is unknown.
+func (l *Log) Set(f Field, v any) error {
+	if int(f) >= numFields {
+		return fmt.Errorf("%d: %w", f, ErrUnknownField)
+	}
+	if err := fieldDefs[f].set(l, v); err != nil {
+		return err
+	}
+	l.present.add(f)
+	return nil
+}
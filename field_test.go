@@ -0,0 +1,194 @@
+package iptables
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLog_Has(t *testing.T) {
+	type TestCase struct {
+		line            string
+		expectedPresent []Field
+		expectedAbsent  []Field
+	}
+
+	testCases := []*TestCase{
+		{
+			line:            "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=93.184.216.34 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=64125 DF PROTO=TCP SPT=54832 DPT=0 SEQ=567002889 ACK=0 WINDOW=64240 RES=0x00 SYN URGP=0 UID=1000 GID= MARK=0x0",
+			expectedPresent: []Field{FieldTimestamp, FieldTimestampParsed, FieldHostname, FieldKernelTimestamp, FieldPrefix, FieldInputInterface, FieldOutputInterface, FieldSource, FieldDestination, FieldLength, FieldToS, FieldPrecedence, FieldTTL, FieldID, FieldDoNotFragment, FieldProtocol, FieldSourcePort, FieldDestinationPort, FieldSequence, FieldAckSequence, FieldWindowSize, FieldRes, FieldSyn, FieldUrgp, FieldUID, FieldMark, FieldHasMark},
+			expectedAbsent:  []Field{FieldFacility, FieldMACAddress, FieldCongestionExperienced, FieldFrag, FieldIsIPv6, FieldType, FieldCode, FieldICMPID, FieldAck, FieldFin, FieldTCPOption, FieldGID, FieldExtra, FieldInner},
+		},
+		{
+			line:            "Jul 21 05:38:28 ubuntu-jammy kernel: [14879.600492] IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=84 TOS=0x00 PREC=0x00 TTL=64 ID=6495 DF PROTO=ICMP TYPE=8 CODE=0 ID=1 SEQ=3",
+			expectedPresent: []Field{FieldType, FieldCode, FieldICMPID, FieldICMPSeq},
+			expectedAbsent:  []Field{FieldPrefix, FieldSourcePort, FieldDestinationPort, FieldSequence, FieldSyn, FieldUID, FieldMark},
+		},
+		{
+			line:            "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] IN=enp0s3 OUT= SRC=2001:0db8:0000:0000:0000:0000:0000:0001 DST=2001:0db8:0000:0000:0000:0000:0000:0002 LEN=72 TC=0 HOPLIMIT=64 FLOWLBL=0 PROTO=UDP SPT=40000 DPT=53 LEN=32",
+			expectedPresent: []Field{FieldTTL, FieldTrafficClass, FieldFlowLabel, FieldIsIPv6},
+			expectedAbsent:  []Field{FieldToS, FieldID},
+		},
+	}
+
+	for _, testCase := range testCases {
+		parsedLog, err := Parse(testCase.line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, f := range testCase.expectedPresent {
+			assert.True(t, parsedLog.Has(f), f.String())
+		}
+		for _, f := range testCase.expectedAbsent {
+			assert.False(t, parsedLog.Has(f), f.String())
+		}
+	}
+}
+
+func TestLog_HasNotParsed(t *testing.T) {
+	l := &Log{Source: "10.0.2.15"}
+	assert.False(t, l.Has(FieldSource))
+}
+
+func TestField_String(t *testing.T) {
+	assert.Equal(t, "sourcePort", FieldSourcePort.String())
+	assert.Equal(t, "inner", FieldInner.String())
+	assert.Equal(t, "", Field(255).String())
+}
+
+func TestLog_Fields(t *testing.T) {
+	// SPT=0 is distinguished from the absence of SPT
+	parsedLog, err := Parse("Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=64 TOS=0x00 PREC=0x00 TTL=64 PROTO=UDP SPT=0 LEN=44")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := parsedLog.Fields()
+	assert.True(t, fields.Has(FieldSourcePort))
+	assert.False(t, fields.Has(FieldDestinationPort))
+	assert.Equal(t, uint16(0), parsedLog.SourcePort)
+
+	expected := []Field{
+		FieldTimestamp,
+		FieldTimestampParsed,
+		FieldHostname,
+		FieldKernelTimestamp,
+		FieldInputInterface,
+		FieldOutputInterface,
+		FieldSource,
+		FieldDestination,
+		FieldLength,
+		FieldToS,
+		FieldPrecedence,
+		FieldTTL,
+		FieldIPVersion,
+		FieldProtocol,
+		FieldSourcePort,
+	}
+	assert.Equal(t, len(expected), fields.Len())
+	var actual []Field
+	for f := range fields.All() {
+		actual = append(actual, f)
+	}
+	assert.Equal(t, expected, actual)
+}
+
+func TestFieldSet_Zero(t *testing.T) {
+	var fields FieldSet
+	assert.Equal(t, 0, fields.Len())
+	assert.False(t, fields.Has(FieldTTL))
+	for range fields.All() {
+		t.Fatal("empty set must yield nothing")
+	}
+}
+
+func TestAllFields(t *testing.T) {
+	fields := AllFields()
+	assert.Len(t, fields, numFields)
+	for i, f := range fields {
+		assert.Equal(t, Field(i), f)
+		assert.NotEmpty(t, f.String())
+	}
+}
+
+func TestDefaultFieldOrder(t *testing.T) {
+	// every field except inner appears exactly once
+	order := DefaultFieldOrder()
+	seen := make(map[Field]bool, numFields)
+	for _, f := range order {
+		assert.False(t, seen[f], f.String())
+		seen[f] = true
+	}
+	assert.Len(t, seen, numFields-1)
+	assert.False(t, seen[FieldInner])
+
+	// the order must not be changed; a new field is appended to the end
+	names := make([]string, len(order))
+	for i, f := range order {
+		names[i] = f.String()
+	}
+	assert.Equal(t, []string{
+		"facility", "severity", "hasPriority", "timestamp", "timestampParsed", "hostname", "kernelTimestamp", "prefix",
+		"inputInterface", "outputInterface", "physInputInterface", "physOutputInterface", "macAddress", "source", "destination",
+		"length", "tos", "precedence", "ttl", "id", "congestionExperienced", "doNotFragment", "moreFragmentsFollowing", "frag", "ipOptions",
+		"isIPv6", "trafficClass", "flowLabel", "protocol", "type", "code", "icmpId", "icmpSeq", "mtu", "spi", "ipsecSequence", "greKey", "greProtocol",
+		"sourcePort", "destinationPort", "checksumCoverage", "sequence", "ackSequence", "windowSize", "res",
+		"urgent", "ack", "push", "reset", "syn", "fin", "ece", "cwr", "ns", "urgp", "tcpOption", "incomplete", "incompleteBytes",
+		"uid", "gid", "mark", "hasMark", "extra", "ipVersion",
+	}, names)
+
+	// the returned slice is a copy
+	order[0] = FieldInner
+	assert.Equal(t, FieldFacility, DefaultFieldOrder()[0])
+}
+
+func TestLog_GetAndSet(t *testing.T) {
+	l := &Log{}
+
+	assert.NoError(t, l.Set(FieldSource, "10.0.2.15"))
+	assert.NoError(t, l.Set(FieldSourcePort, uint16(53)))
+	assert.NoError(t, l.Set(FieldKernelTimestamp, 14479.122228))
+	assert.NoError(t, l.Set(FieldSyn, true))
+	assert.NoError(t, l.Set(FieldTimestampParsed, time.Date(2022, time.July, 21, 5, 31, 48, 0, time.UTC)))
+	assert.NoError(t, l.Set(FieldExtra, map[string]string{"CT": "NEW"}))
+	assert.NoError(t, l.Set(FieldInner, &Log{Source: "10.0.2.2"}))
+
+	assert.Equal(t, "10.0.2.15", l.Source)
+	assert.Equal(t, uint16(53), l.SourcePort)
+	assert.Equal(t, 14479.122228, l.KernelTimestamp)
+	assert.True(t, l.Syn)
+	assert.Equal(t, map[string]string{"CT": "NEW"}, l.Extra)
+	assert.Equal(t, "10.0.2.2", l.Inner.Source)
+	assert.True(t, l.Has(FieldSourcePort))
+	assert.False(t, l.Has(FieldDestinationPort))
+
+	assert.Equal(t, "10.0.2.15", l.Get(FieldSource))
+	assert.Equal(t, uint16(53), l.Get(FieldSourcePort))
+	assert.Equal(t, uint16(0), l.Get(FieldDestinationPort))
+	assert.Equal(t, time.Date(2022, time.July, 21, 5, 31, 48, 0, time.UTC), l.Get(FieldTimestampParsed))
+	assert.Nil(t, l.Get(Field(numFields)))
+
+	// the value of every field can be copied by Get and Set
+	parsedLog, err := Parse("Jul 21 05:38:28 ubuntu-jammy kernel: [14879.600492] IN=enp0s3 OUT= SRC=192.0.2.1 DST=10.0.2.15 LEN=576 TOS=0x00 PREC=0xC0 TTL=254 ID=39147 PROTO=ICMP TYPE=3 CODE=3 [SRC=10.0.2.15 DST=198.51.100.7 LEN=56 PROTO=UDP SPT=40000 DPT=0 ] UID=0 MARK=0x2 CT=NEW")
+	assert.NoError(t, err)
+	copied := &Log{}
+	for _, f := range AllFields() {
+		assert.NoError(t, copied.Set(f, parsedLog.Get(f)), f.String())
+	}
+	assert.True(t, parsedLog.Equal(copied))
+}
+
+func TestLog_Set_Error(t *testing.T) {
+	l := &Log{}
+
+	err := l.Set(FieldSourcePort, 53)
+	assert.ErrorIs(t, err, ErrFieldTypeMismatch)
+	assert.EqualError(t, err, `field "sourcePort" takes uint16, but got int: type of the value mismatches the field`)
+	assert.False(t, l.Has(FieldSourcePort))
+
+	err = l.Set(FieldSource, nil)
+	assert.ErrorIs(t, err, ErrFieldTypeMismatch)
+
+	err = l.Set(Field(numFields), "x")
+	assert.ErrorIs(t, err, ErrUnknownField)
+}
@@ -0,0 +1,646 @@
+package iptables
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var errMissingHexPrefix = errors.New("missing 0x prefix")
+
+// FieldConversionError is an error that occurs when it cannot convert a stringy number field into number.
+// This error satisfies errors.Is(err, ErrStringToNumberConversionFailed).
+type FieldConversionError struct {
+	// Field is the name of the field, e.g. "ttl".
+	Field string
+	// Value is the raw value of the field that failed to convert.
+	Value string
+	// Err is the underlying error of the conversion.
+	Err error
+}
+
+// Error returns the error message with the field name and the offending value.
+func (e *FieldConversionError) Error() string {
+	return fmt.Sprintf("%s; field = %s, value = %q: %s", e.Err, e.Field, e.Value, ErrStringToNumberConversionFailed)
+}
+
+// Unwrap returns the underlying error of the conversion.
+func (e *FieldConversionError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the target is ErrStringToNumberConversionFailed.
+func (e *FieldConversionError) Is(target error) bool {
+	return target == ErrStringToNumberConversionFailed
+}
+
+// maxUnmatchedLineLen is the maximum length of the line in the message of UnmatchedError.
+const maxUnmatchedLineLen = 256
+
+// UnmatchedError is an error that occurs when the given line is not matched with the log format.
+// This error satisfies errors.Is(err, ErrLogFormatUnmatched).
+type UnmatchedError struct {
+	// Line is the line that is not matched.
+	Line string
+	// Reason describes the point where the matching diverged, e.g. "IN= and OUT= are not found".
+	Reason string
+}
+
+// Error returns the error message with the reason and the line, which is truncated if it is too long.
+func (e *UnmatchedError) Error() string {
+	line := e.Line
+	if len(line) > maxUnmatchedLineLen {
+		line = line[:maxUnmatchedLineLen] + "..."
+	}
+	return fmt.Sprintf("%s; reason = %s, line = %q", ErrLogFormatUnmatched, e.Reason, line)
+}
+
+// Unwrap returns ErrLogFormatUnmatched.
+func (e *UnmatchedError) Unwrap() error {
+	return ErrLogFormatUnmatched
+}
+
+// tokenizer iterates the whitespace-separated tokens of a text without allocation.
+type tokenizer struct {
+	text string
+	pos  int
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\f' || c == '\r'
+}
+
+// next returns the next token. The second return value is false when there is no more token.
+func (t *tokenizer) next() (string, bool) {
+	for t.pos < len(t.text) && isSpace(t.text[t.pos]) {
+		t.pos++
+	}
+	if t.pos >= len(t.text) {
+		return "", false
+	}
+	start := t.pos
+	for t.pos < len(t.text) && !isSpace(t.text[t.pos]) {
+		t.pos++
+	}
+	return t.text[start:t.pos], true
+}
+
+// bracketed returns the content of the bracket opened by the given token, e.g. "SRC=... DST=... " of "[SRC=... DST=... ]".
+// This takes the nested brackets into account.
+func (t *tokenizer) bracketed(opening string) string {
+	start := t.pos - len(opening)
+	depth := 0
+	for i := start; i < len(t.text); i++ {
+		switch t.text[i] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				t.pos = i + 1
+				return t.text[start+1 : i]
+			}
+		}
+	}
+	// unclosed bracket; regard the rest as the content
+	t.pos = len(t.text)
+	return t.text[start+1:]
+}
+
+// option returns the content of the parenthesized token that follows "OPT", e.g. "020405B4" of "OPT (020405B4)".
+func (t *tokenizer) option() string {
+	pos := t.pos
+	token, ok := t.next()
+	if !ok || !strings.HasPrefix(token, "(") || !strings.HasSuffix(token, ")") {
+		t.pos = pos
+		return ""
+	}
+	return token[1 : len(token)-1]
+}
+
+// paddedNumber returns the number that is separated from its key by whitespace, e.g. "60" of "LEN= 60", which slips through some upstream reformatting.
+// This consumes nothing if the next token doesn't look like a number, e.g. "OUT=" of "IN= OUT=".
+func (t *tokenizer) paddedNumber() string {
+	pos := t.pos
+	token, ok := t.next()
+	if !ok || !looksLikeNumber(token) {
+		t.pos = pos
+		return ""
+	}
+	return token
+}
+
+// looksLikeNumber reports whether the token starts with a digit, optionally signed, e.g. "60", "0x10", and "-1".
+func looksLikeNumber(token string) bool {
+	token = strings.TrimPrefix(token, "-")
+	return token != "" && '0' <= token[0] && token[0] <= '9'
+}
+
+// numericFieldKeys is the set of the keys whose values are numbers.
+var numericFieldKeys = map[string]struct{}{
+	"LEN": {}, "TOS": {}, "PREC": {}, "TTL": {}, "ID": {}, "FRAG": {}, "TC": {}, "HOPLIMIT": {}, "FLOWLBL": {},
+	"TYPE": {}, "CODE": {}, "MTU": {}, "SPI": {}, "KEY": {}, "SPT": {}, "DPT": {}, "SEQ": {}, "ACK": {},
+	"WINDOW": {}, "RES": {}, "URGP": {}, "UID": {}, "GID": {}, "MARK": {},
+}
+
+// the mandatory fields of a line
+const (
+	seenSource = 1 << iota
+	seenDestination
+	seenLength
+	seenProtocol
+	seenTTL // TTL for IPv4, or HOPLIMIT for IPv6
+
+	seenMandatoryFields = seenSource | seenDestination | seenLength | seenProtocol | seenTTL
+)
+
+// parseFields parses the KEY=VALUE fields and the bare flags that follow the prefix, e.g. "IN=eth0 OUT= ... PROTO=TCP ... SYN URGP=0".
+// Since some keys (e.g. LEN, ID, and OPT) have the different meanings before and after PROTO, the fields are interpreted in two phases: the network layer and the transport layer.
+// mandatory is the set of the seen* bits that must be present in the fields.
+func parseFields(fields string, l *Log, o *options, mandatory int) error {
+	l.UID = -1
+	l.GID = -1
+
+	// the failures are collected rather than returned immediately when WithCollectAllErrors is enabled
+	var errs []error
+	fail := func(err error) bool {
+		errs = append(errs, err)
+		return !o.collectAllErrors
+	}
+
+	seen := 0
+	transportLayer := false
+	// the addresses as they are logged, which tell the IP version even if they are canonicalized
+	var rawSource, rawDestination string
+	t := &tokenizer{text: fields}
+	for {
+		token, ok := t.next()
+		if !ok {
+			break
+		}
+
+		if token == "INCOMPLETE" {
+			truncated, err := parseIncompleteBytes(t, l)
+			if truncated {
+				// the kernel could not read the whole header, e.g. "INCOMPLETE [8 bytes]"
+				l.Incomplete = true
+				l.present.add(FieldIncomplete)
+			} else if !transportLayer && l.Has(FieldFrag) {
+				// ip6tables logs the M flag of the fragment header as the bare INCOMPLETE that follows FRAG:, e.g. "FRAG:0 INCOMPLETE ID:12345678"
+				l.MoreFragmentsFollowing = true
+				l.present.add(FieldMoreFragmentsFollowing)
+			}
+			if err != nil && fail(err) {
+				return err
+			}
+			continue
+		}
+
+		if strings.HasPrefix(token, "[") {
+			content := t.bracketed(token)
+			if transportLayer && l.IsICMP() && l.Inner == nil {
+				// the original packet embedded in an ICMP error
+				inner := &Log{}
+				if err := parseFields(content, inner, o, seenSource|seenDestination); err != nil {
+					err = fmt.Errorf("inner packet: %w", err)
+					if fail(err) {
+						return err
+					}
+				}
+				l.Inner = inner
+				l.present.add(FieldInner)
+			}
+			continue
+		}
+
+		key, value, hasValue := strings.Cut(token, "=")
+		if !hasValue {
+			if token == "OPT" {
+				if transportLayer {
+					l.TCPOption = t.option()
+					l.present.add(FieldTCPOption)
+				} else {
+					l.IPOptions = t.option()
+					l.present.add(FieldIPOptions)
+				}
+				continue
+			}
+
+			if frag, found := strings.CutPrefix(token, "FRAG:"); found && !transportLayer {
+				// ip6tables logs the fragment offset in bytes (e.g. "FRAG:1480"), while the IPv4 one is in the 8-byte units (e.g. "FRAG:185" or "FRAG=185"); see FragmentOffsetBytes
+				var err error
+				l.Frag, err = parseIntField("frag", frag, 64)
+				l.present.add(FieldFrag)
+				if err != nil && fail(err) {
+					return err
+				}
+				continue
+			}
+
+			if id, found := strings.CutPrefix(token, "ID:"); found && !transportLayer {
+				// the identification of the fragment header of IPv6, which is logged in hex without the "0x" prefix, e.g. "ID:12345678"
+				var err error
+				l.ID, err = parseUintField("id", id, 16, 64)
+				l.present.add(FieldID)
+				if err != nil && fail(err) {
+					return err
+				}
+				continue
+			}
+
+			if !transportLayer {
+				parseIPFlag(token, l)
+			} else if l.isProtocol("TCP") {
+				// the other protocols (e.g. UDP and SCTP) have no flags section
+				parseTCPFlag(token, l)
+			}
+			continue
+		}
+
+		if value == "" {
+			if _, numeric := numericFieldKeys[key]; numeric {
+				value = t.paddedNumber()
+			}
+		}
+
+		var err error
+		switch key {
+		case "IN":
+			l.InputInterface = value
+			l.present.add(FieldInputInterface)
+		case "OUT":
+			l.OutputInterface = value
+			l.present.add(FieldOutputInterface)
+		case "PHYSIN":
+			l.PhysInputInterface = value
+			l.present.add(FieldPhysInputInterface)
+		case "PHYSOUT":
+			l.PhysOutputInterface = value
+			l.present.add(FieldPhysOutputInterface)
+		case "MAC":
+			l.MACAddress = value
+			l.present.add(FieldMACAddress)
+		case "SRC":
+			rawSource = value
+			l.Source = value
+			if o.canonicalAddresses {
+				l.Source = canonicalAddr(value)
+			}
+			l.present.add(FieldSource)
+			seen |= seenSource
+		case "DST":
+			rawDestination = value
+			l.Destination = value
+			if o.canonicalAddresses {
+				l.Destination = canonicalAddr(value)
+			}
+			l.present.add(FieldDestination)
+			seen |= seenDestination
+		case "LEN":
+			if transportLayer {
+				if l.isProtocol("UDPLITE") {
+					// the length field of UDP-Lite carries the checksum coverage
+					var coverage uint64
+					coverage, err = parseUintField("checksum-coverage", value, 10, 16)
+					l.ChecksumCoverage = uint16(coverage)
+					l.present.add(FieldChecksumCoverage)
+				}
+				// the length of UDP, which is not modeled
+				break
+			}
+			l.Length, err = parseUintField("len", value, 10, 64)
+			l.present.add(FieldLength)
+			seen |= seenLength
+		case "TOS":
+			var tos uint64
+			tos, err = parseHexOrDecimalField("tos", value, 8)
+			l.ToS = uint8(tos)
+			l.present.add(FieldToS)
+		case "PREC":
+			var prec uint64
+			prec, err = parseHexOrDecimalField("prec", value, 8)
+			l.Precedence = uint8(prec)
+			l.present.add(FieldPrecedence)
+		case "TTL":
+			l.TTL, err = parseUintField("ttl", value, 10, 64)
+			l.present.add(FieldTTL)
+			seen |= seenTTL
+		case "ID":
+			if transportLayer {
+				// the identifier of ICMP echo
+				var icmpID uint64
+				icmpID, err = parseUintField("icmp-id", value, 10, 16)
+				l.ICMPID = uint16(icmpID)
+				l.present.add(FieldICMPID)
+				break
+			}
+			l.ID, err = parseUintField("id", value, 10, 64)
+			l.present.add(FieldID)
+		case "FRAG":
+			l.Frag, err = parseIntField("frag", value, 64)
+			l.present.add(FieldFrag)
+		case "TC":
+			var tc uint64
+			// the kernel logs the traffic class in decimal, while some tools emit it in hex as well as TOS
+			tc, err = parseHexOrDecimalField("tc", value, 8)
+			l.TrafficClass = uint8(tc)
+			l.IsIPv6 = true
+			l.present.add(FieldTrafficClass)
+			l.present.add(FieldIsIPv6)
+		case "HOPLIMIT":
+			// the hop limit of IPv6 is equivalent to the TTL of IPv4
+			l.TTL, err = parseUintField("hoplimit", value, 10, 64)
+			l.IsIPv6 = true
+			l.present.add(FieldTTL)
+			l.present.add(FieldIsIPv6)
+			seen |= seenTTL
+		case "FLOWLBL":
+			var flowLabel uint64
+			flowLabel, err = parseUintField("flowlbl", value, 10, 32)
+			l.FlowLabel = uint32(flowLabel)
+			l.IsIPv6 = true
+			l.present.add(FieldFlowLabel)
+			l.present.add(FieldIsIPv6)
+		case "PROTO":
+			if transportLayer && l.isGRE() {
+				// the protocol type of the GRE header, e.g. "PROTO=GRE KEY=0x2a PROTO=0x880b"
+				var greProtocol uint64
+				greProtocol, err = parseHexOrDecimalField("gre-proto", value, 16)
+				l.GREProtocol = uint16(greProtocol)
+				l.present.add(FieldGREProtocol)
+				break
+			}
+			l.Protocol = value
+			l.present.add(FieldProtocol)
+			transportLayer = true
+			seen |= seenProtocol
+		case "TYPE":
+			l.Type, err = parseIntField("type", value, 64)
+			l.present.add(FieldType)
+		case "CODE":
+			l.Code, err = parseIntField("code", value, 64)
+			l.present.add(FieldCode)
+		case "MTU":
+			var mtu uint64
+			mtu, err = parseUintField("mtu", value, 10, 16)
+			l.MTU = uint16(mtu)
+			l.present.add(FieldMTU)
+		case "SPI":
+			var spi uint64
+			spi, err = parseHexField("spi", value, 32)
+			l.SPI = uint32(spi)
+			l.present.add(FieldSPI)
+		case "KEY":
+			var greKey uint64
+			greKey, err = parseHexOrDecimalField("gre-key", value, 32)
+			l.GREKey = uint32(greKey)
+			l.present.add(FieldGREKey)
+		case "SPT":
+			var sourcePort uint64
+			sourcePort, err = parseUintField("spt", value, 10, 16)
+			l.SourcePort = uint16(sourcePort)
+			l.present.add(FieldSourcePort)
+		case "DPT":
+			var destinationPort uint64
+			destinationPort, err = parseUintField("dpt", value, 10, 16)
+			l.DestinationPort = uint16(destinationPort)
+			l.present.add(FieldDestinationPort)
+		case "SEQ":
+			if l.IsICMP() {
+				// the sequence number of ICMP echo
+				var icmpSeq uint64
+				icmpSeq, err = parseUintField("icmp-seq", value, 10, 16)
+				l.ICMPSeq = uint16(icmpSeq)
+				l.present.add(FieldICMPSeq)
+				break
+			}
+			if l.isProtocol("ESP") || l.isProtocol("AH") {
+				// the sequence number of IPsec, which is distinct from the TCP one
+				var ipsecSeq uint64
+				ipsecSeq, err = parseUintField("ipsec-seq", value, 10, 32)
+				l.IPsecSequence = uint32(ipsecSeq)
+				l.present.add(FieldIPsecSequence)
+				break
+			}
+			l.Sequence, err = parseUintField("seq", value, 10, 64)
+			l.present.add(FieldSequence)
+		case "ACK":
+			// the acknowledgment number; the bare ACK is the flag, which is parsed by parseTCPFlag
+			l.AckSequence, err = parseUintField("ack", value, 10, 64)
+			l.present.add(FieldAckSequence)
+		case "WINDOW":
+			l.WindowSize, err = parseUintField("window", value, 10, 64)
+			l.present.add(FieldWindowSize)
+		case "RES":
+			l.Res, err = parseHexField("res", value, 64)
+			l.present.add(FieldRes)
+		case "URGP":
+			l.Urgp, err = parseUintField("urgp", value, 10, 64)
+			l.present.add(FieldUrgp)
+		case "UID":
+			l.UID, err = parseOwnerIDField("uid", value)
+			if value != "" {
+				l.present.add(FieldUID)
+			}
+		case "GID":
+			l.GID, err = parseOwnerIDField("gid", value)
+			if value != "" {
+				l.present.add(FieldGID)
+			}
+		case "MARK":
+			var mark uint64
+			mark, err = parseHexField("mark", value, 32)
+			l.Mark = uint32(mark)
+			l.HasMark = true
+			l.present.add(FieldMark)
+			l.present.add(FieldHasMark)
+		default:
+			if o.extraFields && isExtraFieldKey(key) {
+				if l.Extra == nil {
+					l.Extra = make(map[string]string)
+				}
+				l.Extra[key] = value
+				l.present.add(FieldExtra)
+			}
+		}
+		if err != nil && fail(err) {
+			return err
+		}
+	}
+
+	if seen&mandatory != mandatory {
+		errs = append(errs, &UnmatchedError{Reason: "missing mandatory fields: " + missingFieldNames(mandatory&^seen)})
+	}
+
+	if l.IPVersion = ipVersion(rawSource, rawDestination); l.IPVersion != 0 {
+		l.present.add(FieldIPVersion)
+	}
+	return joinErrors(errs...)
+}
+
+var mandatoryFieldNames = []struct {
+	bit  int
+	name string
+}{
+	{bit: seenSource, name: "SRC"},
+	{bit: seenDestination, name: "DST"},
+	{bit: seenLength, name: "LEN"},
+	{bit: seenProtocol, name: "PROTO"},
+	{bit: seenTTL, name: "TTL"},
+}
+
+// missingFieldNames returns the comma-separated names of the given seen* bits, e.g. "SRC, LEN".
+func missingFieldNames(missing int) string {
+	names := make([]string, 0, len(mandatoryFieldNames))
+	for _, field := range mandatoryFieldNames {
+		if missing&field.bit != 0 {
+			names = append(names, field.name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// parseIncompleteBytes parses the number of the bytes that follows INCOMPLETE, e.g. "[8 bytes]", and reports whether it is bracketed, i.e. the packet is truncated.
+// This consumes nothing if the next token is not bracketed.
+func parseIncompleteBytes(t *tokenizer, l *Log) (bool, error) {
+	pos := t.pos
+	token, ok := t.next()
+	if !ok || !strings.HasPrefix(token, "[") {
+		t.pos = pos
+		return false, nil
+	}
+	content := t.bracketed(token)
+	n, found := strings.CutSuffix(strings.TrimSpace(content), " bytes")
+	if !found {
+		return true, nil
+	}
+	var err error
+	l.IncompleteBytes, err = parseUintField("incomplete", n, 10, 64)
+	l.present.add(FieldIncompleteBytes)
+	return true, err
+}
+
+// parseIPFlag parses a bare flag of the IP header.
+func parseIPFlag(token string, l *Log) {
+	switch token {
+	case "CE":
+		l.CongestionExperienced = true
+		l.present.add(FieldCongestionExperienced)
+	case "DF":
+		l.DoNotFragment = true
+		l.present.add(FieldDoNotFragment)
+	case "MF":
+		l.MoreFragmentsFollowing = true
+		l.present.add(FieldMoreFragmentsFollowing)
+	}
+}
+
+// parseTCPFlag parses a bare flag of the TCP header.
+// Each flag is recognized by its token, so the flags can appear in any order (e.g. the ECN flags before or after the others).
+func parseTCPFlag(token string, l *Log) {
+	switch token {
+	case "URG":
+		l.Urgent = true
+		l.present.add(FieldUrgent)
+	case "ACK":
+		l.Ack = true
+		l.present.add(FieldAck)
+	case "PSH":
+		l.Push = true
+		l.present.add(FieldPush)
+	case "RST":
+		l.Reset = true
+		l.present.add(FieldReset)
+	case "SYN":
+		l.Syn = true
+		l.present.add(FieldSyn)
+	case "FIN":
+		l.Fin = true
+		l.present.add(FieldFin)
+	case "ECE":
+		l.ECE = true
+		l.present.add(FieldECE)
+	case "CWR":
+		l.CWR = true
+		l.present.add(FieldCWR)
+	case "NS":
+		l.NS = true
+		l.present.add(FieldNS)
+	}
+}
+
+// isExtraFieldKey reports whether the given key can be a key of Log.Extra, i.e. it consists of alphanumerics, underscores, and hyphens.
+func isExtraFieldKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '_' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// parseUintField parses the unsigned integer field. An empty value is regarded as zero.
+func parseUintField(field string, value string, base int, bitSize int) (uint64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	v, err := strconv.ParseUint(value, base, bitSize)
+	if err != nil {
+		return 0, &FieldConversionError{Field: field, Value: value, Err: err}
+	}
+	return v, nil
+}
+
+// parseIntField parses the signed decimal integer field. An empty value is regarded as zero.
+func parseIntField(field string, value string, bitSize int) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	v, err := strconv.ParseInt(value, 10, bitSize)
+	if err != nil {
+		return 0, &FieldConversionError{Field: field, Value: value, Err: err}
+	}
+	return v, nil
+}
+
+// parseHexField parses the "0x" prefixed hexadecimal field. An empty value is regarded as zero.
+func parseHexField(field string, value string, bitSize int) (uint64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	hex, found := strings.CutPrefix(value, "0x")
+	if !found {
+		return 0, &FieldConversionError{Field: field, Value: value, Err: errMissingHexPrefix}
+	}
+	v, err := strconv.ParseUint(hex, 16, bitSize)
+	if err != nil {
+		return 0, &FieldConversionError{Field: field, Value: value, Err: err}
+	}
+	return v, nil
+}
+
+// parseHexOrDecimalField parses the field that is either the hexadecimal with the "0x" prefix or the decimal, e.g. TOS and PREC that some configurations log in decimal.
+// An empty value is regarded as zero.
+func parseHexOrDecimalField(field string, value string, bitSize int) (uint64, error) {
+	if strings.HasPrefix(value, "0x") {
+		return parseHexField(field, value, bitSize)
+	}
+	return parseUintField(field, value, 10, bitSize)
+}
+
+// parseOwnerIDField parses the owner ID field (i.e. UID or GID). An empty value is regarded as absent, i.e. -1.
+func parseOwnerIDField(field string, value string) (int64, error) {
+	if value == "" {
+		return -1, nil
+	}
+	v, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return 0, &FieldConversionError{Field: field, Value: value, Err: err}
+	}
+	return int64(v), nil
+}
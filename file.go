@@ -0,0 +1,70 @@
+package iptables
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"io"
+	"os"
+)
+
+var (
+	gzipMagic  = []byte{0x1f, 0x8b}
+	bzip2Magic = []byte("BZh")
+)
+
+// ParseFile parses the iptables lines of the given file; see Parser.ParseFile for details.
+func ParseFile(path string) ([]*Log, []error) {
+	return defaultParser.ParseFile(path)
+}
+
+// ParseFile parses the iptables lines of the given file, which is transparently decompressed if it is compressed by gzip or bzip2 (e.g. the rotated "kern.log.2.gz").
+// The compression is detected by the magic bytes of the content, not by the extension of the path.
+// The file is read as a stream, so the whole content is never loaded into memory at once.
+// The returned errors are *LineError for the lines that fail to parse, as well as ParseLines; unlike ParseLines, the logs and the errors are not aligned, so refer to LineError.Line for the line number.
+// If the file cannot be opened or decompressed, the error is returned as the sole error.
+func (p *Parser) ParseFile(path string) ([]*Log, []error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, []error{err}
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	r, err := decompressingReader(f)
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	var logs []*Log
+	var errs []error
+	for parsedLog, err := range p.ParseReader(r) {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		logs = append(logs, parsedLog)
+	}
+	return logs, errs
+}
+
+// decompressingReader returns the reader that decompresses the content of the given reader according to its magic bytes.
+// The content that is not compressed is returned as it is.
+func decompressingReader(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(len(bzip2Magic))
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		return gzip.NewReader(br)
+	case bytes.HasPrefix(magic, bzip2Magic):
+		return bzip2.NewReader(br), nil
+	default:
+		return br, nil
+	}
+}
@@ -0,0 +1,106 @@
+package iptables
+
+import (
+	"compress/gzip"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// the base64 of bzip2 compressed readerTestICMPLine with a newline, since the standard library has no bzip2 writer
+const fileTestBzip2ICMPLine = "QlpoOTFBWSZTWYU6G1YAADtfgAAQQAN/8g+3/ioyH15gIACFEVPZNTCNTQwjQ00GnpAPTKEUyPU0GjQAAAAACpvLpwZPSnHWdjGrTeyTesZqkWnocQUVyC1NkFA1AeFgdNBxJrnUEoiYsJkDOgBDZVvEOVy7u2GWHND8SPip4ll3nIAlA1ornUVgMlgyXFB2hTZKcwZ6lAzaAbUBGokYEUiAI8Pr864VqOH7OJJYOIyXGRgz+LuSKcKEhCnQ2rA="
+
+func TestParseFile(t *testing.T) {
+	dir := t.TempDir()
+	content := readerTestTCPLine + "\n" + "this is not an iptables log\n" + readerTestICMPLine + "\n"
+
+	plainPath := filepath.Join(dir, "kern.log")
+	if err := os.WriteFile(plainPath, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	gzipPath := filepath.Join(dir, "kern.log.1.gz")
+	writeGzipFile(t, gzipPath, content)
+
+	// the compression is detected by the magic bytes regardless of the extension
+	gzipWithoutExtPath := filepath.Join(dir, "kern.log.2")
+	writeGzipFile(t, gzipWithoutExtPath, content)
+
+	for _, path := range []string{plainPath, gzipPath, gzipWithoutExtPath} {
+		logs, errs := ParseFile(path)
+		assert.Len(t, logs, 2, path)
+		assert.Equal(t, "TCP", logs[0].Protocol)
+		assert.Equal(t, "ICMP", logs[1].Protocol)
+
+		assert.Len(t, errs, 1, path)
+		var lineErr *LineError
+		assert.True(t, errors.As(errs[0], &lineErr))
+		assert.Equal(t, 2, lineErr.Line)
+		assert.True(t, errors.Is(errs[0], ErrLogFormatUnmatched))
+	}
+}
+
+func TestParseFile_Bzip2(t *testing.T) {
+	b, err := base64.StdEncoding.DecodeString(fileTestBzip2ICMPLine)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "kern.log.1.bz2")
+	if err := os.WriteFile(path, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	logs, errs := ParseFile(path)
+	assert.Empty(t, errs)
+	assert.Len(t, logs, 1)
+	assert.Equal(t, "ICMP", logs[0].Protocol)
+}
+
+func TestParseFile_Error(t *testing.T) {
+	dir := t.TempDir()
+
+	logs, errs := ParseFile(filepath.Join(dir, "not-exist.log"))
+	assert.Nil(t, logs)
+	assert.Len(t, errs, 1)
+	assert.True(t, errors.Is(errs[0], os.ErrNotExist))
+
+	// empty file
+	emptyPath := filepath.Join(dir, "empty.log")
+	if err := os.WriteFile(emptyPath, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	logs, errs = ParseFile(emptyPath)
+	assert.Empty(t, logs)
+	assert.Empty(t, errs)
+
+	// broken gzip
+	brokenPath := filepath.Join(dir, "broken.log.gz")
+	if err := os.WriteFile(brokenPath, []byte{0x1f, 0x8b, 0x00}, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	logs, errs = ParseFile(brokenPath)
+	assert.Nil(t, logs)
+	assert.Len(t, errs, 1)
+}
+
+func writeGzipFile(t *testing.T, path string, content string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	w := gzip.NewWriter(f)
+	if _, err := w.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,302 @@
+package iptables
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Format returns the canonical iptables line of the log, i.e. the line in the format the kernel logs, which Parse can parse back.
+// The fields are emitted in the order the kernel emits them, and the optional fields are emitted only when they are present (see Has) or non-zero.
+// The syslog tag is always "kernel:". The syslog priority is emitted when Log.HasPriority is true, which can be parsed back with WithPriority.
+// Note that the information that Log doesn't hold (e.g. the length of UDP) is not reproduced.
+func (l *Log) Format() string {
+	var b strings.Builder
+	b.Grow(256)
+
+	if l.HasPriority {
+		b.WriteByte('<')
+		b.WriteString(strconv.Itoa(int(l.Facility)*8 + int(l.Severity)))
+		b.WriteByte('>')
+	}
+
+	timestamp := l.Timestamp
+	if timestamp == "" && !l.TimestampParsed.IsZero() {
+		timestamp = l.TimestampParsed.Format(syslogTimestampLayout)
+	}
+	if timestamp == "" {
+		timestamp = "-"
+	}
+	hostname := l.Hostname
+	if hostname == "" {
+		hostname = "-"
+	}
+	b.WriteString(timestamp)
+	b.WriteByte(' ')
+	b.WriteString(hostname)
+	b.WriteString(" kernel: [")
+	b.WriteString(formatKernelTimestamp(l.KernelTimestamp))
+	b.WriteString("] ")
+
+	if l.Prefix != "" || l.Has(FieldPrefix) {
+		// the blank prefix is written as the extra space
+		b.WriteString(l.Prefix)
+		b.WriteByte(' ')
+	}
+
+	b.WriteString("IN=")
+	b.WriteString(l.InputInterface)
+	b.WriteString(" OUT=")
+	b.WriteString(l.OutputInterface)
+	if l.PhysInputInterface != "" {
+		b.WriteString(" PHYSIN=")
+		b.WriteString(l.PhysInputInterface)
+	}
+	if l.PhysOutputInterface != "" {
+		b.WriteString(" PHYSOUT=")
+		b.WriteString(l.PhysOutputInterface)
+	}
+	if l.MACAddress != "" || l.Has(FieldMACAddress) {
+		b.WriteString(" MAC=")
+		b.WriteString(l.MACAddress)
+	}
+	b.WriteByte(' ')
+
+	l.formatPacket(&b)
+
+	if l.UID >= 0 && (l.UID != 0 || l.Has(FieldUID)) {
+		b.WriteString(" UID=")
+		b.WriteString(strconv.FormatInt(l.UID, 10))
+	}
+	if l.GID >= 0 && (l.GID != 0 || l.Has(FieldGID)) {
+		b.WriteString(" GID=")
+		b.WriteString(strconv.FormatInt(l.GID, 10))
+	}
+	if l.HasMark || l.Mark != 0 {
+		b.WriteString(" MARK=0x")
+		b.WriteString(strconv.FormatUint(uint64(l.Mark), 16))
+	}
+
+	keys := make([]string, 0, len(l.Extra))
+	for key := range l.Extra {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		b.WriteByte(' ')
+		b.WriteString(key)
+		b.WriteByte('=')
+		b.WriteString(l.Extra[key])
+	}
+
+	return b.String()
+}
+
+// formatKernelTimestamp formats the kernel timestamp as the kernel does, i.e. the seconds padded to 5 characters and the microseconds, e.g. "  396.854443".
+func formatKernelTimestamp(kernelTimestamp float64) string {
+	s := strconv.FormatFloat(kernelTimestamp, 'f', 6, 64)
+	if len(s) < 12 {
+		s = strings.Repeat(" ", 12-len(s)) + s
+	}
+	return s
+}
+
+// formatPacket writes the network and transport layer fields of the log, i.e. from SRC to the end of the protocol specific fields.
+func (l *Log) formatPacket(b *strings.Builder) {
+	b.WriteString("SRC=")
+	b.WriteString(l.Source)
+	b.WriteString(" DST=")
+	b.WriteString(l.Destination)
+	b.WriteString(" LEN=")
+	b.WriteString(strconv.FormatUint(l.Length, 10))
+
+	if l.IsIPv6 {
+		b.WriteString(" TC=")
+		b.WriteString(strconv.FormatUint(uint64(l.TrafficClass), 10))
+		b.WriteString(" HOPLIMIT=")
+		b.WriteString(strconv.FormatUint(l.TTL, 10))
+		b.WriteString(" FLOWLBL=")
+		b.WriteString(strconv.FormatUint(uint64(l.FlowLabel), 10))
+	} else {
+		b.WriteString(" TOS=")
+		writeHexByte(b, l.ToS)
+		b.WriteString(" PREC=")
+		writeHexByte(b, l.Precedence)
+		b.WriteString(" TTL=")
+		b.WriteString(strconv.FormatUint(l.TTL, 10))
+		b.WriteString(" ID=")
+		b.WriteString(strconv.FormatUint(l.ID, 10))
+		if l.CongestionExperienced {
+			b.WriteString(" CE")
+		}
+		if l.DoNotFragment {
+			b.WriteString(" DF")
+		}
+		if l.MoreFragmentsFollowing {
+			b.WriteString(" MF")
+		}
+		if l.Frag != 0 || l.Has(FieldFrag) {
+			b.WriteString(" FRAG:")
+			b.WriteString(strconv.FormatInt(l.Frag, 10))
+		}
+	}
+	if l.IPOptions != "" {
+		b.WriteString(" OPT (")
+		b.WriteString(l.IPOptions)
+		b.WriteByte(')')
+	}
+
+	b.WriteString(" PROTO=")
+	b.WriteString(l.Protocol)
+
+	if l.Incomplete {
+		// the fields of the transport layer are not available
+		b.WriteString(" INCOMPLETE [")
+		b.WriteString(strconv.FormatUint(l.IncompleteBytes, 10))
+		b.WriteString(" bytes]")
+		return
+	}
+
+	switch {
+	case l.isProtocol("TCP"):
+		l.formatPorts(b)
+		if l.Sequence != 0 || l.Has(FieldSequence) {
+			b.WriteString(" SEQ=")
+			b.WriteString(strconv.FormatUint(l.Sequence, 10))
+		}
+		if l.AckSequence != 0 || l.Has(FieldAckSequence) {
+			b.WriteString(" ACK=")
+			b.WriteString(strconv.FormatUint(l.AckSequence, 10))
+		}
+		b.WriteString(" WINDOW=")
+		b.WriteString(strconv.FormatUint(l.WindowSize, 10))
+		b.WriteString(" RES=")
+		writeHexByte(b, uint8(l.Res))
+		// the order of the kernel, i.e. from the most significant bit
+		for _, flag := range []struct {
+			set  bool
+			name string
+		}{
+			{set: l.NS, name: "NS"},
+			{set: l.CWR, name: "CWR"},
+			{set: l.ECE, name: "ECE"},
+			{set: l.Urgent, name: "URG"},
+			{set: l.Ack, name: "ACK"},
+			{set: l.Push, name: "PSH"},
+			{set: l.Reset, name: "RST"},
+			{set: l.Syn, name: "SYN"},
+			{set: l.Fin, name: "FIN"},
+		} {
+			if flag.set {
+				b.WriteByte(' ')
+				b.WriteString(flag.name)
+			}
+		}
+		b.WriteString(" URGP=")
+		b.WriteString(strconv.FormatUint(l.Urgp, 10))
+		if l.TCPOption != "" {
+			b.WriteString(" OPT (")
+			b.WriteString(l.TCPOption)
+			b.WriteByte(')')
+		}
+	case l.IsICMP():
+		b.WriteString(" TYPE=")
+		b.WriteString(strconv.FormatInt(l.Type, 10))
+		b.WriteString(" CODE=")
+		b.WriteString(strconv.FormatInt(l.Code, 10))
+		if l.ICMPID != 0 || l.ICMPSeq != 0 || l.Has(FieldICMPID) || l.Has(FieldICMPSeq) {
+			b.WriteString(" ID=")
+			b.WriteString(strconv.FormatUint(uint64(l.ICMPID), 10))
+			b.WriteString(" SEQ=")
+			b.WriteString(strconv.FormatUint(uint64(l.ICMPSeq), 10))
+		}
+		if l.MTU != 0 || l.Has(FieldMTU) {
+			b.WriteString(" MTU=")
+			b.WriteString(strconv.FormatUint(uint64(l.MTU), 10))
+		}
+		if l.Inner != nil {
+			b.WriteString(" [")
+			l.Inner.formatPacket(b)
+			b.WriteString(" ]")
+		}
+	case l.isProtocol("ESP") || l.isProtocol("AH"):
+		b.WriteString(" SPI=0x")
+		b.WriteString(strconv.FormatUint(uint64(l.SPI), 16))
+		if l.IPsecSequence != 0 || l.Has(FieldIPsecSequence) {
+			b.WriteString(" SEQ=")
+			b.WriteString(strconv.FormatUint(uint64(l.IPsecSequence), 10))
+		}
+	case l.isGRE():
+		if l.GREKey != 0 || l.Has(FieldGREKey) {
+			b.WriteString(" KEY=0x")
+			b.WriteString(strconv.FormatUint(uint64(l.GREKey), 16))
+		}
+		if l.GREProtocol != 0 || l.Has(FieldGREProtocol) {
+			b.WriteString(" PROTO=0x")
+			b.WriteString(strconv.FormatUint(uint64(l.GREProtocol), 16))
+		}
+	case l.HasTransportPorts():
+		l.formatPorts(b)
+		if l.isProtocol("UDPLITE") && (l.ChecksumCoverage != 0 || l.Has(FieldChecksumCoverage)) {
+			b.WriteString(" LEN=")
+			b.WriteString(strconv.FormatUint(uint64(l.ChecksumCoverage), 10))
+		}
+	}
+}
+
+func (l *Log) formatPorts(b *strings.Builder) {
+	b.WriteString(" SPT=")
+	b.WriteString(strconv.FormatUint(uint64(l.SourcePort), 10))
+	b.WriteString(" DPT=")
+	b.WriteString(strconv.FormatUint(uint64(l.DestinationPort), 10))
+}
+
+// writeHexByte writes the byte in the "0x%02X" format.
+func writeHexByte(b *strings.Builder, v uint8) {
+	const digits = "0123456789ABCDEF"
+	b.WriteString("0x")
+	b.WriteByte(digits[v>>4])
+	b.WriteByte(digits[v&0x0f])
+}
+
+// MarshalText implements encoding.TextMarshaler; this returns the canonical iptables line of the log (see Format).
+func (l *Log) MarshalText() ([]byte, error) {
+	return []byte(l.Format()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler; this parses the given iptables line into the log as well as ParseInto does.
+// The error of the parsing is returned as it is.
+func (l *Log) UnmarshalText(text []byte) error {
+	return defaultParser.ParseInto(string(text), l)
+}
+
+// jsonLog is Log without the methods, so that the JSON encoding of Log is the struct one rather than the text one by MarshalText.
+type jsonLog Log
+
+// MarshalJSON implements json.Marshaler; this encodes the log as a JSON object of its fields, not as the iptables line.
+// This is necessary since encoding/json prefers MarshalText to the struct encoding otherwise.
+func (l *Log) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	// the HTML escaping is up to the caller's encoder, which escapes the result again when it is enabled
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode((*jsonLog)(l)); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte{'\n'}), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler; this decodes a JSON object of the fields into the log, as the counterpart of MarshalJSON.
+// A JSON string is parsed as the iptables line as well as UnmarshalText does.
+func (l *Log) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		var line string
+		if err := json.Unmarshal(data, &line); err != nil {
+			return err
+		}
+		return l.UnmarshalText([]byte(line))
+	}
+	return json.Unmarshal(data, (*jsonLog)(l))
+}
@@ -0,0 +1,96 @@
+package iptables
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLog_Format(t *testing.T) {
+	type TestCase struct {
+		name string
+		line string
+	}
+
+	testCases := []*TestCase{
+		{
+			name: "tcp",
+			line: "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] INPUT-DROP: IN=enp0s3 OUT= MAC=00:b3:dd:bc:29:e1:52:54:00:12:35:02:08:00 SRC=10.0.2.2 DST=10.0.2.15 LEN=44 TOS=0x00 PREC=0x00 TTL=64 ID=1234 DF PROTO=TCP SPT=54321 DPT=22 SEQ=1000 ACK=0 WINDOW=65535 RES=0x00 CWR ECE SYN URGP=0 OPT (020405B4)",
+		},
+		{
+			name: "udp with priority, owner and mark",
+			line: "<4>Jul  1 00:00:00 host kernel: [    1.000001] IN= OUT=eth0 SRC=10.0.2.15 DST=10.0.2.2 LEN=60 TOS=0x10 PREC=0x00 TTL=64 ID=0 MF FRAG:185 PROTO=UDP SPT=53 DPT=40000 UID=1000 GID=1000 MARK=0x2a",
+		},
+		{
+			name: "ipv6 icmp with inner packet",
+			line: "Jul 22 10:00:00 ubuntu-jammy kernel: [ 5000.423456] IN6-LOG: IN=enp0s3 OUT= SRC=2001:0db8:0000:0000:0000:0000:0000:0002 DST=2001:0db8:0000:0000:0000:0000:0000:0001 LEN=1280 TC=0 HOPLIMIT=64 FLOWLBL=0 PROTO=ICMPv6 TYPE=2 CODE=0 MTU=1280 [SRC=2001:0db8:0000:0000:0000:0000:0000:0001 DST=2001:0db8:0000:0000:0000:0000:0000:0002 LEN=1500 TC=0 HOPLIMIT=64 FLOWLBL=0 PROTO=TCP SPT=443 DPT=54832 WINDOW=502 RES=0x00 ACK URGP=0 ]",
+		},
+		{
+			name: "icmp echo",
+			line: "Jul 21 05:38:28 ubuntu-jammy kernel: [14879.600492] IN=enp0s3 OUT= SRC=10.0.2.2 DST=10.0.2.15 LEN=84 TOS=0x00 PREC=0x00 TTL=64 ID=1234 DF PROTO=ICMP TYPE=8 CODE=0 ID=42 SEQ=1",
+		},
+		{
+			name: "esp",
+			line: "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] IPSEC-IN: IN=enp0s3 OUT= SRC=198.51.100.1 DST=10.0.2.15 LEN=152 TOS=0x00 PREC=0x00 TTL=57 ID=0 DF PROTO=ESP SPI=0x1000 SEQ=42",
+		},
+		{
+			name: "extra fields",
+			line: "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] IN=enp0s3 OUT= SRC=10.0.2.2 DST=10.0.2.15 LEN=52 TOS=0x00 PREC=0x00 TTL=64 ID=1 PROTO=47 A=1 B=2",
+		},
+	}
+
+	p, err := NewParser(WithPriority(true))
+	assert.NoError(t, err)
+	for _, testCase := range testCases {
+		parsedLog, err := p.Parse(testCase.line)
+		assert.NoError(t, err, testCase.name)
+		assert.Equal(t, testCase.line, parsedLog.Format(), testCase.name)
+	}
+}
+
+func TestLog_MarshalText(t *testing.T) {
+	line := "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] INPUT-DROP: IN=enp0s3 OUT= SRC=10.0.2.2 DST=10.0.2.15 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=1234 DF PROTO=UDP SPT=54321 DPT=53"
+
+	var l Log
+	assert.NoError(t, l.UnmarshalText([]byte(line)))
+	assert.Equal(t, "10.0.2.2", l.Source)
+	assert.Equal(t, uint16(53), l.DestinationPort)
+
+	text, err := l.MarshalText()
+	assert.NoError(t, err)
+	assert.Equal(t, line, string(text))
+
+	err = l.UnmarshalText([]byte("invalid line"))
+	assert.ErrorIs(t, err, ErrLogFormatUnmatched)
+}
+
+func TestLog_MarshalJSON(t *testing.T) {
+	parsedLog, err := Parse("Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] <DROP>: IN=enp0s3 OUT= SRC=10.0.2.2 DST=10.0.2.15 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=1234 DF PROTO=UDP SPT=54321 DPT=53")
+	assert.NoError(t, err)
+
+	// the log is still encoded as a JSON object rather than the text of MarshalText
+	b, err := json.Marshal(parsedLog)
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), `"prefix":"\u003cDROP\u003e:"`)
+	assert.Contains(t, string(b), `"source":"10.0.2.2"`)
+
+	// the HTML escaping is up to the encoder of the caller
+	var buf bytes.Buffer
+	assert.NoError(t, NewEncoder(&buf).Encode(parsedLog))
+	assert.Contains(t, buf.String(), `"prefix":"<DROP>:"`)
+
+	var decoded Log
+	assert.NoError(t, json.Unmarshal(b, &decoded))
+	assert.True(t, parsedLog.TimestampParsed.Equal(decoded.TimestampParsed))
+	// the presence of the fields is tested by TestLog_Has, and the location of the timestamp is not retained by JSON
+	parsedLog.present = FieldSet{}
+	parsedLog.TimestampParsed = decoded.TimestampParsed
+	assert.Equal(t, *parsedLog, decoded)
+
+	// a string in JSON is decoded as the iptables line
+	var fromLine Log
+	assert.NoError(t, json.Unmarshal([]byte(`"Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] IN=enp0s3 OUT= SRC=10.0.2.2 DST=10.0.2.15 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=1234 DF PROTO=UDP SPT=54321 DPT=53"`), &fromLine))
+	assert.Equal(t, "10.0.2.15", fromLine.Destination)
+}
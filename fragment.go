@@ -0,0 +1,18 @@
+package iptables
+
+// fragmentOffsetUnit is the unit of the fragment offset of the IP header in bytes.
+const fragmentOffsetUnit = 8
+
+// IsFragment reports whether the packet is a fragment, i.e. either more fragments follow it or it has the non-zero fragment offset.
+func (l *Log) IsFragment() bool {
+	return l.MoreFragmentsFollowing || l.Frag > 0
+}
+
+// FragmentOffsetBytes returns the fragment offset in bytes.
+// For IPv4, Log.Frag holds it in the 8-byte units, while ip6tables logs it in bytes as it is.
+func (l *Log) FragmentOffsetBytes() int64 {
+	if l.IsIPv6 {
+		return l.Frag
+	}
+	return l.Frag * fragmentOffsetUnit
+}
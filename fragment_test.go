@@ -0,0 +1,87 @@
+package iptables
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLog_IsFragment(t *testing.T) {
+	type TestCase struct {
+		line                        string
+		expectedIsFragment          bool
+		expectedFragmentOffsetBytes int64
+	}
+
+	testCases := []*TestCase{
+		{
+			// the first fragment
+			line:                        "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] IN=enp0s3 OUT= SRC=10.0.2.2 DST=10.0.2.15 LEN=1500 TOS=0x00 PREC=0x00 TTL=64 ID=40000 MF PROTO=UDP SPT=40000 DPT=53 LEN=3008",
+			expectedIsFragment:          true,
+			expectedFragmentOffsetBytes: 0,
+		},
+		{
+			// the last fragment, which is logged by the kernel
+			line:                        "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] IN=enp0s3 OUT= SRC=10.0.2.2 DST=10.0.2.15 LEN=1528 TOS=0x00 PREC=0x00 TTL=64 ID=40000 FRAG:185 PROTO=UDP",
+			expectedIsFragment:          true,
+			expectedFragmentOffsetBytes: 1480,
+		},
+		{
+			// the middle fragment
+			line:                        "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] IN=enp0s3 OUT= SRC=10.0.2.2 DST=10.0.2.15 LEN=1500 TOS=0x00 PREC=0x00 TTL=64 ID=40000 MF FRAG=185 PROTO=UDP",
+			expectedIsFragment:          true,
+			expectedFragmentOffsetBytes: 1480,
+		},
+		{
+			// the first fragment of IPv6, whose M flag is logged as the bare INCOMPLETE by ip6tables
+			line:                        "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] IN=enp0s3 OUT= SRC=2001:db8::2 DST=2001:db8::15 LEN=1280 TC=0 HOPLIMIT=64 FLOWLBL=0 FRAG:0 INCOMPLETE ID:12345678 PROTO=UDP SPT=40000 DPT=53 LEN=3008",
+			expectedIsFragment:          true,
+			expectedFragmentOffsetBytes: 0,
+		},
+		{
+			// the last fragment of IPv6, whose offset is logged in bytes by ip6tables
+			line:                        "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] IN=enp0s3 OUT= SRC=2001:db8::2 DST=2001:db8::15 LEN=1280 TC=0 HOPLIMIT=64 FLOWLBL=0 FRAG:1232 ID:12345678 PROTO=UDP",
+			expectedIsFragment:          true,
+			expectedFragmentOffsetBytes: 1232,
+		},
+		{
+			// not a fragment
+			line:                        "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] IN=enp0s3 OUT= SRC=10.0.2.2 DST=10.0.2.15 LEN=64 TOS=0x00 PREC=0x00 TTL=64 ID=40000 DF PROTO=UDP SPT=40000 DPT=53 LEN=44",
+			expectedIsFragment:          false,
+			expectedFragmentOffsetBytes: 0,
+		},
+	}
+
+	for _, testCase := range testCases {
+		parsedLog, err := Parse(testCase.line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, testCase.expectedIsFragment, parsedLog.IsFragment())
+		assert.Equal(t, testCase.expectedFragmentOffsetBytes, parsedLog.FragmentOffsetBytes())
+	}
+}
+
+func TestParse_IPv6FragmentIncomplete(t *testing.T) {
+	parsedLog, err := Parse("Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] IN=enp0s3 OUT= SRC=2001:db8::2 DST=2001:db8::15 LEN=1280 TC=0 HOPLIMIT=64 FLOWLBL=0 FRAG:0 INCOMPLETE ID:12345678 PROTO=UDP SPT=40000 DPT=53 LEN=3008")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the bare INCOMPLETE is the M flag of the fragment header rather than the truncation
+	assert.True(t, parsedLog.MoreFragmentsFollowing)
+	assert.True(t, parsedLog.Has(FieldMoreFragmentsFollowing))
+	assert.False(t, parsedLog.Incomplete)
+	assert.False(t, parsedLog.Has(FieldIncomplete))
+	assert.Equal(t, uint64(0x12345678), parsedLog.ID)
+	assert.True(t, parsedLog.Has(FieldID))
+	assert.Equal(t, "UDP", parsedLog.Protocol)
+	assert.Equal(t, uint16(40000), parsedLog.SourcePort)
+	assert.Equal(t, uint16(53), parsedLog.DestinationPort)
+}
+
+func TestParse_IPv6FragmentID(t *testing.T) {
+	_, err := Parse("Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] IN=enp0s3 OUT= SRC=2001:db8::2 DST=2001:db8::15 LEN=1280 TC=0 HOPLIMIT=64 FLOWLBL=0 FRAG:1232 ID:xyz PROTO=UDP")
+	var convErr *FieldConversionError
+	assert.ErrorAs(t, err, &convErr)
+	assert.Equal(t, "id", convErr.Field)
+}
@@ -0,0 +1,40 @@
+package iptables
+
+// ICMP types of the echo messages
+const (
+	ICMPTypeEchoReply     = 0
+	ICMPTypeEchoRequest   = 8
+	ICMPv6TypeEchoRequest = 128
+	ICMPv6TypeEchoReply   = 129
+)
+
+// ICMPv6 types of the neighbor discovery messages (RFC 4861)
+const (
+	ICMPv6TypeRouterSolicitation    = 133
+	ICMPv6TypeRouterAdvertisement   = 134
+	ICMPv6TypeNeighborSolicitation  = 135
+	ICMPv6TypeNeighborAdvertisement = 136
+	ICMPv6TypeRedirect              = 137
+)
+
+// IsICMP reports whether the protocol is either ICMP or ICMPv6, given either by the name or by the number (e.g. "PROTO=1").
+func (l *Log) IsICMP() bool {
+	return l.isProtocol("ICMP") || l.isProtocol("ICMPv6")
+}
+
+// ICMPEcho returns the identifier and the sequence number of the ICMP (or ICMPv6) echo request/reply.
+// The third return value is false if the log is not an echo request/reply.
+func (l *Log) ICMPEcho() (id uint16, seq uint16, ok bool) {
+	switch {
+	case l.isProtocol("ICMP") && (l.Type == ICMPTypeEchoRequest || l.Type == ICMPTypeEchoReply),
+		l.isProtocol("ICMPv6") && (l.Type == ICMPv6TypeEchoRequest || l.Type == ICMPv6TypeEchoReply):
+		return l.ICMPID, l.ICMPSeq, true
+	default:
+		return 0, 0, false
+	}
+}
+
+// IsNeighborDiscovery reports whether the log is an ICMPv6 neighbor discovery message, i.e. the router/neighbor solicitation/advertisement or the redirect.
+func (l *Log) IsNeighborDiscovery() bool {
+	return l.isProtocol("ICMPv6") && ICMPv6TypeRouterSolicitation <= l.Type && l.Type <= ICMPv6TypeRedirect
+}
@@ -0,0 +1,197 @@
+package iptables
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLog_ICMPEcho(t *testing.T) {
+	type TestCase struct {
+		line        string
+		expectedID  uint16
+		expectedSeq uint16
+		expectedOK  bool
+	}
+
+	testCases := []*TestCase{
+		{
+			// echo request
+			line:        "Jul 21 05:38:28 ubuntu-jammy kernel: [14879.600492] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=84 TOS=0x00 PREC=0x00 TTL=64 ID=6495 DF PROTO=ICMP TYPE=8 CODE=0 ID=4321 SEQ=3",
+			expectedID:  4321,
+			expectedSeq: 3,
+			expectedOK:  true,
+		},
+		{
+			// echo reply
+			line:        "Jul 21 05:38:28 ubuntu-jammy kernel: [14879.612345] IN-LOG: IN=enp0s3 OUT= MAC=00:b3:dd:bc:29:e1:52:54:00:12:35:02:08:00 SRC=8.8.8.8 DST=10.0.2.15 LEN=84 TOS=0x00 PREC=0x00 TTL=117 ID=0 PROTO=ICMP TYPE=0 CODE=0 ID=4321 SEQ=3",
+			expectedID:  4321,
+			expectedSeq: 3,
+			expectedOK:  true,
+		},
+		{
+			// ICMPv6 echo request
+			line:        "Jul 22 10:00:00 ubuntu-jammy kernel: [ 5000.123456] OUT-LOG: IN= OUT=enp0s3 SRC=2001:0db8:0000:0000:0000:0000:0000:0001 DST=2001:4860:4860:0000:0000:0000:0000:8888 LEN=104 TC=0 HOPLIMIT=64 FLOWLBL=123456 PROTO=ICMPv6 TYPE=128 CODE=0 ID=7 SEQ=65535",
+			expectedID:  7,
+			expectedSeq: 65535,
+			expectedOK:  true,
+		},
+		{
+			// destination unreachable
+			line:        "Jul 21 05:38:28 ubuntu-jammy kernel: [14879.600492] IN-LOG: IN=enp0s3 OUT= SRC=10.0.2.2 DST=10.0.2.15 LEN=112 TOS=0x00 PREC=0xC0 TTL=64 ID=1234 PROTO=ICMP TYPE=3 CODE=3 [SRC=10.0.2.15 DST=10.0.2.2 LEN=84 TOS=0x00 PREC=0x00 TTL=64 ID=4321 PROTO=UDP SPT=41234 DPT=33434 LEN=64 ]",
+			expectedID:  0,
+			expectedSeq: 0,
+			expectedOK:  false,
+		},
+		{
+			// not ICMP
+			line:        "Jul 20 13:24:22 ubuntu-jammy kernel: [  396.854443] IN=enp0s3 OUT= MAC=00:b3:dd:bc:29:e1:52:54:00:12:35:02:08:00 SRC=10.0.2.2 DST=10.0.2.15 LEN=76 TOS=0x00 PREC=0x00 TTL=64 ID=5525 PROTO=TCP SPT=59076 DPT=22 SEQ=8 WINDOW=65535 RES=0x00 ACK PSH URGP=0",
+			expectedID:  0,
+			expectedSeq: 0,
+			expectedOK:  false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		parsedLog, err := Parse(testCase.line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		id, seq, ok := parsedLog.ICMPEcho()
+		assert.Equal(t, testCase.expectedID, id)
+		assert.Equal(t, testCase.expectedSeq, seq)
+		assert.Equal(t, testCase.expectedOK, ok)
+	}
+}
+
+func TestParse_ICMPErrorInnerPacket(t *testing.T) {
+	// fragmentation needed
+	parsedLog, err := Parse("Jul 21 05:38:28 ubuntu-jammy kernel: [14879.600492] IN-LOG: IN=enp0s3 OUT= MAC=00:b3:dd:bc:29:e1:52:54:00:12:35:02:08:00 SRC=192.0.2.1 DST=10.0.2.15 LEN=576 TOS=0x00 PREC=0xC0 TTL=254 ID=39147 PROTO=ICMP TYPE=3 CODE=4 MTU=1400 [SRC=10.0.2.15 DST=198.51.100.7 LEN=1500 TOS=0x00 PREC=0x00 TTL=63 ID=12345 DF PROTO=TCP SPT=54832 DPT=443 SEQ=567002889 ACK=1134538652 WINDOW=502 RES=0x00 ACK PSH URGP=0 ] MARK=0x2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, int64(3), parsedLog.Type)
+	assert.Equal(t, int64(4), parsedLog.Code)
+	assert.Equal(t, uint16(1400), parsedLog.MTU)
+	assert.Equal(t, uint32(2), parsedLog.Mark)
+	// the fields of the inner packet must not be mixed into the outer one
+	assert.Equal(t, "192.0.2.1", parsedLog.Source)
+	assert.Equal(t, uint64(576), parsedLog.Length)
+	assert.Equal(t, uint16(0), parsedLog.SourcePort)
+
+	// the presence of the fields is tested by TestLog_Has
+	parsedLog.Inner.present = FieldSet{}
+	assert.Equal(t, &Log{
+		Source:          "10.0.2.15",
+		Destination:     "198.51.100.7",
+		IPVersion:       4,
+		Length:          1500,
+		TTL:             63,
+		ID:              12345,
+		DoNotFragment:   true,
+		Protocol:        "TCP",
+		SourcePort:      54832,
+		DestinationPort: 443,
+		Sequence:        567002889,
+		AckSequence:     1134538652,
+		WindowSize:      502,
+		Ack:             true,
+		Push:            true,
+		UID:             -1,
+		GID:             -1,
+	}, parsedLog.Inner)
+}
+
+func TestParse_ICMPErrorInnerPacket_Nested(t *testing.T) {
+	// port unreachable whose original packet is truncated
+	parsedLog, err := Parse("Jul 21 05:38:28 ubuntu-jammy kernel: [14879.600492] IN-LOG: IN=enp0s3 OUT= SRC=10.0.2.2 DST=10.0.2.15 LEN=56 TOS=0x00 PREC=0xC0 TTL=64 ID=1234 PROTO=ICMP TYPE=3 CODE=3 [SRC=10.0.2.15 DST=10.0.2.2 LEN=84 TOS=0x00 PREC=0x00 TTL=64 ID=4321 PROTO=UDP INCOMPLETE [8 bytes] ] UID=0 GID=0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "10.0.2.15", parsedLog.Inner.Source)
+	assert.Equal(t, "UDP", parsedLog.Inner.Protocol)
+	assert.True(t, parsedLog.Inner.Incomplete)
+	assert.Equal(t, uint64(8), parsedLog.Inner.IncompleteBytes)
+	assert.False(t, parsedLog.Incomplete)
+	assert.Equal(t, int64(0), parsedLog.UID)
+	assert.Equal(t, int64(0), parsedLog.GID)
+}
+
+func TestParse_ICMPWithoutInnerPacket(t *testing.T) {
+	parsedLog, err := Parse("Jul 21 05:38:28 ubuntu-jammy kernel: [14879.600492] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=84 TOS=0x00 PREC=0x00 TTL=64 ID=6495 DF PROTO=ICMP TYPE=8 CODE=0 ID=1 SEQ=3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, parsedLog.Inner)
+}
+
+func TestParse_ICMPv6(t *testing.T) {
+	type TestCase struct {
+		line                        string
+		expectedType                int64
+		expectedCode                int64
+		expectedIsNeighborDiscovery bool
+		expectedInnerDestination    string
+	}
+
+	testCases := []*TestCase{
+		{
+			// neighbor solicitation
+			line:                        "Jul 22 10:00:00 ubuntu-jammy kernel: [ 5000.123456] IN6-LOG: IN=enp0s3 OUT= MAC=33:33:ff:00:00:01:52:54:00:12:35:02:86:dd SRC=fe80:0000:0000:0000:5054:00ff:fe12:3502 DST=ff02:0000:0000:0000:0000:0001:ff00:0001 LEN=72 TC=0 HOPLIMIT=255 FLOWLBL=0 PROTO=ICMPv6 TYPE=135 CODE=0 ",
+			expectedType:                ICMPv6TypeNeighborSolicitation,
+			expectedCode:                0,
+			expectedIsNeighborDiscovery: true,
+		},
+		{
+			// neighbor advertisement
+			line:                        "Jul 22 10:00:00 ubuntu-jammy kernel: [ 5000.123789] OUT6-LOG: IN= OUT=enp0s3 SRC=2001:0db8:0000:0000:0000:0000:0000:0001 DST=fe80:0000:0000:0000:5054:00ff:fe12:3502 LEN=72 TC=0 HOPLIMIT=255 FLOWLBL=0 PROTO=ICMPv6 TYPE=136 CODE=0",
+			expectedType:                ICMPv6TypeNeighborAdvertisement,
+			expectedCode:                0,
+			expectedIsNeighborDiscovery: true,
+		},
+		{
+			// router advertisement
+			line:                        "Jul 22 10:00:00 ubuntu-jammy kernel: [ 5000.223456] IN6-LOG: IN=enp0s3 OUT= SRC=fe80:0000:0000:0000:0000:0000:0000:0001 DST=ff02:0000:0000:0000:0000:0000:0000:0001 LEN=120 TC=224 HOPLIMIT=255 FLOWLBL=0 PROTO=ICMPv6 TYPE=134 CODE=0",
+			expectedType:                ICMPv6TypeRouterAdvertisement,
+			expectedCode:                0,
+			expectedIsNeighborDiscovery: true,
+		},
+		{
+			// destination unreachable (port unreachable) with the original packet
+			line:                        "Jul 22 10:00:00 ubuntu-jammy kernel: [ 5000.323456] IN6-LOG: IN=enp0s3 OUT= SRC=2001:0db8:0000:0000:0000:0000:0000:0002 DST=2001:0db8:0000:0000:0000:0000:0000:0001 LEN=112 TC=0 HOPLIMIT=64 FLOWLBL=0 PROTO=ICMPv6 TYPE=1 CODE=4 [SRC=2001:0db8:0000:0000:0000:0000:0000:0001 DST=2001:0db8:0000:0000:0000:0000:0000:0002 LEN=64 TC=0 HOPLIMIT=64 FLOWLBL=654321 PROTO=UDP SPT=41234 DPT=33434 LEN=24 ] ",
+			expectedType:                1,
+			expectedCode:                4,
+			expectedIsNeighborDiscovery: false,
+			expectedInnerDestination:    "2001:0db8:0000:0000:0000:0000:0000:0002",
+		},
+		{
+			// packet too big
+			line:                        "Jul 22 10:00:00 ubuntu-jammy kernel: [ 5000.423456] IN6-LOG: IN=enp0s3 OUT= SRC=2001:0db8:0000:0000:0000:0000:0000:0002 DST=2001:0db8:0000:0000:0000:0000:0000:0001 LEN=1280 TC=0 HOPLIMIT=64 FLOWLBL=0 PROTO=ICMPv6 TYPE=2 CODE=0 MTU=1280 [SRC=2001:0db8:0000:0000:0000:0000:0000:0001 DST=2001:0db8:0000:0000:0000:0000:0000:0002 LEN=1500 TC=0 HOPLIMIT=64 FLOWLBL=0 PROTO=TCP SPT=443 DPT=54832 WINDOW=502 RES=0x00 ACK URGP=0 ]",
+			expectedType:                2,
+			expectedCode:                0,
+			expectedIsNeighborDiscovery: false,
+			expectedInnerDestination:    "2001:0db8:0000:0000:0000:0000:0000:0002",
+		},
+	}
+
+	for _, testCase := range testCases {
+		parsedLog, err := Parse(testCase.line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.True(t, parsedLog.IsIPv6)
+		assert.Equal(t, "ICMPv6", parsedLog.Protocol)
+		assert.True(t, parsedLog.IsICMP())
+		assert.Equal(t, testCase.expectedType, parsedLog.Type)
+		assert.Equal(t, testCase.expectedCode, parsedLog.Code)
+		assert.Equal(t, testCase.expectedIsNeighborDiscovery, parsedLog.IsNeighborDiscovery())
+		if testCase.expectedInnerDestination == "" {
+			assert.Nil(t, parsedLog.Inner)
+		} else {
+			assert.Equal(t, testCase.expectedInnerDestination, parsedLog.Inner.Destination)
+		}
+		assert.NoError(t, parsedLog.Validate())
+	}
+}
@@ -0,0 +1,108 @@
+package iptables
+
+import (
+	"encoding/hex"
+)
+
+// The types of the IP options.
+const (
+	IPOptionEndOfList         uint8 = 0
+	IPOptionNoOperation       uint8 = 1
+	IPOptionRecordRoute       uint8 = 7
+	IPOptionTimestamp         uint8 = 68
+	IPOptionSecurity          uint8 = 130
+	IPOptionLooseSourceRoute  uint8 = 131
+	IPOptionStreamID          uint8 = 136
+	IPOptionStrictSourceRoute uint8 = 137
+	IPOptionRouterAlert       uint8 = 148
+)
+
+var ipOptionNames = map[uint8]string{
+	IPOptionEndOfList:         "End of Option List",
+	IPOptionNoOperation:       "No Operation",
+	IPOptionRecordRoute:       "Record Route",
+	IPOptionTimestamp:         "Timestamp",
+	IPOptionSecurity:          "Security",
+	IPOptionLooseSourceRoute:  "Loose Source Route",
+	IPOptionStreamID:          "Stream ID",
+	IPOptionStrictSourceRoute: "Strict Source Route",
+	IPOptionRouterAlert:       "Router Alert",
+}
+
+// IPOption is an option of the IP header.
+type IPOption struct {
+	// Type is the type of the option; see the IPOption* constants.
+	Type uint8
+	// Length is the length of the option including the type and the length octets, as it is declared in the option.
+	// This is 1 for the single-octet options, i.e. End of Option List and No Operation.
+	Length uint8
+	// Data is the raw data of the option, which excludes the type and the length octets.
+	Data []byte
+}
+
+// Name returns the name of the option type, e.g. "Record Route". This returns an empty string for the unknown type.
+func (o IPOption) Name() string {
+	return ipOptionNames[o.Type]
+}
+
+// IPOptionsDecoded decodes Log.IPOptions (i.e. the hex of "OPT (...)" before PROTO) into the IP options.
+// The options of the unknown types and the malformed ones (e.g. truncated) are returned with their raw data, rather than failing.
+// This returns nil when there is no option or the hex is invalid.
+func (l *Log) IPOptionsDecoded() []IPOption {
+	tlvs := decodeOptionTLVs(l.IPOptions)
+	if tlvs == nil {
+		return nil
+	}
+	ipOptions := make([]IPOption, len(tlvs))
+	for i, tlv := range tlvs {
+		ipOptions[i] = IPOption{Type: tlv.kind, Length: tlv.length, Data: tlv.data}
+	}
+	return ipOptions
+}
+
+// optionTLV is an option in the type-length-value format, which is shared by the IP and TCP options.
+type optionTLV struct {
+	kind   uint8
+	length uint8
+	data   []byte
+}
+
+// decodeOptionTLVs decodes the hex of the options into the TLVs.
+// The kinds 0 (End of Option List) and 1 (No Operation) consist of a single octet, and the decoding stops at End of Option List.
+// When the length of an option is malformed, the rest of the bytes is regarded as the data of the option.
+func decodeOptionTLVs(rawOptions string) []optionTLV {
+	if rawOptions == "" {
+		return nil
+	}
+	b, err := hex.DecodeString(rawOptions)
+	if err != nil {
+		return nil
+	}
+
+	var tlvs []optionTLV
+	for i := 0; i < len(b); {
+		kind := b[i]
+		if kind == 0 || kind == 1 {
+			tlvs = append(tlvs, optionTLV{kind: kind, length: 1, data: []byte{}})
+			if kind == 0 {
+				break
+			}
+			i++
+			continue
+		}
+
+		if i+1 >= len(b) {
+			// truncated; there is no length octet
+			tlvs = append(tlvs, optionTLV{kind: kind, data: []byte{}})
+			break
+		}
+		length := int(b[i+1])
+		if length < 2 || i+length > len(b) {
+			tlvs = append(tlvs, optionTLV{kind: kind, length: uint8(length), data: b[i+2:]})
+			break
+		}
+		tlvs = append(tlvs, optionTLV{kind: kind, length: uint8(length), data: b[i+2 : i+length]})
+		i += length
+	}
+	return tlvs
+}
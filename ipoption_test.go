@@ -0,0 +1,85 @@
+package iptables
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLog_IPOptionsDecoded(t *testing.T) {
+	type TestCase struct {
+		ipOptions string
+		expected  []IPOption
+	}
+
+	testCases := []*TestCase{
+		{
+			// Record Route with the room of two addresses, and End of Option List
+			ipOptions: "07070400000000000000000000",
+			expected: []IPOption{
+				{Type: IPOptionRecordRoute, Length: 7, Data: []byte{0x04, 0x00, 0x00, 0x00, 0x00}},
+				{Type: IPOptionEndOfList, Length: 1, Data: []byte{}},
+			},
+		},
+		{
+			// Router Alert
+			ipOptions: "94040000",
+			expected: []IPOption{
+				{Type: IPOptionRouterAlert, Length: 4, Data: []byte{0x00, 0x00}},
+			},
+		},
+		{
+			// No Operation, Loose Source Route, and Timestamp
+			ipOptions: "01830704C0A8000144040500",
+			expected: []IPOption{
+				{Type: IPOptionNoOperation, Length: 1, Data: []byte{}},
+				{Type: IPOptionLooseSourceRoute, Length: 7, Data: []byte{0x04, 0xc0, 0xa8, 0x00, 0x01}},
+				{Type: IPOptionTimestamp, Length: 4, Data: []byte{0x05, 0x00}},
+			},
+		},
+		{
+			// unknown type
+			ipOptions: "FE03AB",
+			expected: []IPOption{
+				{Type: 0xfe, Length: 3, Data: []byte{0xab}},
+			},
+		},
+		{
+			// truncated
+			ipOptions: "8907C0A8",
+			expected: []IPOption{
+				{Type: IPOptionStrictSourceRoute, Length: 7, Data: []byte{0xc0, 0xa8}},
+			},
+		},
+		{
+			ipOptions: "",
+			expected:  nil,
+		},
+		{
+			// invalid hex
+			ipOptions: "XYZ",
+			expected:  nil,
+		},
+	}
+
+	for _, testCase := range testCases {
+		l := &Log{IPOptions: testCase.ipOptions}
+		assert.Equal(t, testCase.expected, l.IPOptionsDecoded())
+	}
+}
+
+func TestIPOption_Name(t *testing.T) {
+	assert.Equal(t, "Record Route", IPOption{Type: IPOptionRecordRoute}.Name())
+	assert.Equal(t, "Strict Source Route", IPOption{Type: IPOptionStrictSourceRoute}.Name())
+	assert.Equal(t, "", IPOption{Type: 0xfe}.Name())
+}
+
+func TestParse_IPOptionsDecoded(t *testing.T) {
+	line := "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] IN=enp0s3 OUT= SRC=10.0.2.2 DST=224.0.0.22 LEN=40 TOS=0x00 PREC=0xC0 TTL=1 ID=0 DF OPT (94040000) PROTO=2"
+
+	parsedLog, err := Parse(line)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []IPOption{{Type: IPOptionRouterAlert, Length: 4, Data: []byte{0x00, 0x00}}}, parsedLog.IPOptionsDecoded())
+}
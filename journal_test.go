@@ -0,0 +1,116 @@
+package iptables
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseJournalJSON(t *testing.T) {
+	entry := `{"__CURSOR":"s=0;i=1","__REALTIME_TIMESTAMP":"1658381908123456","__MONOTONIC_TIMESTAMP":"14879600492","_BOOT_ID":"0","_SOURCE_MONOTONIC_TIMESTAMP":"14879600492","_TRANSPORT":"kernel","PRIORITY":"4","SYSLOG_FACILITY":"0","SYSLOG_IDENTIFIER":"kernel","_HOSTNAME":"ubuntu-jammy","MESSAGE":"OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=84 TOS=0x00 PREC=0x00 TTL=64 ID=6495 DF PROTO=ICMP TYPE=8 CODE=0 ID=1 SEQ=3"}`
+
+	p, err := NewParser(WithLocation(time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsedLog, err := p.ParseJournalJSON([]byte(entry))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "", parsedLog.Timestamp)
+	assert.False(t, parsedLog.Has(FieldTimestamp))
+	assert.Equal(t, time.Date(2022, time.July, 21, 5, 38, 28, 123456000, time.UTC), parsedLog.TimestampParsed)
+	assert.True(t, parsedLog.Has(FieldTimestampParsed))
+	assert.Equal(t, 14879.600492, parsedLog.KernelTimestamp)
+	assert.Equal(t, "ubuntu-jammy", parsedLog.Hostname)
+	assert.Equal(t, uint8(0), parsedLog.Facility)
+	assert.Equal(t, uint8(4), parsedLog.Severity)
+	assert.True(t, parsedLog.HasPriority)
+	assert.Equal(t, "OUT-LOG:", parsedLog.Prefix)
+	assert.Equal(t, "8.8.8.8", parsedLog.Destination)
+	assert.Equal(t, "ICMP", parsedLog.Protocol)
+}
+
+func TestParseJournalJSON_MinimalAndBinaryMessage(t *testing.T) {
+	message := "IN=enp0s3 OUT= SRC=10.0.2.2 DST=10.0.2.15 LEN=40 TTL=64 PROTO=UDP SPT=53 DPT=40000 LEN=20"
+	// the journal exports MESSAGE as an array of the bytes if it is not valid UTF-8
+	entry := `{"MESSAGE":` + toJSONIntArray([]byte(message)) + `}`
+
+	parsedLog, err := ParseJournalJSON([]byte(entry))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, uint16(53), parsedLog.SourcePort)
+	assert.False(t, parsedLog.Has(FieldTimestampParsed))
+	assert.False(t, parsedLog.Has(FieldKernelTimestamp))
+	assert.False(t, parsedLog.Has(FieldHostname))
+	assert.False(t, parsedLog.HasPriority)
+}
+
+func toJSONIntArray(b []byte) string {
+	ints := make([]string, len(b))
+	for i, c := range b {
+		ints[i] = strconv.Itoa(int(c))
+	}
+	return "[" + strings.Join(ints, ",") + "]"
+}
+
+func TestParseJournalJSON_Errors(t *testing.T) {
+	type TestCase struct {
+		entry         string
+		expectedError error
+	}
+
+	testCases := []*TestCase{
+		{entry: `{"__REALTIME_TIMESTAMP":"1658381908123456"}`, expectedError: ErrLogFormatUnmatched},
+		{entry: `not a JSON`, expectedError: ErrLogFormatUnmatched},
+		{entry: `{"MESSAGE":"this is not an iptables log"}`, expectedError: ErrLogFormatUnmatched},
+		{entry: `{"__REALTIME_TIMESTAMP":"yesterday","MESSAGE":"IN=enp0s3 OUT= SRC=10.0.2.2 DST=10.0.2.15 LEN=40 TTL=64 PROTO=UDP"}`, expectedError: ErrStringToNumberConversionFailed},
+		{entry: `{"PRIORITY":"8","MESSAGE":"IN=enp0s3 OUT= SRC=10.0.2.2 DST=10.0.2.15 LEN=40 TTL=64 PROTO=UDP"}`, expectedError: ErrStringToNumberConversionFailed},
+	}
+
+	for _, testCase := range testCases {
+		_, err := ParseJournalJSON([]byte(testCase.entry))
+		assert.ErrorIs(t, err, testCase.expectedError, testCase.entry)
+	}
+
+	_, err := ParseJournalJSON([]byte(`{"_HOSTNAME":"ubuntu-jammy"}`))
+	assert.EqualError(t, err, `given log text is not matched with the log format; reason = MESSAGE is not found in the journal entry, line = "{\"_HOSTNAME\":\"ubuntu-jammy\"}"`)
+}
+
+func TestParseJournalJSON_WithCollectAllErrors(t *testing.T) {
+	p, err := NewParser(WithCollectAllErrors(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry := `{"__REALTIME_TIMESTAMP":"yesterday","_HOSTNAME":"ubuntu-jammy","PRIORITY":"8","MESSAGE":"OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=84 TOS=0x00 PREC=0x00 TTL=6A ID=6495 DF PROTO=ICMP TYPE=8 CODE=0 ID=1 SEQ=3"}`
+	parsedLog, err := p.ParseJournalJSON([]byte(entry))
+	assert.ErrorIs(t, err, ErrStringToNumberConversionFailed)
+	joined, ok := err.(interface{ Unwrap() []error })
+	if assert.True(t, ok) {
+		// TTL of MESSAGE, __REALTIME_TIMESTAMP, and PRIORITY
+		assert.Len(t, joined.Unwrap(), 3)
+	}
+	if assert.NotNil(t, parsedLog) {
+		assert.Equal(t, "OUT-LOG:", parsedLog.Prefix)
+		assert.Equal(t, "ubuntu-jammy", parsedLog.Hostname)
+		assert.False(t, parsedLog.Has(FieldTimestampParsed))
+		assert.False(t, parsedLog.HasPriority)
+	}
+
+	// the first failure is returned without the log by default
+	parsedLog, err = ParseJournalJSON([]byte(entry))
+	var convErr *FieldConversionError
+	assert.ErrorAs(t, err, &convErr)
+	assert.Equal(t, "ttl", convErr.Field)
+	assert.Nil(t, parsedLog)
+
+	parsedLog, err = p.ParseJournalJSON([]byte(`{"MESSAGE":"this is not an iptables log"}`))
+	assert.ErrorIs(t, err, ErrLogFormatUnmatched)
+	assert.Nil(t, parsedLog)
+}
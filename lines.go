@@ -0,0 +1,128 @@
+package iptables
+
+import (
+	"errors"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// ParseLines parses the given iptables lines all at once without aborting on the failure of a line.
+// See Parser.ParseLines for details.
+func ParseLines(lines []string) ([]*Log, []error) {
+	return defaultParser.ParseLines(lines)
+}
+
+// ParseLinesStrict parses the given iptables lines and stops at the first line that fails to parse.
+// See Parser.ParseLinesStrict for details.
+func ParseLinesStrict(lines []string) ([]*Log, error) {
+	return defaultParser.ParseLinesStrict(lines)
+}
+
+// ParseLinesParallel parses the given iptables lines in parallel by the given number of workers; see Parser.ParseLinesParallel for details.
+func ParseLinesParallel(lines []string, workers int) ([]*Log, []error) {
+	return defaultParser.ParseLinesParallel(lines, workers)
+}
+
+// ParseBlock parses the iptables lines separated by newlines in the given string all at once without aborting on the failure of a line.
+// See Parser.ParseBlock for details.
+func ParseBlock(s string) ([]*Log, []error) {
+	return defaultParser.ParseBlock(s)
+}
+
+// ParseLines parses the given iptables lines all at once without aborting on the failure of a line.
+// The returned logs and errors are aligned by the index of the lines: for a line that fails to parse, the log is nil and the error is a *LineError; otherwise the error is nil.
+// When WithCollectAllErrors is enabled, the log of a line whose fields fail to be converted is the partially populated one instead of nil, as well as Parse.
+func (p *Parser) ParseLines(lines []string) ([]*Log, []error) {
+	logs := make([]*Log, len(lines))
+	errs := make([]error, len(lines))
+	for i, line := range lines {
+		parsedLog, err := p.Parse(line)
+		logs[i] = parsedLog
+		if err != nil {
+			errs[i] = &LineError{Line: i + 1, Err: err}
+		}
+	}
+	return logs, errs
+}
+
+// ParseLinesStrict parses the given iptables lines and stops at the first line that fails to parse.
+// The returned error is a *LineError that indicates the failed line.
+// When WithCollectAllErrors is enabled and the fields of the failed line fail to be converted, the logs up to the failed line are returned along with the error, i.e. the last one is the partially populated Log of the failed line; otherwise the logs are nil.
+func (p *Parser) ParseLinesStrict(lines []string) ([]*Log, error) {
+	logs := make([]*Log, len(lines))
+	for i, line := range lines {
+		parsedLog, err := p.Parse(line)
+		if err != nil {
+			if parsedLog == nil {
+				return nil, &LineError{Line: i + 1, Err: err}
+			}
+			logs[i] = parsedLog
+			return logs[:i+1], &LineError{Line: i + 1, Err: err}
+		}
+		logs[i] = parsedLog
+	}
+	return logs, nil
+}
+
+// ParseLinesParallel parses the given iptables lines in parallel by the given number of workers, which is useful for a large batch of lines on a multi-core machine.
+// If workers is less than 1, runtime.GOMAXPROCS(0) is used.
+// The result is the same as ParseLines, i.e. the logs and the errors are aligned by the index of the lines.
+func (p *Parser) ParseLinesParallel(lines []string, workers int) ([]*Log, []error) {
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = min(workers, len(lines))
+	if workers <= 1 {
+		return p.ParseLines(lines)
+	}
+
+	logs := make([]*Log, len(lines))
+	errs := make([]error, len(lines))
+
+	// each worker parses a contiguous shard of the lines and writes the results into its own range, so no synchronization is needed but the wait
+	shardSize := (len(lines) + workers - 1) / workers
+	var wg sync.WaitGroup
+	for start := 0; start < len(lines); start += shardSize {
+		end := min(start+shardSize, len(lines))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := start; i < end; i++ {
+				parsedLog, err := p.Parse(lines[i])
+				logs[i] = parsedLog
+				if err != nil {
+					errs[i] = &LineError{Line: i + 1, Err: err}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	return logs, errs
+}
+
+// ParseBlock parses the iptables lines separated by newlines in the given string all at once without aborting on the failure of a line, e.g. a chunk read from a pipe.
+// The blank lines are skipped, and a trailing "\r" of a line is dropped as well as ParseReader does.
+// The result is the same as ParseLines for the non-blank lines, i.e. the logs and the errors are aligned by the index of the non-blank lines, but the Line of a *LineError is the line number in the given string.
+func (p *Parser) ParseBlock(s string) ([]*Log, []error) {
+	var lines []string
+	var lineNums []int
+	for i, line := range strings.Split(s, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		lines = append(lines, line)
+		lineNums = append(lineNums, i+1)
+	}
+
+	logs, errs := p.ParseLines(lines)
+	for i, err := range errs {
+		var lineErr *LineError
+		if errors.As(err, &lineErr) {
+			lineErr.Line = lineNums[i]
+		}
+	}
+	return logs, errs
+}
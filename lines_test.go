@@ -0,0 +1,179 @@
+package iptables
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLines(t *testing.T) {
+	lines := []string{
+		readerTestTCPLine,
+		"this is not an iptables log",
+		readerTestICMPLine,
+	}
+
+	logs, errs := ParseLines(lines)
+	assert.Len(t, logs, 3)
+	assert.Len(t, errs, 3)
+
+	assert.NoError(t, errs[0])
+	assert.Equal(t, "TCP", logs[0].Protocol)
+
+	assert.Nil(t, logs[1])
+	var lineErr *LineError
+	assert.True(t, errors.As(errs[1], &lineErr))
+	assert.Equal(t, 2, lineErr.Line)
+	assert.True(t, errors.Is(errs[1], ErrLogFormatUnmatched))
+
+	assert.NoError(t, errs[2])
+	assert.Equal(t, "ICMP", logs[2].Protocol)
+}
+
+func TestParseLinesStrict(t *testing.T) {
+	logs, err := ParseLinesStrict([]string{readerTestTCPLine, readerTestICMPLine})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, logs, 2)
+
+	logs, err = ParseLinesStrict([]string{readerTestTCPLine, "this is not an iptables log", readerTestICMPLine})
+	assert.Nil(t, logs)
+	var lineErr *LineError
+	assert.True(t, errors.As(err, &lineErr))
+	assert.Equal(t, 2, lineErr.Line)
+	assert.True(t, errors.Is(err, ErrLogFormatUnmatched))
+}
+
+func TestParseLinesParallel(t *testing.T) {
+	var lines []string
+	for i := 0; i < 100; i++ {
+		switch i % 3 {
+		case 0:
+			lines = append(lines, readerTestTCPLine)
+		case 1:
+			lines = append(lines, "this is not an iptables log")
+		default:
+			lines = append(lines, readerTestICMPLine)
+		}
+	}
+
+	expectedLogs, expectedErrs := ParseLines(lines)
+	for _, workers := range []int{0, 1, 3, 8, 1000} {
+		logs, errs := ParseLinesParallel(lines, workers)
+		assert.Equal(t, expectedLogs, logs, workers)
+		assert.Equal(t, expectedErrs, errs, workers)
+	}
+
+	logs, errs := ParseLinesParallel(nil, 4)
+	assert.Empty(t, logs)
+	assert.Empty(t, errs)
+}
+
+func benchmarkLines() []string {
+	lines := make([]string, 10000)
+	for i := range lines {
+		if i%2 == 0 {
+			lines[i] = readerTestTCPLine
+		} else {
+			lines[i] = readerTestICMPLine
+		}
+	}
+	return lines
+}
+
+func BenchmarkParseLines(b *testing.B) {
+	lines := benchmarkLines()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ParseLines(lines)
+	}
+}
+
+func BenchmarkParseLinesParallel(b *testing.B) {
+	lines := benchmarkLines()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ParseLinesParallel(lines, 0)
+	}
+}
+
+func TestParseBlock(t *testing.T) {
+	block := readerTestTCPLine + "\n\n" + "this is not an iptables log\r\n" + "   \n" + readerTestICMPLine + "\n"
+
+	logs, errs := ParseBlock(block)
+	assert.Len(t, logs, 3)
+	assert.Len(t, errs, 3)
+
+	expected, err := Parse(readerTestTCPLine)
+	assert.NoError(t, err)
+	assert.NoError(t, errs[0])
+	assert.Equal(t, expected, logs[0])
+
+	assert.Nil(t, logs[1])
+	var lineErr *LineError
+	assert.True(t, errors.As(errs[1], &lineErr))
+	// the line number in the block, counting the blank lines
+	assert.Equal(t, 3, lineErr.Line)
+	assert.True(t, errors.Is(errs[1], ErrLogFormatUnmatched))
+
+	assert.NoError(t, errs[2])
+	assert.Equal(t, "ICMP", logs[2].Protocol)
+
+	// the same as ParseLines for the lines without the blank ones
+	linesLogs, linesErrs := ParseLines([]string{readerTestTCPLine, readerTestICMPLine})
+	blockLogs, blockErrs := ParseBlock(readerTestTCPLine + "\r\n" + readerTestICMPLine)
+	assert.Equal(t, linesLogs, blockLogs)
+	assert.Equal(t, linesErrs, blockErrs)
+
+	logs, errs = ParseBlock("\n \n")
+	assert.Empty(t, logs)
+	assert.Empty(t, errs)
+}
+
+func TestParseLines_WithCollectAllErrors(t *testing.T) {
+	p, err := NewParser(WithCollectAllErrors(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	malformedLine := strings.Replace(readerTestTCPLine, "TTL=64", "TTL=6x4", 1)
+	lines := []string{readerTestTCPLine, malformedLine, "this is not an iptables log"}
+
+	for _, parse := range []func([]string) ([]*Log, []error){
+		p.ParseLines,
+		func(lines []string) ([]*Log, []error) { return p.ParseLinesParallel(lines, 3) },
+		func(lines []string) ([]*Log, []error) { return p.ParseBlock(strings.Join(lines, "\n")) },
+	} {
+		logs, errs := parse(lines)
+		assert.NoError(t, errs[0])
+
+		// the partially populated log is kept along with the error
+		var lineErr *LineError
+		assert.ErrorAs(t, errs[1], &lineErr)
+		assert.Equal(t, 2, lineErr.Line)
+		assert.ErrorIs(t, errs[1], ErrStringToNumberConversionFailed)
+		if assert.NotNil(t, logs[1]) {
+			assert.Equal(t, uint16(22), logs[1].DestinationPort)
+		}
+
+		assert.ErrorIs(t, errs[2], ErrLogFormatUnmatched)
+		assert.Nil(t, logs[2])
+	}
+
+	logs, err := p.ParseLinesStrict(lines)
+	assert.ErrorIs(t, err, ErrStringToNumberConversionFailed)
+	if assert.Len(t, logs, 2) {
+		assert.Equal(t, uint64(64), logs[0].TTL)
+		assert.Equal(t, uint16(22), logs[1].DestinationPort)
+	}
+
+	logs, err = p.ParseLinesStrict([]string{readerTestTCPLine, "this is not an iptables log"})
+	assert.ErrorIs(t, err, ErrLogFormatUnmatched)
+	assert.Nil(t, logs)
+}
@@ -0,0 +1,159 @@
+package iptables
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"net"
+	"os"
+	"strings"
+	"sync"
+)
+
+// maxDatagramSize is the maximum size of a datagram that Listen can receive, i.e. the maximum size of a UDP payload.
+const maxDatagramSize = 64 * 1024
+
+// Listen listens to the syslog datagrams on the given network and address; see Parser.Listen for details.
+func Listen(network, address string) (iter.Seq2[*Log, error], func() error, error) {
+	return defaultParser.Listen(network, address)
+}
+
+// Listen listens to the syslog datagrams on the given network and address, and parses the iptables lines of them, e.g. p.Listen("udp", ":514") and p.Listen("unixgram", "/run/iptables.sock").
+// The network must be one of "udp", "udp4", "udp6", and "unixgram".
+// Each datagram is either of RFC 3164 (e.g. "<4>Jul 21 05:38:28 host kernel: [14879.600492] ...") or RFC 5424 (e.g. "<4>1 2022-07-21T05:38:28Z host kernel - - - [14879.600492] ..."); the header of RFC 5424 is rewritten into the one of RFC 3164, and the priority is always parsed into Log.Facility and Log.Severity (i.e. as if WithPriority is enabled).
+// A datagram can have multiple lines, and the blank lines are skipped.
+//
+// The sequence yields a parsed log, or the error of Parse for a line that cannot be parsed; the caller can decide whether to continue by breaking the loop or not.
+// The sequence ends when the returned close function is called, or with the error of receiving a datagram. The close function stops the listener (and removes the socket file of "unixgram"); it is safe to call more than once.
+// The sequence must not be iterated more than once or concurrently.
+func (p *Parser) Listen(network, address string) (iter.Seq2[*Log, error], func() error, error) {
+	switch network {
+	case "udp", "udp4", "udp6", "unixgram":
+	default:
+		return nil, nil, fmt.Errorf("unsupported network %q; only udp, udp4, udp6, and unixgram are supported", network)
+	}
+
+	conn, err := net.ListenPacket(network, address)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// the datagrams always have the priority
+	opts := *p.opts
+	opts.priority = true
+	lp := &Parser{opts: &opts}
+
+	var (
+		closeOnce sync.Once
+		closeErr  error
+	)
+	closeFunc := func() error {
+		closeOnce.Do(func() {
+			closeErr = conn.Close()
+			if network == "unixgram" {
+				if err := os.Remove(address); err != nil && !errors.Is(err, os.ErrNotExist) && closeErr == nil {
+					closeErr = err
+				}
+			}
+		})
+		return closeErr
+	}
+
+	seq := func(yield func(*Log, error) bool) {
+		buf := make([]byte, maxDatagramSize)
+		for {
+			n, _, err := conn.ReadFrom(buf)
+			if err != nil {
+				if !errors.Is(err, net.ErrClosed) {
+					yield(nil, err)
+				}
+				return
+			}
+
+			for _, line := range strings.Split(string(buf[:n]), "\n") {
+				line = strings.TrimRight(line, "\r\x00")
+				if strings.TrimSpace(line) == "" {
+					continue
+				}
+				parsedLog, err := lp.Parse(normalizeSyslogMessage(line))
+				if !yield(parsedLog, err) {
+					return
+				}
+			}
+		}
+	}
+	return seq, closeFunc, nil
+}
+
+// normalizeSyslogMessage rewrites the header of RFC 5424 (i.e. "<PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG") into the one of RFC 3164 (i.e. "<PRI>TIMESTAMP HOSTNAME APP-NAME: MSG"), which Parse understands.
+// The message that is not of RFC 5424 is returned as it is.
+func normalizeSyslogMessage(msg string) string {
+	if !strings.HasPrefix(msg, "<") {
+		return msg
+	}
+	end := strings.IndexByte(msg, '>')
+	if end < 0 {
+		return msg
+	}
+	priority, rest := msg[:end+1], msg[end+1:]
+	rest, ok := strings.CutPrefix(rest, "1 ")
+	if !ok {
+		return msg
+	}
+
+	header := strings.SplitN(rest, " ", 6)
+	if len(header) < 6 {
+		return msg
+	}
+	timestamp, hostname, appName := header[0], header[1], header[2]
+	body, ok := skipStructuredData(header[5])
+	if !ok {
+		return msg
+	}
+	body = strings.TrimPrefix(strings.TrimPrefix(body, " "), "\ufeff")
+
+	var b strings.Builder
+	b.WriteString(priority)
+	b.WriteString(timestamp)
+	b.WriteByte(' ')
+	b.WriteString(hostname)
+	b.WriteByte(' ')
+	if appName != "-" {
+		b.WriteString(appName)
+		b.WriteString(": ")
+	}
+	b.WriteString(body)
+	return b.String()
+}
+
+// skipStructuredData skips the structured data of RFC 5424 (i.e. "-" or the elements like `[id param="value"]`) at the beginning of the given string.
+// The second return value is false if the structured data is malformed.
+func skipStructuredData(s string) (string, bool) {
+	if rest, ok := strings.CutPrefix(s, "-"); ok {
+		return rest, true
+	}
+	if !strings.HasPrefix(s, "[") {
+		return "", false
+	}
+	for strings.HasPrefix(s, "[") {
+		escaped := false
+		end := -1
+		for i := 1; i < len(s); i++ {
+			if escaped {
+				escaped = false
+				continue
+			}
+			if s[i] == '\\' {
+				escaped = true
+			} else if s[i] == ']' {
+				end = i
+				break
+			}
+		}
+		if end < 0 {
+			return "", false
+		}
+		s = s[end+1:]
+	}
+	return s, true
+}
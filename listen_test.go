@@ -0,0 +1,106 @@
+package iptables
+
+import (
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "iptables.sock")
+	seq, closeFunc, err := Listen("unixgram", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := net.Dial("unixgram", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	datagrams := []string{
+		"<4>Jul 21 05:38:28 ubuntu-jammy kernel: [14879.600492] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=84 TOS=0x00 PREC=0x00 TTL=64 ID=6495 DF PROTO=ICMP TYPE=8 CODE=0 ID=1 SEQ=3\n",
+		"<12>1 2022-07-21T05:38:28.123456Z ubuntu-jammy kernel - - [meta sequenceId=\"1\\]\"] \ufeff[14879.600492] IN=enp0s3 OUT= SRC=10.0.2.2 DST=10.0.2.15 LEN=76 TOS=0x00 PREC=0x00 TTL=64 ID=5525 PROTO=TCP SPT=59076 DPT=22 WINDOW=65535 RES=0x00 ACK PSH URGP=0",
+		"malformed",
+	}
+	for _, datagram := range datagrams {
+		if _, err := conn.Write([]byte(datagram)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var (
+		logs []*Log
+		errs []error
+	)
+	for l, err := range seq {
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			logs = append(logs, l)
+		}
+		if len(logs)+len(errs) == len(datagrams) {
+			assert.NoError(t, closeFunc())
+		}
+	}
+
+	assert.Len(t, logs, 2)
+	assert.Len(t, errs, 1)
+	assert.ErrorIs(t, errs[0], ErrLogFormatUnmatched)
+
+	assert.Equal(t, uint8(0), logs[0].Facility)
+	assert.Equal(t, uint8(4), logs[0].Severity)
+	assert.Equal(t, "ICMP", logs[0].Protocol)
+
+	assert.Equal(t, uint8(1), logs[1].Facility)
+	assert.Equal(t, uint8(4), logs[1].Severity)
+	assert.Equal(t, "ubuntu-jammy", logs[1].Hostname)
+	assert.Equal(t, time.Date(2022, time.July, 21, 5, 38, 28, 123456000, time.UTC), logs[1].TimestampParsed.UTC())
+	assert.Equal(t, 14879.600492, logs[1].KernelTimestamp)
+	assert.Equal(t, uint16(22), logs[1].DestinationPort)
+
+	// the socket file is removed, and closing again is a no-op
+	assert.NoFileExists(t, path)
+	assert.NoError(t, closeFunc())
+}
+
+func TestListen_UnsupportedNetwork(t *testing.T) {
+	_, _, err := Listen("tcp", "127.0.0.1:0")
+	assert.Error(t, err)
+}
+
+func TestNormalizeSyslogMessage(t *testing.T) {
+	type TestCase struct {
+		msg      string
+		expected string
+	}
+
+	testCases := []*TestCase{
+		{
+			msg:      "<4>Jul 21 05:38:28 host kernel: [1.0] IN=eth0",
+			expected: "<4>Jul 21 05:38:28 host kernel: [1.0] IN=eth0",
+		},
+		{
+			msg:      "<4>1 2022-07-21T05:38:28Z host kernel - - - [1.0] IN=eth0",
+			expected: "<4>2022-07-21T05:38:28Z host kernel: [1.0] IN=eth0",
+		},
+		{
+			msg:      "<4>1 2022-07-21T05:38:28Z host - - - [a b=\"c\"][d] [1.0] IN=eth0",
+			expected: "<4>2022-07-21T05:38:28Z host [1.0] IN=eth0",
+		},
+		{
+			// the unterminated structured data
+			msg:      "<4>1 2022-07-21T05:38:28Z host kernel - - [a",
+			expected: "<4>1 2022-07-21T05:38:28Z host kernel - - [a",
+		},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.expected, normalizeSyslogMessage(testCase.msg), testCase.msg)
+	}
+}
@@ -0,0 +1,89 @@
+package iptables
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// ErrMACAddressMalformed is an error that occurs when it cannot interpret the MAC field.
+var ErrMACAddressMalformed = errors.New("malformed MAC field")
+
+const (
+	hardwareAddrLen   = 6
+	ethernetHeaderLen = hardwareAddrLen*2 + 2
+	// vlanTagLen is the length of the 802.1Q tag, i.e. the TPID and the TCI.
+	vlanTagLen = 4
+	// etherTypeVLAN is the TPID of the 802.1Q tag, which is placed where the EtherType would be.
+	etherTypeVLAN = 0x8100
+)
+
+// MACInfo represents the structured content of the MAC field, i.e. the link layer header of the packet.
+type MACInfo struct {
+	DestMAC net.HardwareAddr `json:"destMac"`
+	SrcMAC  net.HardwareAddr `json:"srcMac"`
+	// EtherType is the EtherType of the payload, i.e. the inner one that follows the 802.1Q tag for a VLAN tagged frame.
+	EtherType uint16 `json:"etherType"`
+	// VLANID is the VLAN identifier of the 802.1Q tag.
+	VLANID uint16 `json:"vlanId"`
+	// VLANPriority is the priority code point of the 802.1Q tag.
+	VLANPriority uint8 `json:"vlanPriority"`
+	// HasVLAN indicates whether the frame has the 802.1Q tag, because zero is also a valid VLAN ID (i.e. the priority tagged frame).
+	HasVLAN bool `json:"hasVlan"`
+}
+
+// MAC parses the MAC field (e.g. "00:b3:dd:bc:29:e1:52:54:00:12:35:02:08:00") into the destination MAC address, the source MAC address, and the EtherType.
+// This returns zero MACInfo without an error if the MAC field is empty.
+// For the VLAN tagged frame, the 802.1Q tag (i.e. the TPID 0x8100 and the TCI) between the source MAC address and the EtherType is decoded into the VLAN ID and the priority.
+// This returns ErrMACAddressMalformed if the field is neither the 14-byte Ethernet header nor the 18-byte VLAN tagged one, e.g. the tunnel interfaces that log fewer bytes.
+func (l *Log) MAC() (MACInfo, error) {
+	if l.MACAddress == "" {
+		return MACInfo{}, nil
+	}
+
+	b, err := parseMACField(l.MACAddress)
+	if err != nil {
+		return MACInfo{}, err
+	}
+	if len(b) != ethernetHeaderLen && len(b) != ethernetHeaderLen+vlanTagLen {
+		return MACInfo{}, fmt.Errorf("the field has %d bytes, expected %d or %d bytes: %w", len(b), ethernetHeaderLen, ethernetHeaderLen+vlanTagLen, ErrMACAddressMalformed)
+	}
+
+	macInfo := MACInfo{
+		DestMAC:   net.HardwareAddr(b[0:hardwareAddrLen]),
+		SrcMAC:    net.HardwareAddr(b[hardwareAddrLen : hardwareAddrLen*2]),
+		EtherType: binary.BigEndian.Uint16(b[hardwareAddrLen*2:]),
+	}
+	if len(b) == ethernetHeaderLen {
+		return macInfo, nil
+	}
+
+	if macInfo.EtherType != etherTypeVLAN {
+		return MACInfo{}, fmt.Errorf("the field has %d bytes without the 802.1Q tag: %w", len(b), ErrMACAddressMalformed)
+	}
+	tci := binary.BigEndian.Uint16(b[hardwareAddrLen*2+2:])
+	macInfo.VLANID = tci & 0x0fff
+	macInfo.VLANPriority = uint8(tci >> 13)
+	macInfo.HasVLAN = true
+	macInfo.EtherType = binary.BigEndian.Uint16(b[hardwareAddrLen*2+vlanTagLen:])
+	return macInfo, nil
+}
+
+func parseMACField(field string) ([]byte, error) {
+	octets := strings.Split(field, ":")
+	b := make([]byte, 0, len(octets))
+	for _, octet := range octets {
+		if len(octet) != 2 {
+			return nil, fmt.Errorf("invalid octet %q: %w", octet, ErrMACAddressMalformed)
+		}
+		v, err := strconv.ParseUint(octet, 16, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid octet %q: %w", octet, ErrMACAddressMalformed)
+		}
+		b = append(b, byte(v))
+	}
+	return b, nil
+}
@@ -0,0 +1,91 @@
+package iptables
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLog_MAC(t *testing.T) {
+	type TestCase struct {
+		mac      string
+		expected MACInfo
+	}
+
+	testCases := []*TestCase{
+		{
+			mac: "00:b3:dd:bc:29:e1:52:54:00:12:35:02:08:00",
+			expected: MACInfo{
+				DestMAC:   net.HardwareAddr{0x00, 0xb3, 0xdd, 0xbc, 0x29, 0xe1},
+				SrcMAC:    net.HardwareAddr{0x52, 0x54, 0x00, 0x12, 0x35, 0x02},
+				EtherType: 0x0800,
+			},
+		},
+		{
+			mac: "00:B3:DD:BC:29:E1:52:54:00:12:35:02:86:DD",
+			expected: MACInfo{
+				DestMAC:   net.HardwareAddr{0x00, 0xb3, 0xdd, 0xbc, 0x29, 0xe1},
+				SrcMAC:    net.HardwareAddr{0x52, 0x54, 0x00, 0x12, 0x35, 0x02},
+				EtherType: 0x86dd,
+			},
+		},
+		{
+			// VLAN tagged frame of VLAN ID 100 and priority 5
+			mac: "00:b3:dd:bc:29:e1:52:54:00:12:35:02:81:00:a0:64:08:00",
+			expected: MACInfo{
+				DestMAC:      net.HardwareAddr{0x00, 0xb3, 0xdd, 0xbc, 0x29, 0xe1},
+				SrcMAC:       net.HardwareAddr{0x52, 0x54, 0x00, 0x12, 0x35, 0x02},
+				EtherType:    0x0800,
+				VLANID:       100,
+				VLANPriority: 5,
+				HasVLAN:      true,
+			},
+		},
+		{
+			mac:      "",
+			expected: MACInfo{},
+		},
+	}
+
+	for _, testCase := range testCases {
+		l := &Log{MACAddress: testCase.mac}
+		macInfo, err := l.MAC()
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, testCase.expected, macInfo)
+	}
+}
+
+func TestLog_MAC_Malformed(t *testing.T) {
+	macs := []string{
+		"00:b3:dd:bc:29:e1:52:54",                               // short (e.g. tunnel interface)
+		"00:b3:dd:bc:29:e1:52:54:00:12:35:02:08:0x",             // not a hex
+		"00:b3:dd:bc:29:e1:52:54:00:12:35:02:0800",              // broken separator
+		"00:b3:dd:bc:29:e1:52:54:00:12:35:02:08:",               // trailing separator
+		"00:b3:dd:bc:29:e1:52:54:00:12:35:02:08:00:a0:64:08:00", // 18 bytes without the 802.1Q tag
+	}
+
+	for _, mac := range macs {
+		l := &Log{MACAddress: mac}
+		_, err := l.MAC()
+		assert.True(t, errors.Is(err, ErrMACAddressMalformed), mac)
+	}
+}
+
+func TestParse_VLAN(t *testing.T) {
+	parsedLog, err := Parse("Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] IN=enp0s3.100 OUT= MAC=00:b3:dd:bc:29:e1:52:54:00:12:35:02:81:00:00:64:86:dd SRC=2001:db8::2 DST=2001:db8::1 LEN=72 TC=0 HOPLIMIT=64 FLOWLBL=0 PROTO=UDP SPT=40000 DPT=53 LEN=32")
+	if err != nil {
+		t.Fatal(err)
+	}
+	macInfo, err := parsedLog.MAC()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, macInfo.HasVLAN)
+	assert.Equal(t, uint16(100), macInfo.VLANID)
+	assert.Equal(t, uint8(0), macInfo.VLANPriority)
+	assert.Equal(t, uint16(0x86dd), macInfo.EtherType)
+}
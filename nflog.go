@@ -0,0 +1,34 @@
+package iptables
+
+import (
+	"regexp"
+)
+
+// nflogPreambleRe matches the syslog preamble of the lines that ulogd emits for the NFLOG target, which has no kernel timestamp.
+// The tag is either the one of ulogd (e.g. "ulogd[1234]:" by the SYSLOG plugin) or absent (by the LOGEMU plugin); the other tags are not accepted, since they cannot be told from the prefix.
+// The kernel timestamp is usually absent, but it is accepted when the line is relayed with the one (e.g. by a kernel-tagged syslog template).
+// Since the kernel timestamp doesn't delimit the preamble, the timestamp must be either the classic syslog one or the RFC 5424 one (see timestampPattern).
+var nflogPreambleRe = func() *preambleRegexp {
+	re := regexp.MustCompile(`^(?P<timestamp>` + timestampPattern + `)\s+(?P<hostname>\S*[^\s:])\s+(?:ulogd(?:\[\d+\])?:\s*)?(?:\[\s*(?P<kernelTimestamp>\d+\.\d+)\]\s+)?`)
+	return &preambleRegexp{
+		re:                 re,
+		timestampIdx:       re.SubexpIndex("timestamp"),
+		hostnameIdx:        re.SubexpIndex("hostname"),
+		kernelTimestampIdx: re.SubexpIndex("kernelTimestamp"),
+		facilityMarkerIdx:  -1,
+	}
+}()
+
+// ParseNFLOG parses an iptables line that is logged by the NFLOG target via ulogd; see Parser.ParseNFLOG for details.
+func ParseNFLOG(line string) (*Log, error) {
+	return defaultParser.ParseNFLOG(line)
+}
+
+// ParseNFLOG parses an iptables line that is logged by the NFLOG target via ulogd (e.g. by the LOGEMU or SYSLOG plugin), e.g. "Jul 21 05:31:48 host ulogd[1234]: DROP IN=eth0 OUT= ...".
+// Unlike the LOG target, the line usually has no kernel timestamp, so Log.KernelTimestamp is left zero and absent (see Log.Has) unless the line has the one.
+// The prefix is the one given by --nflog-prefix; the NFLOG group (i.e. --nflog-group) is not logged by ulogd, so it is not available.
+// The options of the Parser are respected except WithTag and WithRegexp, which are for the LOG target.
+func (p *Parser) ParseNFLOG(line string) (*Log, error) {
+	l := &Log{}
+	return p.result(l, p.parse(line, l, nflogPreambleRe))
+}
@@ -0,0 +1,84 @@
+package iptables
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseNFLOG(t *testing.T) {
+	type TestCase struct {
+		name             string
+		line             string
+		expectedHostname string
+		expectedPrefix   string
+		expectedKernelTS float64
+	}
+
+	testCases := []*TestCase{
+		{
+			name:             "SYSLOG plugin",
+			line:             "Jul 21 05:31:48 ubuntu-jammy ulogd[1234]: NFLOG-DROP IN=enp0s3 OUT= MAC=00:b3:dd:bc:29:e1:52:54:00:12:35:02:08:00 SRC=10.0.2.2 DST=10.0.2.15 LEN=60 TOS=00 PREC=0x00 TTL=64 ID=1234 DF PROTO=TCP SPT=54832 DPT=22 SEQ=567002889 ACK=0 WINDOW=64240 SYN URGP=0 MARK=0x0",
+			expectedHostname: "ubuntu-jammy",
+			expectedPrefix:   "NFLOG-DROP",
+		},
+		{
+			name:             "LOGEMU plugin",
+			line:             "Jul 21 05:31:48 ubuntu-jammy NFLOG-DROP: IN=enp0s3 OUT= MAC=00:b3:dd:bc:29:e1:52:54:00:12:35:02:08:00 SRC=10.0.2.2 DST=10.0.2.15 LEN=60 TOS=00 PREC=0x00 TTL=64 ID=1234 DF PROTO=TCP SPT=54832 DPT=22 SEQ=567002889 ACK=0 WINDOW=64240 SYN URGP=0 MARK=0x0",
+			expectedHostname: "ubuntu-jammy",
+			expectedPrefix:   "NFLOG-DROP:",
+		},
+		{
+			name:             "without prefix",
+			line:             "2022-07-21T05:31:48.123456+00:00 ubuntu-jammy ulogd: IN=enp0s3 OUT= SRC=10.0.2.2 DST=10.0.2.15 LEN=60 TOS=00 PREC=0x00 TTL=64 ID=1234 DF PROTO=TCP SPT=54832 DPT=22 SEQ=567002889 ACK=0 WINDOW=64240 SYN URGP=0",
+			expectedHostname: "ubuntu-jammy",
+			expectedPrefix:   "",
+		},
+		{
+			name:             "with kernel timestamp",
+			line:             "Jul 21 05:31:48 ubuntu-jammy ulogd[1234]: [14879.600492] NFLOG-DROP IN=enp0s3 OUT= SRC=10.0.2.2 DST=10.0.2.15 LEN=60 TOS=00 PREC=0x00 TTL=64 ID=1234 DF PROTO=TCP SPT=54832 DPT=22 SEQ=567002889 ACK=0 WINDOW=64240 SYN URGP=0",
+			expectedHostname: "ubuntu-jammy",
+			expectedPrefix:   "NFLOG-DROP",
+			expectedKernelTS: 14879.600492,
+		},
+	}
+
+	for _, testCase := range testCases {
+		parsedLog, err := ParseNFLOG(testCase.line)
+		if err != nil {
+			t.Fatalf("%s: %v", testCase.name, err)
+		}
+		assert.Equal(t, testCase.expectedHostname, parsedLog.Hostname, testCase.name)
+		assert.Equal(t, testCase.expectedPrefix, parsedLog.Prefix, testCase.name)
+		assert.False(t, parsedLog.TimestampParsed.IsZero(), testCase.name)
+		assert.Equal(t, testCase.expectedKernelTS, parsedLog.KernelTimestamp, testCase.name)
+		assert.Equal(t, testCase.expectedKernelTS != 0, parsedLog.Has(FieldKernelTimestamp), testCase.name)
+		assert.Equal(t, "10.0.2.2", parsedLog.Source, testCase.name)
+		assert.Equal(t, uint16(22), parsedLog.DestinationPort, testCase.name)
+		assert.True(t, parsedLog.Syn, testCase.name)
+	}
+}
+
+func TestParseNFLOG_Unmatched(t *testing.T) {
+	_, err := ParseNFLOG("yesterday ubuntu-jammy ulogd: IN=enp0s3 OUT= SRC=10.0.2.2 DST=10.0.2.15 LEN=60 TTL=64 PROTO=UDP")
+	assert.ErrorIs(t, err, ErrLogFormatUnmatched)
+}
+
+func TestParseNFLOG_WithCollectAllErrors(t *testing.T) {
+	p, err := NewParser(WithCollectAllErrors(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsedLog, err := p.ParseNFLOG("Jul 21 05:31:48 ubuntu-jammy ulogd[1234]: NFLOG-DROP IN=enp0s3 OUT= SRC=10.0.2.2 DST=10.0.2.15 LEN=60 TOS=00 PREC=0x00 TTL=6A ID=1234 DF PROTO=TCP SPT=54832 DPT=2x SYN URGP=0")
+	assert.ErrorIs(t, err, ErrStringToNumberConversionFailed)
+	if assert.NotNil(t, parsedLog) {
+		assert.Equal(t, "NFLOG-DROP", parsedLog.Prefix)
+		assert.Equal(t, uint16(54832), parsedLog.SourcePort)
+		assert.True(t, parsedLog.Syn)
+	}
+
+	// the line is not an NFLOG one
+	parsedLog, err = p.ParseNFLOG("this is not an iptables log")
+	assert.ErrorIs(t, err, ErrLogFormatUnmatched)
+	assert.Nil(t, parsedLog)
+}
@@ -0,0 +1,216 @@
+package iptables
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Option configures the behavior of the Parser.
+type Option func(o *options) error
+
+type options struct {
+	year               int
+	location           *time.Location
+	strict             bool
+	extraFields        bool
+	actionPatterns     []ActionPattern
+	preambleRe         *preambleRegexp
+	priority           bool
+	retainRaw          bool
+	canonicalAddresses bool
+	maxLineLength      int
+	collectAllErrors   bool
+}
+
+func defaultOptions() *options {
+	return &options{
+		location:       time.Local,
+		extraFields:    true,
+		actionPatterns: DefaultActionPatterns,
+		preambleRe:     preambleRe,
+		maxLineLength:  DefaultMaxLineLength,
+	}
+}
+
+func newOptions(opts []Option) (*options, error) {
+	o := defaultOptions()
+	for _, opt := range opts {
+		if err := opt(o); err != nil {
+			return nil, err
+		}
+	}
+	return o, nil
+}
+
+// WithYear specifies the year to complement the syslog timestamp with, because the classic syslog timestamp (e.g. "Oct 10 13:55:36") doesn't contain the year.
+// If this option is not given, the current year in the location is used, or the previous one if the timestamp is more than a day ahead of the current time (e.g. "Dec 31" parsed on Jan 1).
+// "Feb 29" in a non-leap year cannot be interpreted (see WithStrict). The RFC 5424 timestamp is not affected by this option, since it contains the year.
+func WithYear(year int) Option {
+	return func(o *options) error {
+		o.year = year
+		return nil
+	}
+}
+
+// DefaultMaxLineLength is the default maximum length of a line that the Parser accepts; see WithMaxLineLength.
+// This is generous enough for the legitimate lines, which are a few hundred bytes long.
+const DefaultMaxLineLength = 64 * 1024
+
+// WithMaxLineLength specifies the maximum length of a line in bytes; the longer line is rejected with ErrLineTooLong before it is matched by the regexp.
+// This is a safety valve for the untrusted log sources, e.g. the crafted megabyte-long lines. Zero disables the limit. The default is DefaultMaxLineLength.
+func WithMaxLineLength(n int) Option {
+	return func(o *options) error {
+		if n < 0 {
+			return fmt.Errorf("max line length must not be negative, but got %d", n)
+		}
+		o.maxLineLength = n
+		return nil
+	}
+}
+
+// WithCollectAllErrors specifies whether to attempt all the fields and return all the failures of them at once, instead of failing on the first one.
+// When this is enabled, the failures (i.e. *FieldConversionError, ErrTimestampParseFailed, and the missing mandatory fields) are returned by errors.Join, and Parse returns the partially populated Log along with the error if any field fails to be converted; so do the other entry points that parse a Log, e.g. ParseNFLOG, ParseUFW, ParseJournalJSON, PooledParser.Parse, ParseReader, and ParseLines.
+// This is useful for the validation tooling that reports all the problems of the malformed lines. This is disabled by default, i.e. the parsing fails fast.
+// A line whose syslog preamble is not found fails as usual, since there are no fields to attempt.
+func WithCollectAllErrors(collectAllErrors bool) Option {
+	return func(o *options) error {
+		o.collectAllErrors = collectAllErrors
+		return nil
+	}
+}
+
+// WithLocation specifies the location (i.e. timezone) to interpret the syslog timestamp in.
+// If this option is not given, time.Local is used. The RFC 5424 timestamp is not affected by this option, since it contains the offset.
+func WithLocation(loc *time.Location) Option {
+	return func(o *options) error {
+		if loc == nil {
+			return errors.New("location must not be nil")
+		}
+		o.location = loc
+		return nil
+	}
+}
+
+// WithStrict makes the parsing fail with ErrTimestampParseFailed when the syslog timestamp cannot be interpreted.
+// By default, such a timestamp leaves Log.TimestampParsed zero and the parsing succeeds.
+func WithStrict(strict bool) Option {
+	return func(o *options) error {
+		o.strict = strict
+		return nil
+	}
+}
+
+// WithExtraFields specifies whether to collect the unknown KEY=VALUE fields into Log.Extra.
+// This is enabled by default; disabling it saves the cost of scanning the fields.
+func WithExtraFields(extraFields bool) Option {
+	return func(o *options) error {
+		o.extraFields = extraFields
+		return nil
+	}
+}
+
+// WithActionPatterns specifies the patterns to extract the action from the prefix by Parser.Action, instead of DefaultActionPatterns.
+// The patterns are tried in order, and the action of the first matched pattern is used.
+func WithActionPatterns(patterns ...ActionPattern) Option {
+	return func(o *options) error {
+		for _, pattern := range patterns {
+			if pattern.Pattern == nil {
+				return errors.New("pattern of the action pattern must not be nil")
+			}
+		}
+		o.actionPatterns = patterns
+		return nil
+	}
+}
+
+// WithPriority specifies whether to parse the leading syslog priority (e.g. "<4>") into Log.Facility and Log.Severity.
+// This is disabled by default, since the syslog daemons usually strip the priority; enable it to parse the raw messages (e.g. the ones read from a socket).
+// The lines without the priority are parsed as well even if this is enabled.
+func WithPriority(priority bool) Option {
+	return func(o *options) error {
+		o.priority = priority
+		return nil
+	}
+}
+
+// WithTag specifies the syslog tag that precedes the kernel timestamp, without the trailing colon (e.g. "kernel" for "kernel: [...]").
+// The lines that have the other tag are regarded as unmatched. An empty tag means the lines have no tag at all.
+// If this option is not given, any tag (e.g. "kernel:" or "iptables[123]:") is accepted, and so is the absence of the tag.
+func WithTag(tag string) Option {
+	return func(o *options) error {
+		if strings.IndexFunc(tag, unicode.IsSpace) >= 0 {
+			return errors.New("tag must not contain any space")
+		}
+		if tag == "" {
+			o.preambleRe = newPreambleRe("")
+			return nil
+		}
+		o.preambleRe = newPreambleRe(regexp.QuoteMeta(tag) + `:\s*`)
+		return nil
+	}
+}
+
+// WithRetainRaw specifies whether to retain the original line in Log.Raw, e.g. to re-emit or audit the source of the parsed log.
+// This is disabled by default to save the memory of holding the lines.
+func WithRetainRaw(retainRaw bool) Option {
+	return func(o *options) error {
+		o.retainRaw = retainRaw
+		return nil
+	}
+}
+
+// WithCanonicalAddresses specifies whether to rewrite the source and destination addresses (i.e. Log.Source and Log.Destination) into the canonical form on parsing; see Log.CanonicalSource.
+// This is disabled by default, i.e. the addresses are kept as they are logged.
+func WithCanonicalAddresses(canonicalAddresses bool) Option {
+	return func(o *options) error {
+		o.canonicalAddresses = canonicalAddresses
+		return nil
+	}
+}
+
+// WithRegexp specifies the regexp of the syslog preamble (i.e. the part before the prefix) instead of the default one, for the environments that have the different format of the preamble.
+// fieldMap maps the names of the named groups of the regexp to the fields that they capture; FieldTimestamp and FieldKernelTimestamp must be mapped, and FieldHostname can be mapped optionally.
+// The rest of the line after the match is parsed as the prefix and the fields as usual.
+// This returns an error on NewParser if a named group doesn't exist in the regexp, or a field is unsupported, duplicated, or missing.
+// This option and WithTag override each other, i.e. the last one takes effect.
+func WithRegexp(re *regexp.Regexp, fieldMap map[string]Field) Option {
+	return func(o *options) error {
+		if re == nil {
+			return errors.New("regexp must not be nil")
+		}
+		preamble := &preambleRegexp{re: re, timestampIdx: -1, hostnameIdx: -1, kernelTimestampIdx: -1, facilityMarkerIdx: -1}
+		for name, f := range fieldMap {
+			idx := re.SubexpIndex(name)
+			if idx < 0 {
+				return fmt.Errorf("named group %q is not found in the regexp", name)
+			}
+			var dst *int
+			switch f {
+			case FieldTimestamp:
+				dst = &preamble.timestampIdx
+			case FieldHostname:
+				dst = &preamble.hostnameIdx
+			case FieldKernelTimestamp:
+				dst = &preamble.kernelTimestampIdx
+			default:
+				return fmt.Errorf("field %q cannot be captured by the regexp; only timestamp, hostname, and kernelTimestamp are supported", f)
+			}
+			if *dst >= 0 {
+				return fmt.Errorf("field %q is mapped more than once", f)
+			}
+			*dst = idx
+		}
+		if preamble.timestampIdx < 0 {
+			return errors.New("named group of the timestamp is required")
+		}
+		if preamble.kernelTimestampIdx < 0 {
+			return errors.New("named group of the kernel timestamp is required")
+		}
+		o.preambleRe = preamble
+		return nil
+	}
+}
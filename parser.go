@@ -5,181 +5,478 @@ import (
 	"fmt"
 	"regexp"
 	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unsafe"
 )
 
 // Log represents the parsed iptables log entry.
+// It represents both iptables (IPv4) and ip6tables (IPv6) log entries; for IPv6, TTL holds the value of HOPLIMIT.
 type Log struct {
-	Timestamp              string  `json:"timestamp"`
-	Hostname               string  `json:"hostname"`
-	KernelTimestamp        float64 `json:"kernelTimestamp"`
-	Prefix                 string  `json:"prefix"`
-	InputInterface         string  `json:"inputInterface"`
-	OutputInterface        string  `json:"outputInterface"`
-	MACAddress             string  `json:"macAddress"`
-	Source                 string  `json:"source"`
-	Destination            string  `json:"destination"`
-	Length                 uint64  `json:"length"`
-	ToS                    uint8   `json:"tos"`
-	Precedence             uint8   `json:"precedence"`
-	TTL                    uint64  `json:"ttl"`
-	ID                     uint64  `json:"id"`
-	CongestionExperienced  bool    `json:"congestionExperienced"`
-	DoNotFragment          bool    `json:"doNotFragment"`
-	MoreFragmentsFollowing bool    `json:"moreFragmentsFollowing"`
-	Frag                   int64   `json:"frag"`
-	IPOptions              string  `json:"ipOptions"`
-	Protocol               string  `json:"protocol"`
-	Type                   int64   `json:"type"`
-	Code                   int64   `json:"code"`
-	SourcePort             uint16  `json:"sourcePort"`
-	DestinationPort        uint16  `json:"destinationPort"`
-	Sequence               uint64  `json:"sequence"`
-	AckSequence            uint64  `json:"ackSequence"`
-	WindowSize             uint64  `json:"windowSize"`
-	Res                    uint64  `json:"res"`
-	Urgent                 bool    `json:"urgent"`
-	Ack                    bool    `json:"ack"`
-	Push                   bool    `json:"push"`
-	Reset                  bool    `json:"reset"`
-	Syn                    bool    `json:"syn"`
-	Fin                    bool    `json:"fin"`
-	Urgp                   uint64  `json:"urgp"`
-	TCPOption              string  `json:"tcpOption"`
-}
-
-var re = regexp.MustCompile(`^(?P<timestamp>.+)\s+(?P<hostname>\S+)\s+kernel:\s+\[\s*(?P<kernel_timestamp>[^]]+)]\s+(?:(?P<prefix>.+)\s+)?IN=(\S*)\s+OUT=(\S*)\s+(?:MAC=(\S*)\s+)?SRC=(\S*)\s+DST=(\S*)\s+LEN=(\d*)\s+TOS=(?:0x(\S+))?\s+PREC=(?:0x(\S+))?\s+TTL=(\d*)\s+ID=(\d*)\s+(CE\s+)?(DF\s+)?(MF\s+)?(?:FRAG=(\d*)\s+)?(?:OPT \((.+)\)\s+)?PROTO=(\S+)(?:\s+TYPE=(\d+))?(?:\s+CODE=(\d+))?(?:\s+SPT=(\d*))?(?:\s+DPT=(\d*))?(?:\s+SEQ=(\d*))?(?:\s+ACK=(\d*))?(?:\s+WINDOW=(\d*))?(?:\s+RES=0x(\S*))?(\s+URG)?(\s+ACK)?(\s+PSH)?(\s+RST)?(\s+SYN)?(\s+FIN)?(?:\s+URGP=(\d*))?(?:\s+OPT \((.*)\))?`)
+	// Facility is the facility of the syslog priority (e.g. 16 of "<134>"), which is parsed only when WithPriority is enabled.
+	// This is parsed from the facility marker (e.g. 0 of "kern.warn") as well, which some syslog daemons (e.g. BusyBox syslogd) write before the tag.
+	Facility uint8 `json:"facility"`
+	// Severity is the severity of the syslog priority (e.g. 6 of "<134>"), which is parsed only when WithPriority is enabled.
+	// This is parsed from the facility marker (e.g. 4 of "kern.warn") as well.
+	Severity uint8 `json:"severity"`
+	// HasPriority indicates whether the syslog priority (i.e. "<PRI>") or the facility marker is present, because zero is also a valid facility and severity.
+	HasPriority     bool      `json:"hasPriority"`
+	Timestamp       string    `json:"timestamp"`
+	TimestampParsed time.Time `json:"timestampParsed"`
+	Hostname        string    `json:"hostname"`
+	// KernelTimestamp is the seconds since the boot (e.g. 14879.600492 of "[14879.600492]"); it is zero and Has(FieldKernelTimestamp) is false if the kernel doesn't print the timestamp.
+	KernelTimestamp float64 `json:"kernelTimestamp"`
+	// Prefix is the log prefix (i.e. --log-prefix) without the trailing whitespaces.
+	// Has(FieldPrefix) tells whether the prefix is logged: it is false if the rule has no prefix, and true with the empty Prefix if the prefix consists of the whitespaces only (e.g. --log-prefix " "), which is told by the extra whitespaces before the fields.
+	Prefix                 string `json:"prefix"`
+	InputInterface         string `json:"inputInterface"`
+	OutputInterface        string `json:"outputInterface"`
+	PhysInputInterface     string `json:"physInputInterface"`
+	PhysOutputInterface    string `json:"physOutputInterface"`
+	MACAddress             string `json:"macAddress"`
+	Source                 string `json:"source"`
+	Destination            string `json:"destination"`
+	Length                 uint64 `json:"length"`
+	ToS                    uint8  `json:"tos"`
+	Precedence             uint8  `json:"precedence"`
+	TTL                    uint64 `json:"ttl"`
+	ID                     uint64 `json:"id"`
+	CongestionExperienced  bool   `json:"congestionExperienced"`
+	DoNotFragment          bool   `json:"doNotFragment"`
+	MoreFragmentsFollowing bool   `json:"moreFragmentsFollowing"`
+	Frag                   int64  `json:"frag"`
+	IPOptions              string `json:"ipOptions"`
+	IsIPv6                 bool   `json:"isIPv6"`
+	// IPVersion is the version of IP (i.e. 4 or 6), which is detected by the format of Source and Destination; it is zero if the version is indeterminate, e.g. the addresses are malformed or of the different versions.
+	// An IPv4-mapped IPv6 address (e.g. "::ffff:192.0.2.1") is regarded as IPv6, since it is carried by the IPv6 header, unless the other address is the plain IPv4 one; then the pair is regarded as IPv4 as well as Validate does.
+	// The version is detected by the addresses as they are logged, even if WithCanonicalAddresses unmaps them.
+	IPVersion     uint8  `json:"ipVersion"`
+	TrafficClass  uint8  `json:"trafficClass"`
+	FlowLabel     uint32 `json:"flowLabel"`
+	Protocol      string `json:"protocol"`
+	Type          int64  `json:"type"`
+	Code          int64  `json:"code"`
+	ICMPID        uint16 `json:"icmpId"`
+	ICMPSeq       uint16 `json:"icmpSeq"`
+	MTU           uint16 `json:"mtu"`
+	SPI           uint32 `json:"spi"`
+	IPsecSequence uint32 `json:"ipsecSequence"`
+	// GREKey is the key of the GRE header (i.e. KEY), e.g. the call ID of PPTP, which is logged only for GRE.
+	GREKey uint32 `json:"greKey"`
+	// GREProtocol is the protocol type of the GRE header (i.e. the EtherType of the encapsulated packet, e.g. 0x880b of PPP), which is logged as the PROTO following PROTO=GRE.
+	GREProtocol     uint16 `json:"greProtocol"`
+	SourcePort      uint16 `json:"sourcePort"`
+	DestinationPort uint16 `json:"destinationPort"`
+	// ChecksumCoverage is the checksum coverage of UDP-Lite (i.e. LEN after PROTO=UDPLITE), which is carried in the length field of the UDP header; 0 means the whole datagram.
+	ChecksumCoverage uint16 `json:"checksumCoverage"`
+	Sequence         uint64 `json:"sequence"`
+	AckSequence      uint64 `json:"ackSequence"`
+	WindowSize       uint64 `json:"windowSize"`
+	Res              uint64 `json:"res"`
+	Urgent           bool   `json:"urgent"`
+	Ack              bool   `json:"ack"`
+	Push             bool   `json:"push"`
+	Reset            bool   `json:"reset"`
+	Syn              bool   `json:"syn"`
+	Fin              bool   `json:"fin"`
+	ECE              bool   `json:"ece"`
+	CWR              bool   `json:"cwr"`
+	NS               bool   `json:"ns"`
+	Urgp             uint64 `json:"urgp"`
+	TCPOption        string `json:"tcpOption"`
+	// Incomplete indicates whether the kernel could not read the whole header of the packet, i.e. "INCOMPLETE [N bytes]" is logged instead of the fields of the header.
+	// The fields that follow the marker in the header are left zero.
+	Incomplete bool `json:"incomplete"`
+	// IncompleteBytes is the number of the bytes that the kernel could read (i.e. N of "INCOMPLETE [N bytes]").
+	IncompleteBytes uint64 `json:"incompleteBytes"`
+	// UID is the user ID of the packet owner, which is logged with the owner match; -1 when it is absent.
+	UID int64 `json:"uid"`
+	// GID is the group ID of the packet owner, which is logged with the owner match; -1 when it is absent.
+	GID int64 `json:"gid"`
+	// Mark is the packet mark (i.e. MARK), which is logged when the packet has the non-zero mark.
+	Mark uint32 `json:"mark"`
+	// HasMark indicates whether the MARK field is present, because zero is also a valid mark.
+	HasMark bool `json:"hasMark"`
+	// Extra holds the KEY=VALUE fields that are not mapped to the typed fields above (e.g. the fields emitted by custom matches or kernel modules), keyed by the KEY.
+	// The values are left as raw strings. This is nil when there is no such field.
+	Extra map[string]string `json:"extra,omitempty"`
+	// Inner is the original packet that is embedded in an ICMP error message (e.g. "[SRC=... DST=... PROTO=... ]"). This is nil when there is no such packet.
+	// Only the packet fields are populated, i.e. the syslog preamble and the prefix are empty.
+	Inner *Log `json:"inner,omitempty"`
+	// Raw is the original line, which is retained only when WithRetainRaw is enabled; this is empty otherwise.
+	// This is excluded from JSON, since it duplicates the parsed fields.
+	Raw string `json:"-"`
+
+	// present is the set of the fields that are present in the parsed line; see Has.
+	present FieldSet
+}
+
+// preambleRe matches the syslog preamble of the line, which is followed by the iptables specific part, i.e. the prefix and the fields.
+// The tag (e.g. "kernel:") is optional, and any tag is accepted by default; WithTag restricts it.
+var preambleRe = newPreambleRe(`(?:\S+:\s*)?`)
+
+// preambleRegexp is a regexp of the syslog preamble with the indices of the subexpressions of the fields; an index is -1 when the regexp doesn't capture the field.
+type preambleRegexp struct {
+	re                 *regexp.Regexp
+	timestampIdx       int
+	hostnameIdx        int
+	kernelTimestampIdx int
+	facilityMarkerIdx  int
+	// fallback is tried when re doesn't match, or nil
+	fallback *preambleRegexp
+}
+
+// timestampPattern matches the syslog timestamps that parseTimestamp understands, i.e. the classic one (e.g. "Jul 21 05:38:28") and the RFC 5424 one.
+// This is used where the preamble has no kernel timestamp to delimit the syslog timestamp, which is too ambiguous to be matched loosely.
+const timestampPattern = `[A-Z][a-z]{2}\s+\d{1,2}\s+\d{2}:\d{2}:\d{2}(?:\.\d+)?|\d{4}-\d{2}-\d{2}T\S+`
+
+// kernelTimestampAheadRe matches the kernel timestamp that is optionally preceded by a tag, at the beginning of the rest of the preamble matched by the fallback.
+var kernelTimestampAheadRe = regexp.MustCompile(`^(?:\S+:\s*)?\[\s*\d+\.\d+]`)
+
+// newPreambleRe makes a regexp of the syslog preamble with the given pattern of the tag part.
+// The syslog timestamp is anchored to the known formats, so that the hostname, which is optional (e.g. "Jul 20 13:24:22 kernel: [...]"), is not mistaken for a part of the timestamp.
+// The hostname cannot end with a colon, so that a tag is not mistaken for the hostname.
+// The facility marker (e.g. "kern.warn" of BusyBox syslogd) can precede the tag, and the printk caller ID (e.g. "[T1234]" of CONFIG_PRINTK_CALLER) can follow the kernel timestamp; they are seen on the minimal systems (e.g. WSL and the embedded ones).
+// The preambles that don't match are tried by the fallbacks in order:
+//  1. The syslog timestamp of an unknown format, which is delimited by the hostname and the kernel timestamp; the hostname is required for that, and the timestamp is left unparsed (see WithStrict).
+//  2. The kernel timestamp (e.g. "[14879.600492]") is absent, which is the case when the printk timestamp is disabled; the syslog timestamp must be either of the known formats.
+//     Note that a prefix that ends with a colon (e.g. "DROP:") is taken as the tag in that case if the line has no tag.
+func newPreambleRe(tagPattern string) *preambleRegexp {
+	marker := `(?:(?P<facility_marker>` + facilityMarkerPattern + `)\s+)?`
+	kernelTimestamp := `\[\s*(?P<kernel_timestamp>[^]]+)](?:\[\s*[TC]\d+])?\s+`
+	re := regexp.MustCompile(`^(?P<timestamp>` + timestampPattern + `)\s+(?:(?P<hostname>\S*[^\s:])\s+)?` + marker + tagPattern + kernelTimestamp)
+	unknownTimestamp := regexp.MustCompile(`^(?P<timestamp>.+?)\s+(?P<hostname>\S*[^\s:])\s+` + marker + tagPattern + kernelTimestamp)
+	withoutKernelTimestamp := regexp.MustCompile(`^(?P<timestamp>` + timestampPattern + `)\s+(?P<hostname>\S*[^\s:])\s+` + marker + tagPattern)
+	return &preambleRegexp{
+		re:                 re,
+		timestampIdx:       re.SubexpIndex("timestamp"),
+		hostnameIdx:        re.SubexpIndex("hostname"),
+		kernelTimestampIdx: re.SubexpIndex("kernel_timestamp"),
+		facilityMarkerIdx:  re.SubexpIndex("facility_marker"),
+		fallback: &preambleRegexp{
+			re:                 unknownTimestamp,
+			timestampIdx:       unknownTimestamp.SubexpIndex("timestamp"),
+			hostnameIdx:        unknownTimestamp.SubexpIndex("hostname"),
+			kernelTimestampIdx: unknownTimestamp.SubexpIndex("kernel_timestamp"),
+			facilityMarkerIdx:  unknownTimestamp.SubexpIndex("facility_marker"),
+			fallback: &preambleRegexp{
+				re:                 withoutKernelTimestamp,
+				timestampIdx:       withoutKernelTimestamp.SubexpIndex("timestamp"),
+				hostnameIdx:        withoutKernelTimestamp.SubexpIndex("hostname"),
+				kernelTimestampIdx: -1,
+				facilityMarkerIdx:  withoutKernelTimestamp.SubexpIndex("facility_marker"),
+			},
+		},
+	}
+}
+
+// submatch returns the text of the subexpression of the given index, or false if the subexpression doesn't exist or doesn't participate in the match.
+func (p *preambleRegexp) submatch(line string, match []int, idx int) (string, bool) {
+	if idx < 0 || match[2*idx] < 0 {
+		return "", false
+	}
+	return line[match[2*idx]:match[2*idx+1]], true
+}
 
 var (
 	// ErrLogFormatUnmatched is an error that occurs when it cannot parse the given log line.
 	ErrLogFormatUnmatched = errors.New("given log text is not matched with the log format")
 	// ErrStringToNumberConversionFailed is an error that occurs when it cannot convert a stringy number field into number.
 	ErrStringToNumberConversionFailed = errors.New("failed to convert a string field to number")
+	// ErrTimestampParseFailed is an error that occurs when it cannot interpret the syslog timestamp in strict mode.
+	ErrTimestampParseFailed = errors.New("failed to parse the syslog timestamp")
+	// ErrLineTooLong is an error that occurs when the given line exceeds the maximum length; see WithMaxLineLength.
+	ErrLineTooLong = errors.New("line is too long")
 )
 
+// Parser is a parser of iptables logs that is configured with options.
+// A Parser is safe for concurrent use by multiple goroutines.
+type Parser struct {
+	opts *options
+}
+
+var defaultParser = &Parser{opts: defaultOptions()}
+
+// NewParser makes a new Parser with the given options.
+// This returns an error if any of the options is invalid.
+func NewParser(opts ...Option) (*Parser, error) {
+	o, err := newOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Parser{opts: o}, nil
+}
+
 // Parse parses an iptables line.
 // This function might return the two types of error: ErrLogFormatUnmatched or ErrStringToNumberConversionFailed.
+// The former is returned as *UnmatchedError, which carries the line and the reason. The latter is returned as *FieldConversionError, which carries the offending field and value.
 func Parse(line string) (*Log, error) {
-	submatch := re.FindStringSubmatch(line)
-	if len(submatch) <= 0 {
-		return nil, ErrLogFormatUnmatched
-	}
+	return defaultParser.Parse(line)
+}
 
-	kernelTimestamp, err := strconv.ParseFloat(submatch[3], 64)
+// ParseWithOptions parses an iptables line with the given options.
+// This is a shorthand of NewParser(opts...) and Parser.Parse; consider reusing a Parser when parsing many lines.
+func ParseWithOptions(line string, opts ...Option) (*Log, error) {
+	p, err := NewParser(opts...)
 	if err != nil {
-		return nil, fmt.Errorf("%s; field = kernel-timestamp: %w", err, ErrStringToNumberConversionFailed)
+		return nil, err
 	}
+	return p.Parse(line)
+}
 
-	l, err := strconv.ParseInt(submatch[10], 10, 64)
-	if err != nil && submatch[10] != "" {
-		return nil, fmt.Errorf("%s; field = len: %w", err, ErrStringToNumberConversionFailed)
-	}
+// ParseInto parses an iptables line into the given Log; see Parser.ParseInto for details.
+func ParseInto(line string, dst *Log) error {
+	return defaultParser.ParseInto(line, dst)
+}
 
-	tos, err := strconv.ParseInt(submatch[11], 16, 64)
-	if err != nil && submatch[11] != "" {
-		return nil, fmt.Errorf("%s; field = tos: %w", err, ErrStringToNumberConversionFailed)
-	}
+// Parse parses an iptables line.
+// This method might return the two types of error: ErrLogFormatUnmatched or ErrStringToNumberConversionFailed.
+// The former is returned as *UnmatchedError, which carries the line and the reason. The latter is returned as *FieldConversionError, which carries the offending field and value.
+// In addition, this returns ErrTimestampParseFailed when WithStrict is enabled and the syslog timestamp cannot be interpreted, and ErrLineTooLong when the line exceeds the maximum length (see WithMaxLineLength).
+// When WithCollectAllErrors is enabled, all the failures are joined into the error, and the partially populated Log is returned along with it.
+//
+// The numeric fields are handled consistently as follows, which makes the truncated lines parsable as far as possible:
+//   - A field with an empty value (e.g. "LEN=") is zero-filled without an error; UID and GID are -1 instead, since zero is a valid ID.
+//   - A field with a malformed value (e.g. "LEN=6O" and "TTL=-1") fails with *FieldConversionError.
+//   - A missing mandatory field (i.e. SRC, DST, LEN, PROTO, and TTL or HOPLIMIT) fails with *UnmatchedError, and the other missing fields are left zero.
+func (p *Parser) Parse(line string) (*Log, error) {
+	l := &Log{}
+	return p.result(l, p.parse(line, l, p.opts.preambleRe))
+}
 
-	prec, err := strconv.ParseInt(submatch[12], 16, 64)
-	if err != nil && submatch[12] != "" {
-		return nil, fmt.Errorf("%s; field = prec: %w", err, ErrStringToNumberConversionFailed)
+// ParseInto parses an iptables line into the given Log, instead of allocating a new one.
+// This resets all the fields of dst before parsing, so no stale data of the previous parsing remains; this makes it possible to reuse a Log (e.g. by sync.Pool) in hot loops.
+// This might return the same errors as Parse; the content of dst is unspecified on error, unless WithCollectAllErrors is enabled (see Parse).
+func (p *Parser) ParseInto(line string, dst *Log) error {
+	*dst = Log{}
+	return p.parse(line, dst, p.opts.preambleRe)
+}
+
+// ParseBytes parses an iptables line given as bytes; see Parser.ParseBytes for details.
+func ParseBytes(b []byte) (*Log, error) {
+	return defaultParser.ParseBytes(b)
+}
+
+// ParseBytesInto parses an iptables line given as bytes into the given Log; see Parser.ParseBytesInto for details.
+func ParseBytesInto(b []byte, dst *Log) error {
+	return defaultParser.ParseBytesInto(b, dst)
+}
+
+// ParseBytes parses an iptables line given as bytes, as well as Parse does, without copying the bytes into a string.
+// The string fields of the Log (and the ones of the error, e.g. UnmatchedError.Line) refer to the given bytes directly, so the bytes must not be modified as long as the Log and the error are in use.
+// So this is for the immutable inputs, e.g. the bytes of a file read at once; if the caller reuses the buffer (e.g. bufio.Scanner.Bytes), use Parse(string(b)) instead, which copies the line.
+func (p *Parser) ParseBytes(b []byte) (*Log, error) {
+	return p.Parse(bytesToString(b))
+}
+
+// ParseBytesInto parses an iptables line given as bytes into the given Log without copying the bytes; see Parser.ParseBytes and Parser.ParseInto for details.
+func (p *Parser) ParseBytesInto(b []byte, dst *Log) error {
+	return p.ParseInto(bytesToString(b), dst)
+}
+
+// bytesToString returns the string that shares the memory with the given bytes; the bytes must not be modified while the string is in use.
+func bytesToString(b []byte) string {
+	return unsafe.String(unsafe.SliceData(b), len(b))
+}
+
+// parse parses the line into the given Log, matching the syslog preamble by the given regexp.
+func (p *Parser) parse(line string, l *Log, preamble *preambleRegexp) error {
+	if p.opts.retainRaw {
+		l.Raw = line
+	}
+	err := p.parseLine(line, l, preamble)
+	var unmatchedErr *UnmatchedError
+	if errors.As(err, &unmatchedErr) {
+		unmatchedErr.Line = line
 	}
+	return err
+}
 
-	ttl, err := strconv.ParseInt(submatch[13], 10, 64)
-	if err != nil && submatch[13] != "" {
-		return nil, fmt.Errorf("%s; field = ttl: %w", err, ErrStringToNumberConversionFailed)
+func (p *Parser) parseLine(line string, l *Log, preamble *preambleRegexp) error {
+	fields, err := p.parsePreamble(line, l, preamble)
+	if err != nil && (!p.opts.collectAllErrors || fields == "") {
+		return err
+	}
+	if fieldsErr := parseFields(fields, l, p.opts, seenMandatoryFields); fieldsErr != nil {
+		return joinErrors(err, fieldsErr)
 	}
+	return err
+}
+
+// parsePreamble parses the part of the line before the fields, i.e. the syslog priority, the syslog preamble, and the prefix, into the given Log.
+// The syslog preamble is matched by the given regexp. This returns the rest of the line, i.e. the fields that begin with "IN=".
+// When WithCollectAllErrors is enabled, the failures of the fields in the preamble are returned along with the rest of the line, which is empty if the preamble is not found.
+func (p *Parser) parsePreamble(line string, l *Log, preamble *preambleRegexp) (string, error) {
+	o := p.opts
+	var errs []error
 
-	id, err := strconv.ParseInt(submatch[14], 10, 64)
-	if err != nil && submatch[14] != "" {
-		return nil, fmt.Errorf("%s; field = id: %w", err, ErrStringToNumberConversionFailed)
+	if o.maxLineLength > 0 && len(line) > o.maxLineLength {
+		return "", fmt.Errorf("%d bytes exceeds the maximum of %d bytes: %w", len(line), o.maxLineLength, ErrLineTooLong)
 	}
 
-	frag, err := strconv.ParseInt(submatch[18], 10, 64)
-	if err != nil && submatch[18] != "" {
-		return nil, fmt.Errorf("%s; field = frag: %w", err, ErrStringToNumberConversionFailed)
+	// the lines can have the stray whitespaces around them, e.g. the carriage return of CRLF
+	line = strings.TrimSpace(line)
+
+	if o.priority {
+		rest, err := parsePriority(line, l)
+		if err != nil {
+			var conversionErr *FieldConversionError
+			if !o.collectAllErrors || !errors.As(err, &conversionErr) {
+				return "", err
+			}
+			errs = append(errs, err)
+		}
+		line = rest
 	}
 
-	typ, err := strconv.ParseInt(submatch[21], 10, 64)
-	if err != nil && submatch[21] != "" {
-		return nil, fmt.Errorf("%s; field = type: %w", err, ErrStringToNumberConversionFailed)
+	// the syslog preamble is matched by the regexp, and the rest is tokenized by hand for performance
+	match := preamble.re.FindStringSubmatchIndex(line)
+	for len(match) <= 0 && preamble.fallback != nil {
+		preamble = preamble.fallback
+		match = preamble.re.FindStringSubmatchIndex(line)
+		if len(match) > 0 && preamble.kernelTimestampIdx < 0 && kernelTimestampAheadRe.MatchString(line[match[1]:]) {
+			// the kernel timestamp is there, but the preamble has failed to match for the other reason (e.g. the unexpected tag)
+			match = nil
+		}
 	}
+	if len(match) <= 0 {
+		return "", &UnmatchedError{Reason: "syslog preamble (i.e. timestamp, hostname, tag, and kernel timestamp) is not found"}
+	}
+	body := line[match[1]:]
 
-	code, err := strconv.ParseInt(submatch[22], 10, 64)
-	if err != nil && submatch[22] != "" {
-		return nil, fmt.Errorf("%s; field = code: %w", err, ErrStringToNumberConversionFailed)
+	prefix, fields, ok := splitPrefix(body)
+	if !ok {
+		return "", &UnmatchedError{Reason: "IN= and OUT= are not found"}
 	}
 
-	sourcePort, err := strconv.ParseInt(submatch[23], 10, 64)
-	if err != nil && submatch[23] != "" {
-		return nil, fmt.Errorf("%s; field = spt: %w", err, ErrStringToNumberConversionFailed)
+	var hasTimestamp, hasHostname bool
+	if l.Timestamp, hasTimestamp = preamble.submatch(line, match, preamble.timestampIdx); hasTimestamp {
+		l.present.add(FieldTimestamp)
+	}
+	if l.Hostname, hasHostname = preamble.submatch(line, match, preamble.hostnameIdx); hasHostname {
+		l.present.add(FieldHostname)
+	}
+	if marker, ok := preamble.submatch(line, match, preamble.facilityMarkerIdx); ok && !l.HasPriority {
+		// the syslog priority takes precedence, though they should agree
+		parseFacilityMarker(marker, l)
+	}
+	l.Prefix = prefix
+	if prefix != "" || hasBlankPrefix(line[:match[1]], body) {
+		l.present.add(FieldPrefix)
 	}
 
-	destinationPort, err := strconv.ParseInt(submatch[24], 10, 64)
-	if err != nil && submatch[24] != "" {
-		return nil, fmt.Errorf("%s; field = dpt: %w", err, ErrStringToNumberConversionFailed)
+	if hasTimestamp {
+		timestampParsed, err := parseTimestamp(l.Timestamp, o)
+		if err != nil && o.strict {
+			err = fmt.Errorf("%s; timestamp = %q: %w", err, l.Timestamp, ErrTimestampParseFailed)
+			if !o.collectAllErrors {
+				return "", err
+			}
+			errs = append(errs, err)
+		}
+		l.TimestampParsed = timestampParsed
+		if err == nil {
+			l.present.add(FieldTimestampParsed)
+		}
 	}
 
-	sequence, err := strconv.ParseInt(submatch[25], 10, 64)
-	if err != nil && submatch[25] != "" {
-		return nil, fmt.Errorf("%s; field = seq: %w", err, ErrStringToNumberConversionFailed)
+	if rawKernelTimestamp, ok := preamble.submatch(line, match, preamble.kernelTimestampIdx); ok {
+		kernelTimestamp, err := strconv.ParseFloat(rawKernelTimestamp, 64)
+		if err != nil {
+			err = &FieldConversionError{Field: "kernel-timestamp", Value: rawKernelTimestamp, Err: err}
+			if !o.collectAllErrors {
+				return "", err
+			}
+			errs = append(errs, err)
+		} else {
+			l.KernelTimestamp = kernelTimestamp
+			l.present.add(FieldKernelTimestamp)
+		}
 	}
 
-	ack, err := strconv.ParseInt(submatch[26], 10, 64)
-	if err != nil && submatch[26] != "" {
-		return nil, fmt.Errorf("%s; field = ack: %w", err, ErrStringToNumberConversionFailed)
+	return fields, joinErrors(errs...)
+}
+
+// joinErrors joins the given errors by errors.Join, except that a single error is returned as it is to keep its type.
+func joinErrors(errs ...error) error {
+	var nonNil []error
+	for _, err := range errs {
+		if err != nil {
+			nonNil = append(nonNil, err)
+		}
 	}
+	if len(nonNil) == 1 {
+		return nonNil[0]
+	}
+	return errors.Join(nonNil...)
+}
 
-	window, err := strconv.ParseInt(submatch[27], 10, 64)
-	if err != nil && submatch[27] != "" {
-		return nil, fmt.Errorf("%s; field = window: %w", err, ErrStringToNumberConversionFailed)
+// hasFieldFailure reports whether the error has a failure of a field, i.e. *FieldConversionError or ErrTimestampParseFailed, rather than the failure of the line.
+func hasFieldFailure(err error) bool {
+	var conversionErr *FieldConversionError
+	return errors.As(err, &conversionErr) || errors.Is(err, ErrTimestampParseFailed)
+}
+
+// result returns the given Log and the error of parsing it as the entry points (e.g. Parse) return them.
+// The Log is nil on error, unless WithCollectAllErrors is enabled and the error has a failure of a field, in which case the rest of the fields have been attempted and the partially populated Log is returned along with the error.
+func (p *Parser) result(l *Log, err error) (*Log, error) {
+	if err == nil || p.opts.collectAllErrors && hasFieldFailure(err) {
+		return l, err
 	}
+	return nil, err
+}
+
+// splitPrefix splits the given text into the prefix and the fields.
+// The prefix is everything before the first "IN=... OUT=..." that is followed by the IP header fields; it is not necessarily followed by a space.
+// If there is no such "IN=... OUT=...", the first one is regarded as the beginning of the fields.
+func splitPrefix(body string) (string, string, bool) {
+	fallback := -1
+	offset := 0
+	for {
+		i := strings.Index(body[offset:], "IN=")
+		if i < 0 {
+			break
+		}
+		i += offset
+		offset = i + len("IN=")
 
-	res, err := strconv.ParseInt(submatch[28], 16, 64)
-	if err != nil && submatch[28] != "" {
-		return nil, fmt.Errorf("%s; field = res: %w", err, ErrStringToNumberConversionFailed)
+		// skip the value of IN, which can be empty
+		valueEnd := offset
+		for valueEnd < len(body) && !isSpace(body[valueEnd]) {
+			valueEnd++
+		}
+		t := &tokenizer{text: body[valueEnd:]}
+		out, ok := t.next()
+		if !ok || !strings.HasPrefix(out, "OUT=") {
+			continue
+		}
+		if fallback < 0 {
+			fallback = i
+		}
+		following, _ := t.next()
+		for _, key := range followingOutKeys {
+			if strings.HasPrefix(following, key) {
+				return strings.TrimRightFunc(body[:i], unicode.IsSpace), body[i:], true
+			}
+		}
 	}
 
-	urgp, err := strconv.ParseInt(submatch[35], 10, 64)
-	if err != nil && submatch[35] != "" {
-		return nil, fmt.Errorf("%s; field = urgp: %w", err, ErrStringToNumberConversionFailed)
+	if fallback < 0 {
+		return "", "", false
 	}
+	return strings.TrimRightFunc(body[:fallback], unicode.IsSpace), body[fallback:], true
+}
 
-	return &Log{
-		Timestamp:              submatch[1],
-		Hostname:               submatch[2],
-		KernelTimestamp:        kernelTimestamp,
-		Prefix:                 submatch[4],
-		InputInterface:         submatch[5],
-		OutputInterface:        submatch[6],
-		MACAddress:             submatch[7],
-		Source:                 submatch[8],
-		Destination:            submatch[9],
-		Length:                 uint64(l),
-		ToS:                    uint8(tos),
-		Precedence:             uint8(prec),
-		TTL:                    uint64(ttl),
-		ID:                     uint64(id),
-		CongestionExperienced:  submatch[15] != "",
-		DoNotFragment:          submatch[16] != "",
-		MoreFragmentsFollowing: submatch[17] != "",
-		Frag:                   frag,
-		IPOptions:              submatch[19],
-		Protocol:               submatch[20],
-		Type:                   typ,
-		Code:                   code,
-		SourcePort:             uint16(sourcePort),
-		DestinationPort:        uint16(destinationPort),
-		Sequence:               uint64(sequence),
-		AckSequence:            uint64(ack),
-		WindowSize:             uint64(window),
-		Res:                    uint64(res),
-		Urgent:                 submatch[29] != "",
-		Ack:                    submatch[30] != "",
-		Push:                   submatch[31] != "",
-		Reset:                  submatch[32] != "",
-		Syn:                    submatch[33] != "",
-		Fin:                    submatch[34] != "",
-		Urgp:                   uint64(urgp),
-		TCPOption:              submatch[36],
-	}, nil
+// hasBlankPrefix reports whether the prefix consists of the whitespaces only, given the matched preamble and the following body.
+// The kernel separates the preamble and the prefix by a single space, so the prefix is blank if the preamble is followed by more whitespaces, or if the body begins with the whitespaces that the preamble leaves.
+func hasBlankPrefix(preamble string, body string) bool {
+	if body != "" && isSpace(body[0]) {
+		return true
+	}
+	spaces := len(preamble) - len(strings.TrimRightFunc(preamble, unicode.IsSpace))
+	return spaces > 1
 }
+
+// followingOutKeys are the keys of the fields that can follow OUT.
+var followingOutKeys = []string{"PHYSIN=", "PHYSOUT=", "MAC=", "SRC="}
@@ -1,12 +1,25 @@
 package iptables
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
+	"unsafe"
 
 	"github.com/stretchr/testify/assert"
 )
 
 func TestParse(t *testing.T) {
+	year := 2022
+	p, err := NewParser(WithYear(year))
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	type TestCase struct {
 		line     string
 		expected *Log
@@ -18,6 +31,7 @@ func TestParse(t *testing.T) {
 			line: "2022-07-12T09:01:27.345918+00:00 ubuntu-jammy kernel: [ 1269.733882] IN= foo IN=bar ININ: IN=enp0s3 OUT= MAC=00:b3:dd:bc:29:e1:52:54:00:12:35:02:08:00 SRC=93.184.216.34 DST=10.0.2.15 LEN=44 TOS=0x00 PREC=0x00 TTL=64 ID=15989 PROTO=TCP SPT=80 DPT=54830 SEQ=153856001 ACK=1134538652 WINDOW=65535 RES=0x00 ACK SYN URGP=0 OPT (020405B4)",
 			expected: &Log{
 				Timestamp:              "2022-07-12T09:01:27.345918+00:00",
+				TimestampParsed:        time.Date(2022, time.July, 12, 9, 1, 27, 345918000, time.UTC),
 				Hostname:               "ubuntu-jammy",
 				KernelTimestamp:        1269.733882,
 				Prefix:                 "IN= foo IN=bar ININ:",
@@ -26,6 +40,7 @@ func TestParse(t *testing.T) {
 				MACAddress:             "00:b3:dd:bc:29:e1:52:54:00:12:35:02:08:00",
 				Source:                 "93.184.216.34",
 				Destination:            "10.0.2.15",
+				IPVersion:              4,
 				Length:                 44,
 				ToS:                    0,
 				Precedence:             0,
@@ -53,6 +68,8 @@ func TestParse(t *testing.T) {
 				Fin:                    false,
 				Urgp:                   0,
 				TCPOption:              "020405B4",
+				UID:                    -1,
+				GID:                    -1,
 			},
 		},
 		{
@@ -60,6 +77,7 @@ func TestParse(t *testing.T) {
 			line: "Jul 20 13:24:22 ubuntu-jammy kernel: [  396.854443] IN=enp0s3 OUT= MAC=00:b3:dd:bc:29:e1:52:54:00:12:35:02:08:00 SRC=10.0.2.2 DST=10.0.2.15 LEN=76 TOS=0x00 PREC=0x00 TTL=64 ID=5525 PROTO=TCP SPT=59076 DPT=22 WINDOW=65535 RES=0x00 ACK PSH URGP=0",
 			expected: &Log{
 				Timestamp:              "Jul 20 13:24:22",
+				TimestampParsed:        time.Date(year, time.July, 20, 13, 24, 22, 0, time.Local),
 				Hostname:               "ubuntu-jammy",
 				KernelTimestamp:        396.854443,
 				Prefix:                 "",
@@ -68,6 +86,7 @@ func TestParse(t *testing.T) {
 				MACAddress:             "00:b3:dd:bc:29:e1:52:54:00:12:35:02:08:00",
 				Source:                 "10.0.2.2",
 				Destination:            "10.0.2.15",
+				IPVersion:              4,
 				Length:                 76,
 				ToS:                    0,
 				Precedence:             0,
@@ -95,6 +114,8 @@ func TestParse(t *testing.T) {
 				Fin:                    false,
 				Urgp:                   0,
 				TCPOption:              "",
+				UID:                    -1,
+				GID:                    -1,
 			},
 		},
 		{
@@ -102,6 +123,7 @@ func TestParse(t *testing.T) {
 			line: "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=93.184.216.34 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=64125 DF PROTO=TCP SPT=54832 DPT=80 SEQ=567002889 ACK=0 WINDOW=64240 RES=0x00 SYN URGP=0 OPT (020405B40402080A12A016080000000001030307)",
 			expected: &Log{
 				Timestamp:              "Jul 21 05:31:48",
+				TimestampParsed:        time.Date(year, time.July, 21, 5, 31, 48, 0, time.Local),
 				Hostname:               "ubuntu-jammy",
 				KernelTimestamp:        14479.122228,
 				Prefix:                 "OUT-LOG:",
@@ -110,6 +132,7 @@ func TestParse(t *testing.T) {
 				MACAddress:             "",
 				Source:                 "10.0.2.15",
 				Destination:            "93.184.216.34",
+				IPVersion:              4,
 				Length:                 60,
 				ToS:                    0,
 				Precedence:             0,
@@ -137,6 +160,8 @@ func TestParse(t *testing.T) {
 				Fin:                    false,
 				Urgp:                   0,
 				TCPOption:              "020405B40402080A12A016080000000001030307",
+				UID:                    -1,
+				GID:                    -1,
 			},
 		},
 		{
@@ -144,6 +169,7 @@ func TestParse(t *testing.T) {
 			line: "Jul 21 05:38:28 ubuntu-jammy kernel: [14879.600492] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=84 TOS=0x00 PREC=0x00 TTL=64 ID=6495 DF PROTO=ICMP TYPE=8 CODE=0 ID=1 SEQ=3",
 			expected: &Log{
 				Timestamp:              "Jul 21 05:38:28",
+				TimestampParsed:        time.Date(year, time.July, 21, 5, 38, 28, 0, time.Local),
 				Hostname:               "ubuntu-jammy",
 				KernelTimestamp:        14879.600492,
 				Prefix:                 "OUT-LOG:",
@@ -152,6 +178,7 @@ func TestParse(t *testing.T) {
 				MACAddress:             "",
 				Source:                 "10.0.2.15",
 				Destination:            "8.8.8.8",
+				IPVersion:              4,
 				Length:                 84,
 				ToS:                    0,
 				Precedence:             0,
@@ -165,6 +192,8 @@ func TestParse(t *testing.T) {
 				Protocol:               "ICMP",
 				Type:                   8,
 				Code:                   0,
+				ICMPID:                 1,
+				ICMPSeq:                3,
 				SourcePort:             0,
 				DestinationPort:        0,
 				Sequence:               0,
@@ -179,6 +208,8 @@ func TestParse(t *testing.T) {
 				Fin:                    false,
 				Urgp:                   0,
 				TCPOption:              "",
+				UID:                    -1,
+				GID:                    -1,
 			},
 		},
 		{
@@ -186,6 +217,7 @@ func TestParse(t *testing.T) {
 			line: "2022-07-12T09:01:27.345918+00:00 ubuntu-jammy kernel: [ 1269.733882] IN=enp0s3 OUT= MAC=00:b3:dd:bc:29:e1:52:54:00:12:35:02:08:00 SRC=93.184.216.34 DST=10.0.2.15 LEN=44 TOS=0x01 PREC=0x02 TTL=64 ID=15989 CE DF MF FRAG=123 OPT (0123456789) PROTO=TCP SPT=80 DPT=54830 SEQ=153856001 ACK=1134538652 WINDOW=65535 RES=0x03 URG ACK PSH RST SYN FIN URGP=4 OPT (020405B4)",
 			expected: &Log{
 				Timestamp:              "2022-07-12T09:01:27.345918+00:00",
+				TimestampParsed:        time.Date(2022, time.July, 12, 9, 1, 27, 345918000, time.UTC),
 				Hostname:               "ubuntu-jammy",
 				KernelTimestamp:        1269.733882,
 				Prefix:                 "",
@@ -194,6 +226,7 @@ func TestParse(t *testing.T) {
 				MACAddress:             "00:b3:dd:bc:29:e1:52:54:00:12:35:02:08:00",
 				Source:                 "93.184.216.34",
 				Destination:            "10.0.2.15",
+				IPVersion:              4,
 				Length:                 44,
 				ToS:                    1,
 				Precedence:             2,
@@ -221,15 +254,1596 @@ func TestParse(t *testing.T) {
 				Fin:                    true,
 				Urgp:                   4,
 				TCPOption:              "020405B4",
+				UID:                    -1,
+				GID:                    -1,
+			},
+		},
+		{
+			// ip6tables log
+			line: "Jul 22 10:00:00 ubuntu-jammy kernel: [ 5000.123456] IN-LOG: IN=enp0s3 OUT= MAC=00:b3:dd:bc:29:e1:52:54:00:12:35:02:86:dd SRC=2001:0db8:0000:0000:0000:0000:0000:0001 DST=2001:0db8:0000:0000:0000:0000:0000:0002 LEN=80 TC=32 HOPLIMIT=57 FLOWLBL=123456 PROTO=TCP SPT=443 DPT=50000 SEQ=2717302352 ACK=1871154530 WINDOW=28800 RES=0x00 ACK SYN URGP=0",
+			expected: &Log{
+				Timestamp:              "Jul 22 10:00:00",
+				TimestampParsed:        time.Date(year, time.July, 22, 10, 0, 0, 0, time.Local),
+				Hostname:               "ubuntu-jammy",
+				KernelTimestamp:        5000.123456,
+				Prefix:                 "IN-LOG:",
+				InputInterface:         "enp0s3",
+				OutputInterface:        "",
+				MACAddress:             "00:b3:dd:bc:29:e1:52:54:00:12:35:02:86:dd",
+				Source:                 "2001:0db8:0000:0000:0000:0000:0000:0001",
+				Destination:            "2001:0db8:0000:0000:0000:0000:0000:0002",
+				IPVersion:              6,
+				Length:                 80,
+				ToS:                    0,
+				Precedence:             0,
+				TTL:                    57,
+				ID:                     0,
+				CongestionExperienced:  false,
+				DoNotFragment:          false,
+				MoreFragmentsFollowing: false,
+				Frag:                   0,
+				IPOptions:              "",
+				IsIPv6:                 true,
+				TrafficClass:           32,
+				FlowLabel:              123456,
+				Protocol:               "TCP",
+				Type:                   0,
+				Code:                   0,
+				SourcePort:             443,
+				DestinationPort:        50000,
+				Sequence:               2717302352,
+				AckSequence:            1871154530,
+				WindowSize:             28800,
+				Res:                    0,
+				Urgent:                 false,
+				Ack:                    true,
+				Push:                   false,
+				Reset:                  false,
+				Syn:                    true,
+				Fin:                    false,
+				Urgp:                   0,
+				TCPOption:              "",
+				UID:                    -1,
+				GID:                    -1,
+			},
+		},
+		{
+			// ip6tables log with compressed addresses
+			line: "Jul 22 10:00:01 ubuntu-jammy kernel: [ 5001.123456] IN= OUT=enp0s3 SRC=fe80::a00:27ff:fe4e:66a1 DST=ff02::1:2 LEN=147 TC=0 HOPLIMIT=1 FLOWLBL=0 PROTO=UDP SPT=546 DPT=547 LEN=107",
+			expected: &Log{
+				Timestamp:              "Jul 22 10:00:01",
+				TimestampParsed:        time.Date(year, time.July, 22, 10, 0, 1, 0, time.Local),
+				Hostname:               "ubuntu-jammy",
+				KernelTimestamp:        5001.123456,
+				Prefix:                 "",
+				InputInterface:         "",
+				OutputInterface:        "enp0s3",
+				MACAddress:             "",
+				Source:                 "fe80::a00:27ff:fe4e:66a1",
+				Destination:            "ff02::1:2",
+				IPVersion:              6,
+				Length:                 147,
+				ToS:                    0,
+				Precedence:             0,
+				TTL:                    1,
+				ID:                     0,
+				CongestionExperienced:  false,
+				DoNotFragment:          false,
+				MoreFragmentsFollowing: false,
+				Frag:                   0,
+				IPOptions:              "",
+				IsIPv6:                 true,
+				TrafficClass:           0,
+				FlowLabel:              0,
+				Protocol:               "UDP",
+				Type:                   0,
+				Code:                   0,
+				SourcePort:             546,
+				DestinationPort:        547,
+				Sequence:               0,
+				AckSequence:            0,
+				WindowSize:             0,
+				Res:                    0,
+				Urgent:                 false,
+				Ack:                    false,
+				Push:                   false,
+				Reset:                  false,
+				Syn:                    false,
+				Fin:                    false,
+				Urgp:                   0,
+				TCPOption:              "",
+				UID:                    -1,
+				GID:                    -1,
 			},
 		},
 	}
 
 	for _, testCase := range testCases {
-		parsedLog, err := Parse(testCase.line)
+		parsedLog, err := p.Parse(testCase.line)
 		if err != nil {
 			t.Fatal(err)
 		}
+		// the presence of the fields is tested by TestLog_Has
+		parsedLog.present = FieldSet{}
 		assert.EqualValues(t, testCase.expected, parsedLog)
 	}
 }
+
+func TestParse_Prefix(t *testing.T) {
+	type TestCase struct {
+		line                   string
+		expectedPrefix         string
+		expectedInputInterface string
+		expectedSource         string
+	}
+
+	testCases := []*TestCase{
+		{
+			// prefix resembling a field
+			line:                   "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] IN-DROP: IN=enp0s3 OUT= SRC=93.184.216.34 DST=10.0.2.15 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=64125 DF PROTO=TCP SPT=80 DPT=54832 WINDOW=64240 RES=0x00 SYN URGP=0",
+			expectedPrefix:         "IN-DROP:",
+			expectedInputInterface: "enp0s3",
+			expectedSource:         "93.184.216.34",
+		},
+		{
+			// prefix containing "="
+			line:                   "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] table=filter chain=INPUT IN=enp0s3 OUT= SRC=93.184.216.34 DST=10.0.2.15 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=64125 DF PROTO=TCP SPT=80 DPT=54832 WINDOW=64240 RES=0x00 SYN URGP=0",
+			expectedPrefix:         "table=filter chain=INPUT",
+			expectedInputInterface: "enp0s3",
+			expectedSource:         "93.184.216.34",
+		},
+		{
+			// prefix containing "IN=" and "OUT=" respectively
+			line:                   "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] IN=x: OUT=y: IN=enp0s3 OUT= SRC=93.184.216.34 DST=10.0.2.15 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=64125 DF PROTO=TCP SPT=80 DPT=54832 WINDOW=64240 RES=0x00 SYN URGP=0",
+			expectedPrefix:         "IN=x: OUT=y:",
+			expectedInputInterface: "enp0s3",
+			expectedSource:         "93.184.216.34",
+		},
+		{
+			// prefix with colons and multiple spaces
+			line:                   "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] FW:  DROP:  IN:   IN=enp0s3 OUT= SRC=93.184.216.34 DST=10.0.2.15 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=64125 DF PROTO=TCP SPT=80 DPT=54832 WINDOW=64240 RES=0x00 SYN URGP=0",
+			expectedPrefix:         "FW:  DROP:  IN:",
+			expectedInputInterface: "enp0s3",
+			expectedSource:         "93.184.216.34",
+		},
+		{
+			// prefix without a trailing space
+			line:                   "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] DROP:IN=enp0s3 OUT= SRC=93.184.216.34 DST=10.0.2.15 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=64125 DF PROTO=TCP SPT=80 DPT=54832 WINDOW=64240 RES=0x00 SYN URGP=0",
+			expectedPrefix:         "DROP:",
+			expectedInputInterface: "enp0s3",
+			expectedSource:         "93.184.216.34",
+		},
+	}
+
+	for _, testCase := range testCases {
+		parsedLog, err := Parse(testCase.line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, testCase.expectedPrefix, parsedLog.Prefix)
+		assert.Equal(t, testCase.expectedInputInterface, parsedLog.InputInterface)
+		assert.Equal(t, testCase.expectedSource, parsedLog.Source)
+	}
+}
+
+func TestParse_Owner(t *testing.T) {
+	type TestCase struct {
+		line        string
+		expectedUID int64
+		expectedGID int64
+	}
+
+	testCases := []*TestCase{
+		{
+			line:        "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=93.184.216.34 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=64125 DF PROTO=TCP SPT=54832 DPT=80 SEQ=567002889 ACK=0 WINDOW=64240 RES=0x00 SYN URGP=0 OPT (020405B40402080A12A016080000000001030307) UID=1000 GID=1001",
+			expectedUID: 1000,
+			expectedGID: 1001,
+		},
+		{
+			// reversed order
+			line:        "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=64 TOS=0x00 PREC=0x00 TTL=64 ID=40000 PROTO=UDP SPT=40000 DPT=53 LEN=44 GID=0 UID=0",
+			expectedUID: 0,
+			expectedGID: 0,
+		},
+		{
+			// only UID
+			line:        "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=64 TOS=0x00 PREC=0x00 TTL=64 ID=40000 PROTO=UDP SPT=40000 DPT=53 LEN=44 UID=33",
+			expectedUID: 33,
+			expectedGID: -1,
+		},
+		{
+			// absent; the prefix looking like the owner fields must be ignored
+			line:        "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] UID=1 GID=2 IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=64 TOS=0x00 PREC=0x00 TTL=64 ID=40000 PROTO=UDP SPT=40000 DPT=53 LEN=44",
+			expectedUID: -1,
+			expectedGID: -1,
+		},
+	}
+
+	for _, testCase := range testCases {
+		parsedLog, err := Parse(testCase.line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, testCase.expectedUID, parsedLog.UID)
+		assert.Equal(t, testCase.expectedGID, parsedLog.GID)
+	}
+}
+
+func TestParse_Mark(t *testing.T) {
+	type TestCase struct {
+		line            string
+		expectedMark    uint32
+		expectedHasMark bool
+	}
+
+	testCases := []*TestCase{
+		{
+			line:            "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=93.184.216.34 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=64125 DF PROTO=TCP SPT=54832 DPT=80 SEQ=567002889 ACK=0 WINDOW=64240 RES=0x00 SYN URGP=0 OPT (020405B40402080A12A016080000000001030307) MARK=0x16",
+			expectedMark:    0x16,
+			expectedHasMark: true,
+		},
+		{
+			line:            "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=64 TOS=0x00 PREC=0x00 TTL=64 ID=40000 PROTO=UDP SPT=40000 DPT=53 LEN=44 UID=0 GID=0 MARK=0xffffffff",
+			expectedMark:    0xffffffff,
+			expectedHasMark: true,
+		},
+		{
+			line:            "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=64 TOS=0x00 PREC=0x00 TTL=64 ID=40000 PROTO=UDP SPT=40000 DPT=53 LEN=44 MARK=0x0",
+			expectedMark:    0,
+			expectedHasMark: true,
+		},
+		{
+			line:            "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=64 TOS=0x00 PREC=0x00 TTL=64 ID=40000 PROTO=UDP SPT=40000 DPT=53 LEN=44",
+			expectedMark:    0,
+			expectedHasMark: false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		parsedLog, err := Parse(testCase.line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, testCase.expectedMark, parsedLog.Mark)
+		assert.Equal(t, testCase.expectedHasMark, parsedLog.HasMark)
+	}
+
+	_, err := Parse("Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=64 TOS=0x00 PREC=0x00 TTL=64 ID=40000 PROTO=UDP SPT=40000 DPT=53 LEN=44 MARK=0x100000000")
+	assert.True(t, errors.Is(err, ErrStringToNumberConversionFailed))
+}
+
+func TestParse_PhysicalInterfaces(t *testing.T) {
+	type TestCase struct {
+		line                        string
+		expectedInputInterface      string
+		expectedOutputInterface     string
+		expectedPhysInputInterface  string
+		expectedPhysOutputInterface string
+		expectedMACAddress          string
+	}
+
+	testCases := []*TestCase{
+		{
+			// docker bridge
+			line:                        "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] DOCKER-FWD: IN=docker0 OUT=docker0 PHYSIN=veth1a2b3c4 PHYSOUT=veth5d6e7f8 MAC=02:42:ac:11:00:03:02:42:ac:11:00:02:08:00 SRC=172.17.0.2 DST=172.17.0.3 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=41234 DF PROTO=TCP SPT=45678 DPT=6379 WINDOW=64240 RES=0x00 SYN URGP=0",
+			expectedInputInterface:      "docker0",
+			expectedOutputInterface:     "docker0",
+			expectedPhysInputInterface:  "veth1a2b3c4",
+			expectedPhysOutputInterface: "veth5d6e7f8",
+			expectedMACAddress:          "02:42:ac:11:00:03:02:42:ac:11:00:02:08:00",
+		},
+		{
+			// only the physical input interface
+			line:                        "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] IN=br0 OUT= PHYSIN=eth1 MAC=02:42:ac:11:00:03:02:42:ac:11:00:02:08:00 SRC=192.168.1.10 DST=192.168.1.1 LEN=84 TOS=0x00 PREC=0x00 TTL=64 ID=1 DF PROTO=ICMP TYPE=8 CODE=0 ID=1 SEQ=1",
+			expectedInputInterface:      "br0",
+			expectedOutputInterface:     "",
+			expectedPhysInputInterface:  "eth1",
+			expectedPhysOutputInterface: "",
+			expectedMACAddress:          "02:42:ac:11:00:03:02:42:ac:11:00:02:08:00",
+		},
+	}
+
+	for _, testCase := range testCases {
+		parsedLog, err := Parse(testCase.line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, testCase.expectedInputInterface, parsedLog.InputInterface)
+		assert.Equal(t, testCase.expectedOutputInterface, parsedLog.OutputInterface)
+		assert.Equal(t, testCase.expectedPhysInputInterface, parsedLog.PhysInputInterface)
+		assert.Equal(t, testCase.expectedPhysOutputInterface, parsedLog.PhysOutputInterface)
+		assert.Equal(t, testCase.expectedMACAddress, parsedLog.MACAddress)
+	}
+}
+
+func TestParse_ExtraFields(t *testing.T) {
+	type TestCase struct {
+		line          string
+		expectedExtra map[string]string
+	}
+
+	testCases := []*TestCase{
+		{
+			line: "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=64 TOS=0x00 PREC=0x00 TTL=64 ID=40000 PROTO=UDP SPT=40000 DPT=53 LEN=44 CT=NEW VENDOR_TAG=abc=def EMPTY=",
+			expectedExtra: map[string]string{
+				"CT":         "NEW",
+				"VENDOR_TAG": "abc=def",
+				"EMPTY":      "",
+			},
+		},
+		{
+			// KEY=VALUE in the prefix is not a field
+			line:          "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] rule=42 IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=64 TOS=0x00 PREC=0x00 TTL=64 ID=40000 PROTO=UDP SPT=40000 DPT=53 LEN=44 UID=0 GID=0 MARK=0x1",
+			expectedExtra: nil,
+		},
+	}
+
+	for _, testCase := range testCases {
+		parsedLog, err := Parse(testCase.line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, testCase.expectedExtra, parsedLog.Extra)
+	}
+}
+
+func TestNewParser(t *testing.T) {
+	line := "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=64 TOS=0x00 PREC=0x00 TTL=64 ID=40000 PROTO=UDP SPT=40000 DPT=53 LEN=44 CT=NEW"
+
+	p, err := NewParser(WithYear(2022), WithLocation(time.UTC), WithStrict(true), WithExtraFields(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsedLog, err := p.Parse(line)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, time.Date(2022, time.July, 21, 5, 31, 48, 0, time.UTC), parsedLog.TimestampParsed)
+	assert.Nil(t, parsedLog.Extra)
+
+	logs, errs := p.ParseLines([]string{line})
+	assert.NoError(t, errs[0])
+	assert.Equal(t, time.Date(2022, time.July, 21, 5, 31, 48, 0, time.UTC), logs[0].TimestampParsed)
+
+	_, err = p.Parse("yesterday ubuntu-jammy kernel: [14479.122228] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=64 TOS=0x00 PREC=0x00 TTL=64 ID=40000 PROTO=UDP SPT=40000 DPT=53 LEN=44")
+	assert.True(t, errors.Is(err, ErrTimestampParseFailed))
+}
+
+func TestNewParser_InvalidOption(t *testing.T) {
+	p, err := NewParser(WithLocation(nil))
+	assert.Nil(t, p)
+	assert.Error(t, err)
+}
+
+// parseBenchmarkLines are the representative lines for the benchmarks, i.e. the baseline of ns/op and allocs/op; run them with "go test -bench . -benchmem".
+var parseBenchmarkLines = []struct {
+	name string
+	line string
+}{
+	{
+		name: "TCP",
+		line: "2022-07-12T09:01:27.345918+00:00 ubuntu-jammy kernel: [ 1269.733882] IN=enp0s3 OUT= MAC=00:b3:dd:bc:29:e1:52:54:00:12:35:02:08:00 SRC=93.184.216.34 DST=10.0.2.15 LEN=44 TOS=0x00 PREC=0x00 TTL=64 ID=15989 PROTO=TCP SPT=80 DPT=54830 SEQ=153856001 ACK=1134538652 WINDOW=65535 RES=0x00 ACK SYN URGP=0 OPT (020405B4)",
+	},
+	{
+		name: "UDP",
+		line: "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] [UFW BLOCK] IN=enp0s3 OUT= MAC=01:00:5e:00:00:fb:52:54:00:12:35:02:08:00 SRC=10.0.2.2 DST=224.0.0.251 LEN=73 TOS=0x00 PREC=0x00 TTL=255 ID=13926 DF PROTO=UDP SPT=5353 DPT=5353 LEN=53",
+	},
+	{
+		name: "ICMP",
+		line: "Jul 21 05:38:28 ubuntu-jammy kernel: [14879.600492] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=84 TOS=0x00 PREC=0x00 TTL=64 ID=6495 DF PROTO=ICMP TYPE=8 CODE=0 ID=1 SEQ=3",
+	},
+	{
+		name: "IPv6",
+		line: "Jul 21 05:38:28 ubuntu-jammy kernel: [14879.600492] IN=enp0s3 OUT= MAC=33:33:00:00:00:01:52:54:00:12:35:02:86:dd SRC=fe80:0000:0000:0000:5054:00ff:fe12:3502 DST=2001:0db8:0000:0000:0000:0000:0000:0001 LEN=72 TC=0 HOPLIMIT=64 FLOWLBL=327680 PROTO=TCP SPT=443 DPT=40000 WINDOW=64240 RES=0x00 ACK PSH URGP=0",
+	},
+}
+
+func BenchmarkParse(b *testing.B) {
+	for _, benchmarkLine := range parseBenchmarkLines {
+		b.Run(benchmarkLine.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := Parse(benchmarkLine.line); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestParse_ReorderedFields(t *testing.T) {
+	ordered, err := Parse("Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=93.184.216.34 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=64125 DF PROTO=TCP SPT=54832 DPT=80 SEQ=567002889 ACK=0 WINDOW=64240 RES=0x00 SYN URGP=0 UID=1000 GID=1000 MARK=0x1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reordered, err := Parse("Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] OUT-LOG: IN= OUT=enp0s3 DST=93.184.216.34 SRC=10.0.2.15 LEN=60 TTL=64 TOS=0x00 DF ID=64125 PREC=0x00 PROTO=TCP DPT=80 SPT=54832 WINDOW=64240 SEQ=567002889 ACK=0 SYN RES=0x00 MARK=0x1 URGP=0 GID=1000 UID=1000")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, ordered, reordered)
+}
+
+func TestParse_Unmatched(t *testing.T) {
+	lines := []string{
+		"",
+		"this is not an iptables log",
+		// missing OUT
+		"Jul 21 05:31:48 ubuntu-jammy kernel: [14879.600492] IN= SRC=10.0.2.15 DST=8.8.8.8 LEN=84 TOS=0x00 PREC=0x00 TTL=64 ID=6495 DF PROTO=ICMP TYPE=8 CODE=0 ID=1 SEQ=3",
+		// missing SRC
+		"Jul 21 05:31:48 ubuntu-jammy kernel: [14879.600492] IN= OUT=enp0s3 DST=8.8.8.8 LEN=84 TOS=0x00 PREC=0x00 TTL=64 ID=6495 DF PROTO=ICMP TYPE=8 CODE=0 ID=1 SEQ=3",
+		// missing PROTO
+		"Jul 21 05:31:48 ubuntu-jammy kernel: [14879.600492] IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=84 TOS=0x00 PREC=0x00 TTL=64 ID=6495 DF",
+	}
+
+	for _, line := range lines {
+		_, err := Parse(line)
+		assert.True(t, errors.Is(err, ErrLogFormatUnmatched), line)
+	}
+}
+
+func TestParseInto(t *testing.T) {
+	tcpLine := "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=93.184.216.34 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=64125 DF PROTO=TCP SPT=54832 DPT=80 SEQ=567002889 ACK=0 WINDOW=64240 RES=0x00 SYN URGP=0 OPT (020405B40402080A12A016080000000001030307) UID=1000 GID=1000 MARK=0x1 CT=NEW"
+	icmpLine := "Jul 21 05:38:28 ubuntu-jammy kernel: [14879.600492] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=84 TOS=0x00 PREC=0x00 TTL=64 ID=6495 DF PROTO=ICMP TYPE=8 CODE=0 ID=1 SEQ=3"
+
+	var l Log
+	if err := ParseInto(tcpLine, &l); err != nil {
+		t.Fatal(err)
+	}
+	expected, err := Parse(tcpLine)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, expected, &l)
+
+	// stale data of the TCP line must not leak
+	if err := ParseInto(icmpLine, &l); err != nil {
+		t.Fatal(err)
+	}
+	expected, err = Parse(icmpLine)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, expected, &l)
+
+	err = ParseInto("this is not an iptables log", &l)
+	assert.True(t, errors.Is(err, ErrLogFormatUnmatched))
+}
+
+func BenchmarkParseInto(b *testing.B) {
+	for _, benchmarkLine := range parseBenchmarkLines {
+		b.Run(benchmarkLine.name, func(b *testing.B) {
+			b.ReportAllocs()
+			var l Log
+			for i := 0; i < b.N; i++ {
+				if err := ParseInto(benchmarkLine.line, &l); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestParseBytes(t *testing.T) {
+	line := []byte("Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=93.184.216.34 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=64125 DF PROTO=TCP SPT=54832 DPT=80 SEQ=567002889 ACK=0 WINDOW=64240 RES=0x00 SYN URGP=0")
+
+	expected, err := Parse(string(line))
+	assert.NoError(t, err)
+
+	parsedLog, err := ParseBytes(line)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, parsedLog)
+
+	// the string fields of the parsed log refer to the given bytes without copying
+	lineStart := uintptr(unsafe.Pointer(unsafe.SliceData(line)))
+	for _, s := range []string{parsedLog.Timestamp, parsedLog.Hostname, parsedLog.Prefix, parsedLog.Source, parsedLog.Protocol} {
+		p := uintptr(unsafe.Pointer(unsafe.StringData(s)))
+		assert.True(t, lineStart <= p && p < lineStart+uintptr(len(line)), s)
+	}
+
+	var l Log
+	assert.NoError(t, ParseBytesInto([]byte(expected.Format()), &l))
+	assert.Equal(t, "93.184.216.34", l.Destination)
+
+	_, err = ParseBytes([]byte("this is not an iptables log"))
+	assert.ErrorIs(t, err, ErrLogFormatUnmatched)
+}
+
+func BenchmarkParseBytes(b *testing.B) {
+	line := []byte(parseBenchmarkLines[0].line)
+
+	b.Run("bytes", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := ParseBytes(line); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("string", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := Parse(string(line)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestParse_UnmatchedError(t *testing.T) {
+	type TestCase struct {
+		line           string
+		expectedReason string
+	}
+
+	testCases := []*TestCase{
+		{
+			line:           "this is not an iptables log",
+			expectedReason: "syslog preamble (i.e. timestamp, hostname, tag, and kernel timestamp) is not found",
+		},
+		{
+			line:           "Jul 21 05:31:48 ubuntu-jammy kernel: [14879.600492] IN= SRC=10.0.2.15 DST=8.8.8.8 LEN=84 TOS=0x00 PREC=0x00 TTL=64 ID=6495 DF PROTO=ICMP TYPE=8 CODE=0 ID=1 SEQ=3",
+			expectedReason: "IN= and OUT= are not found",
+		},
+		{
+			line:           "Jul 21 05:31:48 ubuntu-jammy kernel: [14879.600492] IN= OUT=enp0s3 DST=8.8.8.8 TOS=0x00 PREC=0x00 TTL=64 ID=6495 DF PROTO=ICMP TYPE=8 CODE=0 ID=1 SEQ=3",
+			expectedReason: "missing mandatory fields: SRC, LEN",
+		},
+		{
+			// the inner packet of ICMP error
+			line:           "Jul 21 05:31:48 ubuntu-jammy kernel: [14879.600492] IN=enp0s3 OUT= SRC=10.0.2.2 DST=10.0.2.15 LEN=84 TOS=0x00 PREC=0x00 TTL=64 ID=6495 PROTO=ICMP TYPE=3 CODE=3 [SRC=10.0.2.15 LEN=56 PROTO=UDP ]",
+			expectedReason: "missing mandatory fields: DST",
+		},
+	}
+
+	for _, testCase := range testCases {
+		_, err := Parse(testCase.line)
+		assert.True(t, errors.Is(err, ErrLogFormatUnmatched))
+
+		var unmatchedErr *UnmatchedError
+		if !errors.As(err, &unmatchedErr) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		assert.Equal(t, testCase.line, unmatchedErr.Line)
+		assert.Equal(t, testCase.expectedReason, unmatchedErr.Reason)
+		assert.Contains(t, err.Error(), "reason = "+testCase.expectedReason)
+	}
+}
+
+func TestUnmatchedError_TruncatedLine(t *testing.T) {
+	line := strings.Repeat("x", 1000)
+
+	err := &UnmatchedError{Line: line, Reason: "IN= and OUT= are not found"}
+	assert.Contains(t, err.Error(), `line = "`+strings.Repeat("x", maxUnmatchedLineLen)+`..."`)
+	assert.NotContains(t, err.Error(), strings.Repeat("x", maxUnmatchedLineLen+1))
+}
+
+func TestParse_FieldConversionError(t *testing.T) {
+	type TestCase struct {
+		line          string
+		expectedField string
+		expectedValue string
+	}
+
+	testCases := []*TestCase{
+		{
+			line:          "Jul 21 05:38:28 ubuntu-jammy kernel: [14879.600492] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=84 TOS=0x00 PREC=0x00 TTL=25x ID=6495 DF PROTO=ICMP TYPE=8 CODE=0 ID=1 SEQ=3",
+			expectedField: "ttl",
+			expectedValue: "25x",
+		},
+		{
+			line:          "Jul 21 05:38:28 ubuntu-jammy kernel: [14879.600492] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=84 TOS=0xZZ PREC=0x00 TTL=64 ID=6495 DF PROTO=ICMP TYPE=8 CODE=0 ID=1 SEQ=3",
+			expectedField: "tos",
+			expectedValue: "0xZZ",
+		},
+		{
+			line:          "Jul 21 05:38:28 ubuntu-jammy kernel: [14879.600492] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=84 TOS=0x00 PREC=C0 TTL=64 ID=6495 DF PROTO=ICMP TYPE=8 CODE=0 ID=1 SEQ=3",
+			expectedField: "prec",
+			expectedValue: "C0",
+		},
+		{
+			line:          "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=93.184.216.34 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=64125 DF PROTO=TCP SPT=54832 DPT=65536 WINDOW=64240 RES=0x00 SYN URGP=0",
+			expectedField: "dpt",
+			expectedValue: "65536",
+		},
+		{
+			line:          "Jul 21 05:38:28 ubuntu-jammy kernel: [14879.6a] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=84 TOS=0x00 PREC=0x00 TTL=64 ID=6495 DF PROTO=ICMP TYPE=8 CODE=0 ID=1 SEQ=3",
+			expectedField: "kernel-timestamp",
+			expectedValue: "14879.6a",
+		},
+	}
+
+	for _, testCase := range testCases {
+		_, err := Parse(testCase.line)
+		assert.True(t, errors.Is(err, ErrStringToNumberConversionFailed))
+
+		var convErr *FieldConversionError
+		if !errors.As(err, &convErr) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		assert.Equal(t, testCase.expectedField, convErr.Field)
+		assert.Equal(t, testCase.expectedValue, convErr.Value)
+		assert.Contains(t, err.Error(), "field = "+testCase.expectedField+`, value = "`+testCase.expectedValue+`"`)
+	}
+}
+
+func TestParse_MinimalSystems(t *testing.T) {
+	type TestCase struct {
+		line                    string
+		expectedHostname        string
+		expectedKernelTimestamp float64
+		expectedPrefix          string
+		expectedHasPriority     bool
+		expectedFacility        uint8
+		expectedSeverity        uint8
+	}
+
+	testCases := []*TestCase{
+		{
+			// WSL2 with rsyslog
+			line:                    "Jul 21 05:31:48 DESKTOP-7H2K9QF kernel: [  123.456789] [UFW BLOCK] IN=eth0 OUT= MAC=00:15:5d:3a:1b:2c:00:15:5d:3a:1b:2d:08:00 SRC=172.28.0.1 DST=172.28.10.5 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=4242 DF PROTO=TCP SPT=51234 DPT=22 WINDOW=64240 RES=0x00 SYN URGP=0",
+			expectedHostname:        "DESKTOP-7H2K9QF",
+			expectedKernelTimestamp: 123.456789,
+			expectedPrefix:          "[UFW BLOCK]",
+		},
+		{
+			// the printk caller ID follows the kernel timestamp
+			line:                    "Jul 21 05:31:48 DESKTOP-7H2K9QF kernel: [  123.456789][    T1] [UFW BLOCK] IN=eth0 OUT= MAC=00:15:5d:3a:1b:2c:00:15:5d:3a:1b:2d:08:00 SRC=172.28.0.1 DST=172.28.10.5 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=4242 DF PROTO=TCP SPT=51234 DPT=22 WINDOW=64240 RES=0x00 SYN URGP=0",
+			expectedHostname:        "DESKTOP-7H2K9QF",
+			expectedKernelTimestamp: 123.456789,
+			expectedPrefix:          "[UFW BLOCK]",
+		},
+		{
+			line:                    "Jul 21 05:31:48 DESKTOP-7H2K9QF kernel: [  123.456789][ C0] IN=eth0 OUT= MAC=00:15:5d:3a:1b:2c:00:15:5d:3a:1b:2d:08:00 SRC=172.28.0.1 DST=172.28.10.5 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=4242 DF PROTO=TCP SPT=51234 DPT=22 WINDOW=64240 RES=0x00 SYN URGP=0",
+			expectedHostname:        "DESKTOP-7H2K9QF",
+			expectedKernelTimestamp: 123.456789,
+		},
+		{
+			// BusyBox syslogd writes the facility marker
+			line:                    "Jul 21 05:31:48 OpenWrt kern.warn kernel: [ 1234.567890] DROP wan in: IN=eth1 OUT= MAC=00:15:5d:3a:1b:2c:00:15:5d:3a:1b:2d:08:00 SRC=198.51.100.7 DST=192.0.2.1 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=4242 DF PROTO=TCP SPT=51234 DPT=22 WINDOW=64240 RES=0x00 SYN URGP=0",
+			expectedHostname:        "OpenWrt",
+			expectedKernelTimestamp: 1234.56789,
+			expectedPrefix:          "DROP wan in:",
+			expectedHasPriority:     true,
+			expectedFacility:        0,
+			expectedSeverity:        4,
+		},
+		{
+			line:                    "Jul 21 05:31:48 router.example local4.info kernel: [ 1234.567890] IN=eth1 OUT= MAC=00:15:5d:3a:1b:2c:00:15:5d:3a:1b:2d:08:00 SRC=198.51.100.7 DST=192.0.2.1 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=4242 DF PROTO=TCP SPT=51234 DPT=22 WINDOW=64240 RES=0x00 SYN URGP=0",
+			expectedHostname:        "router.example",
+			expectedKernelTimestamp: 1234.56789,
+			expectedHasPriority:     true,
+			expectedFacility:        20,
+			expectedSeverity:        6,
+		},
+		{
+			// without the kernel timestamp
+			line:                "Jul 21 05:31:48 OpenWrt kern.warning kernel: DROP wan in: IN=eth1 OUT= MAC=00:15:5d:3a:1b:2c:00:15:5d:3a:1b:2d:08:00 SRC=198.51.100.7 DST=192.0.2.1 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=4242 DF PROTO=TCP SPT=51234 DPT=22 WINDOW=64240 RES=0x00 SYN URGP=0",
+			expectedHostname:    "OpenWrt",
+			expectedPrefix:      "DROP wan in:",
+			expectedHasPriority: true,
+			expectedFacility:    0,
+			expectedSeverity:    4,
+		},
+		{
+			// the hostname that contains a dot is not mistaken for the facility marker
+			line:                    "Jul 21 05:31:48 kern.example kernel: [ 1234.567890] IN=eth1 OUT= MAC=00:15:5d:3a:1b:2c:00:15:5d:3a:1b:2d:08:00 SRC=198.51.100.7 DST=192.0.2.1 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=4242 DF PROTO=TCP SPT=51234 DPT=22 WINDOW=64240 RES=0x00 SYN URGP=0",
+			expectedHostname:        "kern.example",
+			expectedKernelTimestamp: 1234.56789,
+		},
+	}
+
+	for _, testCase := range testCases {
+		parsedLog, err := Parse(testCase.line)
+		if err != nil {
+			t.Fatalf("%s: %v", testCase.line, err)
+		}
+		assert.Equal(t, "Jul 21 05:31:48", parsedLog.Timestamp, testCase.line)
+		assert.Equal(t, testCase.expectedHostname, parsedLog.Hostname, testCase.line)
+		assert.Equal(t, testCase.expectedKernelTimestamp, parsedLog.KernelTimestamp, testCase.line)
+		assert.Equal(t, testCase.expectedPrefix, parsedLog.Prefix, testCase.line)
+		assert.Equal(t, testCase.expectedHasPriority, parsedLog.HasPriority, testCase.line)
+		assert.Equal(t, testCase.expectedHasPriority, parsedLog.Has(FieldFacility), testCase.line)
+		assert.Equal(t, testCase.expectedFacility, parsedLog.Facility, testCase.line)
+		assert.Equal(t, testCase.expectedSeverity, parsedLog.Severity, testCase.line)
+		assert.Equal(t, "TCP", parsedLog.Protocol, testCase.line)
+		assert.Equal(t, uint16(22), parsedLog.DestinationPort, testCase.line)
+	}
+
+	// the syslog priority takes precedence over the facility marker
+	parsedLog, err := ParseWithOptions("<134>Jul 21 05:31:48 OpenWrt kern.warn kernel: [ 1234.567890] IN=eth1 OUT= SRC=198.51.100.7 DST=192.0.2.1 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=4242 DF PROTO=TCP SPT=51234 DPT=22 WINDOW=64240 RES=0x00 SYN URGP=0", WithPriority(true))
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(16), parsedLog.Facility)
+	assert.Equal(t, uint8(6), parsedLog.Severity)
+}
+
+func TestParse_WithCollectAllErrors(t *testing.T) {
+	type TestCase struct {
+		line           string
+		opts           []Option
+		expectedFields []string
+		expectedErr    error
+		expectedLog    bool
+		expectedInner  bool
+	}
+
+	testCases := []*TestCase{
+		{
+			line:           "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.12x] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=93.184.216.34 LEN=60 TOS=0xZZ PREC=0x00 TTL=25x ID=64125 DF PROTO=TCP SPT=54832 DPT=65536 WINDOW=64240 RES=0x00 SYN URGP=0",
+			expectedFields: []string{"kernel-timestamp", "tos", "ttl", "dpt"},
+			expectedErr:    ErrStringToNumberConversionFailed,
+			expectedLog:    true,
+		},
+		{
+			line:           "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] IN= OUT=enp0s3 SRC=10.0.2.15 DST=93.184.216.34 TOS=0x00 PREC=0x00 TTL=6A ID=64125 DF PROTO=TCP SPT=54832 DPT=80",
+			expectedFields: []string{"ttl"},
+			expectedErr:    ErrLogFormatUnmatched, // missing LEN
+			expectedLog:    true,
+		},
+		{
+			line:           "Foo 21 05:31:48 ubuntu-jammy kernel: [14479.122228] IN= OUT=enp0s3 SRC=10.0.2.15 DST=93.184.216.34 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=64125 DF PROTO=TCP SPT=54832 DPT=8O",
+			opts:           []Option{WithStrict(true)},
+			expectedFields: []string{"dpt"},
+			expectedErr:    ErrTimestampParseFailed,
+			expectedLog:    true,
+		},
+		{
+			line:           "<1a>Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] IN= OUT=enp0s3 SRC=10.0.2.15 DST=93.184.216.34 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=64125 DF PROTO=TCP SPT=54832 DPT=8O",
+			opts:           []Option{WithPriority(true)},
+			expectedFields: []string{"pri", "dpt"},
+			expectedErr:    ErrStringToNumberConversionFailed,
+			expectedLog:    true,
+		},
+		{
+			// the failures of the inner packet are collected as well
+			line:           "Jul 21 05:38:28 ubuntu-jammy kernel: [14879.600492] IN=enp0s3 OUT= SRC=192.0.2.1 DST=10.0.2.15 LEN=576 TOS=0x00 PREC=0xC0 TTL=254 ID=39147 PROTO=ICMP TYPE=3 CODE=3 [SRC=10.0.2.15 DST=198.51.100.7 LEN=5x TOS=0x00 PREC=0x00 TTL=64 ID=12345 PROTO=UDP SPT=40000 DPT=53 LEN=36 ] MARK=0xZ",
+			expectedFields: []string{"len", "mark"},
+			expectedErr:    ErrStringToNumberConversionFailed,
+			expectedLog:    true,
+			expectedInner:  true,
+		},
+		{
+			// no fields to attempt
+			line:        "this is not an iptables log",
+			expectedErr: ErrLogFormatUnmatched,
+		},
+	}
+
+	for _, testCase := range testCases {
+		parsedLog, err := ParseWithOptions(testCase.line, append(testCase.opts, WithCollectAllErrors(true))...)
+		assert.ErrorIs(t, err, testCase.expectedErr, testCase.line)
+
+		var fields []string
+		var collect func(err error)
+		collect = func(err error) {
+			if joined, ok := err.(interface{ Unwrap() []error }); ok {
+				for _, e := range joined.Unwrap() {
+					collect(e)
+				}
+				return
+			}
+			var convErr *FieldConversionError
+			if errors.As(err, &convErr) {
+				fields = append(fields, convErr.Field)
+			}
+		}
+		collect(err)
+		assert.Equal(t, testCase.expectedFields, fields, testCase.line)
+
+		if !testCase.expectedLog {
+			assert.Nil(t, parsedLog)
+			continue
+		}
+		// the log is populated as far as possible
+		if !assert.NotNil(t, parsedLog) {
+			continue
+		}
+		assert.True(t, parsedLog.Has(FieldSource))
+		assert.True(t, parsedLog.Has(FieldProtocol))
+		assert.True(t, parsedLog.Has(FieldID))
+		assert.Equal(t, testCase.expectedInner, parsedLog.Inner != nil)
+	}
+}
+
+func TestParse_WithCollectAllErrors_FailFast(t *testing.T) {
+	line := "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=93.184.216.34 LEN=60 TOS=0xZZ PREC=0x00 TTL=25x ID=64125 DF PROTO=TCP SPT=54832 DPT=65536 WINDOW=64240 RES=0x00 SYN URGP=0"
+
+	// the first failure is returned by default
+	parsedLog, err := Parse(line)
+	assert.Nil(t, parsedLog)
+	var convErr *FieldConversionError
+	if !errors.As(err, &convErr) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assert.Equal(t, convErr, err)
+	assert.Equal(t, "tos", convErr.Field)
+
+	// the valid line is not affected
+	validLine := "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=93.184.216.34 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=64125 DF PROTO=TCP SPT=54832 DPT=80 WINDOW=64240 RES=0x00 SYN URGP=0"
+	expected, err := Parse(validLine)
+	assert.NoError(t, err)
+	parsedLog, err = ParseWithOptions(validLine, WithCollectAllErrors(true))
+	assert.NoError(t, err)
+	assert.Equal(t, expected, parsedLog)
+}
+
+func TestParse_IPsec(t *testing.T) {
+	type TestCase struct {
+		line                  string
+		expectedProtocol      string
+		expectedSPI           uint32
+		expectedIPsecSequence uint32
+	}
+
+	testCases := []*TestCase{
+		{
+			line:                  "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] IPSEC-IN: IN=enp0s3 OUT= MAC=00:b3:dd:bc:29:e1:52:54:00:12:35:02:08:00 SRC=198.51.100.1 DST=10.0.2.15 LEN=152 TOS=0x00 PREC=0x00 TTL=57 ID=0 DF PROTO=ESP SPI=0xc1a2b3d4",
+			expectedProtocol:      "ESP",
+			expectedSPI:           0xc1a2b3d4,
+			expectedIPsecSequence: 0,
+		},
+		{
+			line:                  "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] IPSEC-IN: IN=enp0s3 OUT= SRC=198.51.100.1 DST=10.0.2.15 LEN=152 TOS=0x00 PREC=0x00 TTL=57 ID=0 DF PROTO=ESP SPI=0x1000 SEQ=42",
+			expectedProtocol:      "ESP",
+			expectedSPI:           0x1000,
+			expectedIPsecSequence: 42,
+		},
+		{
+			line:                  "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] IPSEC-OUT: IN= OUT=enp0s3 SRC=10.0.2.15 DST=198.51.100.1 LEN=124 TOS=0x00 PREC=0x00 TTL=64 ID=4242 PROTO=AH SPI=0x200 SEQ=7",
+			expectedProtocol:      "AH",
+			expectedSPI:           0x200,
+			expectedIPsecSequence: 7,
+		},
+	}
+
+	for _, testCase := range testCases {
+		parsedLog, err := Parse(testCase.line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, testCase.expectedProtocol, parsedLog.Protocol)
+		assert.Equal(t, testCase.expectedSPI, parsedLog.SPI)
+		assert.Equal(t, testCase.expectedIPsecSequence, parsedLog.IPsecSequence)
+		assert.Equal(t, uint64(0), parsedLog.Sequence)
+		assert.Nil(t, parsedLog.Extra)
+	}
+}
+
+func TestParse_SCTP(t *testing.T) {
+	parsedLog, err := Parse("Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] SCTP-IN: IN=enp0s3 OUT= MAC=00:b3:dd:bc:29:e1:52:54:00:12:35:02:08:00 SRC=198.51.100.1 DST=10.0.2.15 LEN=100 TOS=0x02 PREC=0x00 TTL=64 ID=0 DF PROTO=SCTP SPT=36412 DPT=2905")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "SCTP", parsedLog.Protocol)
+	assert.Equal(t, uint16(36412), parsedLog.SourcePort)
+	assert.Equal(t, uint16(2905), parsedLog.DestinationPort)
+	assert.Equal(t, uint8(2), parsedLog.ToS)
+	assert.True(t, parsedLog.DoNotFragment)
+	assert.Equal(t, []string{}, parsedLog.TCPFlags())
+
+	// the bare tokens that look like TCP flags must not be regarded as flags for SCTP
+	parsedLog, err = Parse("Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] SCTP-IN: IN=enp0s3 OUT= SRC=198.51.100.1 DST=10.0.2.15 LEN=100 TOS=0x02 PREC=0x00 TTL=64 ID=0 DF PROTO=SCTP SPT=36412 DPT=2905 ACK SYN")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, uint16(2905), parsedLog.DestinationPort)
+	assert.Equal(t, []string{}, parsedLog.TCPFlags())
+}
+
+func TestParse_UDPLite(t *testing.T) {
+	parsedLog, err := Parse("Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] UDPLITE-IN: IN=enp0s3 OUT= SRC=198.51.100.1 DST=10.0.2.15 LEN=1228 TOS=0x00 PREC=0x00 TTL=64 ID=4321 DF PROTO=UDPLITE SPT=5004 DPT=5005 LEN=8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "UDPLITE", parsedLog.Protocol)
+	assert.Equal(t, uint64(1228), parsedLog.Length)
+	assert.Equal(t, uint16(5004), parsedLog.SourcePort)
+	assert.Equal(t, uint16(5005), parsedLog.DestinationPort)
+	assert.Equal(t, uint16(8), parsedLog.ChecksumCoverage)
+	assert.True(t, parsedLog.Has(FieldChecksumCoverage))
+	assert.Equal(t, "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] UDPLITE-IN: IN=enp0s3 OUT= SRC=198.51.100.1 DST=10.0.2.15 LEN=1228 TOS=0x00 PREC=0x00 TTL=64 ID=4321 DF PROTO=UDPLITE SPT=5004 DPT=5005 LEN=8", parsedLog.Format())
+
+	// the length of UDP is not the checksum coverage
+	parsedLog, err = Parse("Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] UDP-IN: IN=enp0s3 OUT= SRC=198.51.100.1 DST=10.0.2.15 LEN=1228 TOS=0x00 PREC=0x00 TTL=64 ID=4321 DF PROTO=UDP SPT=5004 DPT=5005 LEN=1208")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, uint16(0), parsedLog.ChecksumCoverage)
+	assert.False(t, parsedLog.Has(FieldChecksumCoverage))
+}
+
+func TestParse_GRE(t *testing.T) {
+	type TestCase struct {
+		line                string
+		expectedGREKey      uint32
+		expectedGREProtocol uint16
+		expectedPresent     bool
+	}
+
+	testCases := []*TestCase{
+		{
+			line:                "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] PPTP-IN: IN=enp0s3 OUT= SRC=198.51.100.1 DST=10.0.2.15 LEN=72 TOS=0x00 PREC=0x00 TTL=64 ID=4321 PROTO=GRE KEY=0x2a PROTO=0x880b",
+			expectedGREKey:      0x2a,
+			expectedGREProtocol: 0x880b,
+			expectedPresent:     true,
+		},
+		{
+			// the protocol number, and the decimal values
+			line:                "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] PPTP-IN: IN=enp0s3 OUT= SRC=198.51.100.1 DST=10.0.2.15 LEN=72 TOS=0x00 PREC=0x00 TTL=64 ID=4321 PROTO=47 KEY=42 PROTO=34827",
+			expectedGREKey:      42,
+			expectedGREProtocol: 0x880b,
+			expectedPresent:     true,
+		},
+		{
+			// the kernel logs nothing beyond the IP header by default
+			line:            "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] PPTP-IN: IN=enp0s3 OUT= SRC=198.51.100.1 DST=10.0.2.15 LEN=72 TOS=0x00 PREC=0x00 TTL=64 ID=4321 PROTO=GRE MARK=0x1",
+			expectedPresent: false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		parsedLog, err := Parse(testCase.line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.True(t, parsedLog.isGRE(), testCase.line)
+		assert.Equal(t, testCase.expectedGREKey, parsedLog.GREKey, testCase.line)
+		assert.Equal(t, testCase.expectedGREProtocol, parsedLog.GREProtocol, testCase.line)
+		assert.Equal(t, testCase.expectedPresent, parsedLog.Has(FieldGREKey), testCase.line)
+		assert.Equal(t, testCase.expectedPresent, parsedLog.Has(FieldGREProtocol), testCase.line)
+
+		reparsedLog, err := Parse(parsedLog.Format())
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Empty(t, Diff(parsedLog, reparsedLog), testCase.line)
+	}
+}
+
+func TestParse_Incomplete(t *testing.T) {
+	line := "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] IN-LOG: IN=enp0s3 OUT= SRC=198.51.100.1 DST=10.0.2.15 LEN=40 TOS=0x00 PREC=0x00 TTL=64 ID=4321 MF PROTO=TCP INCOMPLETE [12 bytes]"
+	parsedLog, err := Parse(line)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "TCP", parsedLog.Protocol)
+	assert.True(t, parsedLog.Incomplete)
+	assert.Equal(t, uint64(12), parsedLog.IncompleteBytes)
+	assert.True(t, parsedLog.Has(FieldIncompleteBytes))
+	assert.False(t, parsedLog.Has(FieldSourcePort))
+	assert.Equal(t, line, parsedLog.Format())
+
+	// the bare marker without the number of the bytes is not the truncation
+	parsedLog, err = Parse("Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] IN-LOG: IN=enp0s3 OUT= SRC=198.51.100.1 DST=10.0.2.15 LEN=40 TOS=0x00 PREC=0x00 TTL=64 ID=4321 PROTO=UDP INCOMPLETE MARK=0x1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.False(t, parsedLog.Incomplete)
+	assert.False(t, parsedLog.Has(FieldIncomplete))
+	assert.False(t, parsedLog.Has(FieldIncompleteBytes))
+	assert.Equal(t, uint32(1), parsedLog.Mark)
+
+	_, err = Parse("Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] IN-LOG: IN=enp0s3 OUT= SRC=198.51.100.1 DST=10.0.2.15 LEN=40 TOS=0x00 PREC=0x00 TTL=64 ID=4321 PROTO=TCP INCOMPLETE [x bytes]")
+	var convErr *FieldConversionError
+	assert.ErrorAs(t, err, &convErr)
+	assert.Equal(t, "incomplete", convErr.Field)
+}
+
+func TestParse_Tag(t *testing.T) {
+	type TestCase struct {
+		line             string
+		opts             []Option
+		expectedHostname string
+		expectedPrefix   string
+		expectedErr      error
+	}
+
+	testCases := []*TestCase{
+		{
+			// no tag
+			line:             "Jul 21 05:31:48 ubuntu-jammy [14479.122228] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=64 TOS=0x00 PREC=0x00 TTL=64 ID=40000 PROTO=UDP SPT=40000 DPT=53 LEN=44",
+			expectedHostname: "ubuntu-jammy",
+			expectedPrefix:   "OUT-LOG:",
+		},
+		{
+			// the other tag
+			line:             "Jul 21 05:31:48 container-1 iptables[123]: [14479.122228] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=64 TOS=0x00 PREC=0x00 TTL=64 ID=40000 PROTO=UDP SPT=40000 DPT=53 LEN=44",
+			expectedHostname: "container-1",
+			expectedPrefix:   "OUT-LOG:",
+		},
+		{
+			line:             "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=64 TOS=0x00 PREC=0x00 TTL=64 ID=40000 PROTO=UDP SPT=40000 DPT=53 LEN=44",
+			opts:             []Option{WithTag("kernel")},
+			expectedHostname: "ubuntu-jammy",
+			expectedPrefix:   "OUT-LOG:",
+		},
+		{
+			line:        "Jul 21 05:31:48 container-1 iptables[123]: [14479.122228] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=64 TOS=0x00 PREC=0x00 TTL=64 ID=40000 PROTO=UDP SPT=40000 DPT=53 LEN=44",
+			opts:        []Option{WithTag("kernel")},
+			expectedErr: ErrLogFormatUnmatched,
+		},
+		{
+			line:             "Jul 21 05:31:48 ubuntu-jammy [14479.122228] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=64 TOS=0x00 PREC=0x00 TTL=64 ID=40000 PROTO=UDP SPT=40000 DPT=53 LEN=44",
+			opts:             []Option{WithTag("")},
+			expectedHostname: "ubuntu-jammy",
+			expectedPrefix:   "OUT-LOG:",
+		},
+		{
+			line:        "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=64 TOS=0x00 PREC=0x00 TTL=64 ID=40000 PROTO=UDP SPT=40000 DPT=53 LEN=44",
+			opts:        []Option{WithTag("")},
+			expectedErr: ErrLogFormatUnmatched,
+		},
+	}
+
+	for _, testCase := range testCases {
+		parsedLog, err := ParseWithOptions(testCase.line, testCase.opts...)
+		if testCase.expectedErr != nil {
+			assert.ErrorIs(t, err, testCase.expectedErr)
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, testCase.expectedHostname, parsedLog.Hostname)
+		assert.Equal(t, testCase.expectedPrefix, parsedLog.Prefix)
+		assert.Equal(t, 14479.122228, parsedLog.KernelTimestamp)
+	}
+
+	_, err := NewParser(WithTag("ker nel"))
+	assert.Error(t, err)
+}
+
+func TestParse_WithoutKernelTimestamp(t *testing.T) {
+	type TestCase struct {
+		line             string
+		opts             []Option
+		expectedHostname string
+		expectedPrefix   string
+		expectedErr      error
+	}
+
+	testCases := []*TestCase{
+		{
+			line:             "Jul 21 05:31:48 ubuntu-jammy kernel: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=84 TOS=0x00 PREC=0x00 TTL=64 ID=6495 DF PROTO=ICMP TYPE=8 CODE=0 ID=1 SEQ=3",
+			expectedHostname: "ubuntu-jammy",
+			expectedPrefix:   "",
+		},
+		{
+			line:             "Jul 21 05:31:48 ubuntu-jammy kernel: OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=84 TOS=0x00 PREC=0x00 TTL=64 ID=6495 DF PROTO=ICMP TYPE=8 CODE=0 ID=1 SEQ=3",
+			expectedHostname: "ubuntu-jammy",
+			expectedPrefix:   "OUT-LOG:",
+		},
+		{
+			line:             "2023-07-21T05:31:48.123456+09:00 ubuntu-jammy kernel: OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=84 TOS=0x00 PREC=0x00 TTL=64 ID=6495 DF PROTO=ICMP TYPE=8 CODE=0 ID=1 SEQ=3",
+			expectedHostname: "ubuntu-jammy",
+			expectedPrefix:   "OUT-LOG:",
+		},
+		{
+			line:             "Jul 21 05:31:48 ubuntu-jammy kernel: OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=84 TOS=0x00 PREC=0x00 TTL=64 ID=6495 DF PROTO=ICMP TYPE=8 CODE=0 ID=1 SEQ=3",
+			opts:             []Option{WithTag("kernel")},
+			expectedHostname: "ubuntu-jammy",
+			expectedPrefix:   "OUT-LOG:",
+		},
+		{
+			// the timestamp is not delimited by the kernel timestamp, so it must be in the known format
+			line:        "21/07/2023 05:31:48 ubuntu-jammy kernel: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=84 TOS=0x00 PREC=0x00 TTL=64 ID=6495 DF PROTO=ICMP TYPE=8 CODE=0 ID=1 SEQ=3",
+			expectedErr: ErrLogFormatUnmatched,
+		},
+	}
+
+	for _, testCase := range testCases {
+		parsedLog, err := ParseWithOptions(testCase.line, testCase.opts...)
+		if testCase.expectedErr != nil {
+			assert.ErrorIs(t, err, testCase.expectedErr)
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, testCase.expectedHostname, parsedLog.Hostname)
+		assert.Equal(t, testCase.expectedPrefix, parsedLog.Prefix)
+		assert.Zero(t, parsedLog.KernelTimestamp)
+		assert.False(t, parsedLog.Has(FieldKernelTimestamp))
+		assert.True(t, parsedLog.Has(FieldTimestamp))
+		assert.False(t, parsedLog.TimestampParsed.IsZero())
+		assert.Equal(t, "ICMP", parsedLog.Protocol)
+		assert.Equal(t, "10.0.2.15", parsedLog.Source)
+		assert.Equal(t, "8.8.8.8", parsedLog.Destination)
+		assert.Equal(t, uint64(6495), parsedLog.ID)
+	}
+}
+
+func TestParse_WithoutHostname(t *testing.T) {
+	type TestCase struct {
+		line              string
+		opts              []Option
+		expectedTimestamp string
+		expectedHostname  string
+		expectedHas       bool
+		expectedPrefix    string
+	}
+
+	testCases := []*TestCase{
+		{
+			line:              "Jul 20 13:24:22 kernel: [14479.122228] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=84 TOS=0x00 PREC=0x00 TTL=64 ID=6495 DF PROTO=ICMP TYPE=8 CODE=0 ID=1 SEQ=3",
+			expectedTimestamp: "Jul 20 13:24:22",
+			expectedPrefix:    "OUT-LOG:",
+		},
+		{
+			line:              "Jul  1 13:24:22.123456 [14479.122228] IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=84 TOS=0x00 PREC=0x00 TTL=64 ID=6495 DF PROTO=ICMP TYPE=8 CODE=0 ID=1 SEQ=3",
+			expectedTimestamp: "Jul  1 13:24:22.123456",
+		},
+		{
+			line:              "2022-07-20T13:24:22.123456+00:00 kernel: [14479.122228] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=84 TOS=0x00 PREC=0x00 TTL=64 ID=6495 DF PROTO=ICMP TYPE=8 CODE=0 ID=1 SEQ=3",
+			expectedTimestamp: "2022-07-20T13:24:22.123456+00:00",
+			expectedPrefix:    "OUT-LOG:",
+		},
+		{
+			line:              "Jul 20 13:24:22 kernel: [14479.122228] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=84 TOS=0x00 PREC=0x00 TTL=64 ID=6495 DF PROTO=ICMP TYPE=8 CODE=0 ID=1 SEQ=3",
+			opts:              []Option{WithTag("kernel")},
+			expectedTimestamp: "Jul 20 13:24:22",
+			expectedPrefix:    "OUT-LOG:",
+		},
+		{
+			// the hostname that looks like the time is still the hostname
+			line:              "Jul 20 13:24:22 ubuntu-jammy kernel: [14479.122228] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=84 TOS=0x00 PREC=0x00 TTL=64 ID=6495 DF PROTO=ICMP TYPE=8 CODE=0 ID=1 SEQ=3",
+			expectedTimestamp: "Jul 20 13:24:22",
+			expectedHostname:  "ubuntu-jammy",
+			expectedHas:       true,
+			expectedPrefix:    "OUT-LOG:",
+		},
+	}
+
+	for _, testCase := range testCases {
+		parsedLog, err := ParseWithOptions(testCase.line, append(testCase.opts, WithStrict(true))...)
+		if !assert.NoError(t, err, testCase.line) {
+			continue
+		}
+		assert.Equal(t, testCase.expectedTimestamp, parsedLog.Timestamp, testCase.line)
+		assert.False(t, parsedLog.TimestampParsed.IsZero(), testCase.line)
+		assert.Equal(t, testCase.expectedHostname, parsedLog.Hostname, testCase.line)
+		assert.Equal(t, testCase.expectedHas, parsedLog.Has(FieldHostname), testCase.line)
+		assert.Equal(t, testCase.expectedPrefix, parsedLog.Prefix, testCase.line)
+		assert.Equal(t, 14479.122228, parsedLog.KernelTimestamp, testCase.line)
+	}
+
+	// the timestamp of an unknown format is delimited by the hostname as before
+	parsedLog, err := Parse("21/07/2022 05:31:48 ubuntu-jammy kernel: [14479.122228] IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=84 TOS=0x00 PREC=0x00 TTL=64 ID=6495 DF PROTO=ICMP TYPE=8 CODE=0 ID=1 SEQ=3")
+	assert.NoError(t, err)
+	assert.Equal(t, "21/07/2022 05:31:48", parsedLog.Timestamp)
+	assert.Equal(t, "ubuntu-jammy", parsedLog.Hostname)
+	assert.True(t, parsedLog.TimestampParsed.IsZero())
+}
+
+func TestParse_TCPFlagOrder(t *testing.T) {
+	const lineFormat = "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=93.184.216.34 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=64125 DF PROTO=TCP SPT=54832 DPT=80 SEQ=567002889 ACK=0 WINDOW=64240 RES=0x00 %s URGP=0"
+
+	expected, err := Parse(fmt.Sprintf(lineFormat, "CWR ECE URG ACK PSH RST SYN FIN"))
+	assert.NoError(t, err)
+	for _, flag := range []bool{expected.CWR, expected.ECE, expected.Urgent, expected.Ack, expected.Push, expected.Reset, expected.Syn, expected.Fin} {
+		assert.True(t, flag)
+	}
+
+	testCases := []string{
+		"FIN SYN RST PSH ACK URG ECE CWR",
+		"SYN CWR ACK ECE FIN URG RST PSH",
+		"ECE CWR URG ACK PSH RST SYN FIN",
+		"URG ACK PSH RST SYN FIN CWR ECE",
+	}
+	for _, testCase := range testCases {
+		parsedLog, err := Parse(fmt.Sprintf(lineFormat, testCase))
+		assert.NoError(t, err, testCase)
+		assert.Equal(t, expected, parsedLog, testCase)
+	}
+
+	// the flags are also recognized after URGP
+	parsedLog, err := Parse(fmt.Sprintf(lineFormat, "ACK") + " SYN")
+	assert.NoError(t, err)
+	assert.True(t, parsedLog.Ack)
+	assert.True(t, parsedLog.Syn)
+}
+
+func TestParse_RetainRaw(t *testing.T) {
+	line := "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=64 TOS=0x00 PREC=0x00 TTL=64 ID=40000 PROTO=UDP SPT=40000 DPT=53"
+
+	parsedLog, err := Parse(line)
+	assert.NoError(t, err)
+	assert.Empty(t, parsedLog.Raw)
+
+	p, err := NewParser(WithRetainRaw(true))
+	assert.NoError(t, err)
+	parsedLog, err = p.Parse(line)
+	assert.NoError(t, err)
+	assert.Equal(t, line, parsedLog.Raw)
+
+	// the raw line is excluded from JSON
+	b, err := json.Marshal(parsedLog)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(b), "OUT-LOG: IN=")
+
+	// ParseInto overwrites the raw line of the reused Log
+	other := "Jul 21 05:31:49 ubuntu-jammy kernel: [14479.122229] IN=enp0s3 OUT= SRC=8.8.8.8 DST=10.0.2.15 LEN=64 TOS=0x00 PREC=0x00 TTL=64 ID=40001 PROTO=UDP SPT=53 DPT=40000"
+	assert.NoError(t, p.ParseInto(other, parsedLog))
+	assert.Equal(t, other, parsedLog.Raw)
+}
+
+func TestParse_CRLFAndStrayWhitespaces(t *testing.T) {
+	expected, err := Parse("Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=93.184.216.34 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=64125 DF PROTO=TCP SPT=54832 DPT=80 SEQ=567002889 ACK=0 WINDOW=64240 RES=0x00 SYN URGP=0 OPT (020405B40402080A)")
+	assert.NoError(t, err)
+
+	lines := []string{
+		"Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=93.184.216.34 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=64125 DF PROTO=TCP SPT=54832 DPT=80 SEQ=567002889 ACK=0 WINDOW=64240 RES=0x00 SYN URGP=0 OPT (020405B40402080A)\r\n",
+		"Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=93.184.216.34 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=64125 DF PROTO=TCP SPT=54832 DPT=80 SEQ=567002889 ACK=0 WINDOW=64240 RES=0x00 SYN URGP=0 OPT (020405B40402080A)\r",
+		"  Jul 21 05:31:48  ubuntu-jammy  kernel:  [14479.122228]  OUT-LOG:  IN= OUT=enp0s3  SRC=10.0.2.15 DST=93.184.216.34  LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=64125 DF  PROTO=TCP SPT=54832 DPT=80 SEQ=567002889 ACK=0 WINDOW=64240 RES=0x00 SYN  URGP=0 OPT (020405B40402080A) \t\r\n",
+	}
+	for _, line := range lines {
+		parsedLog, err := Parse(line)
+		assert.NoError(t, err, "%q", line)
+		assert.Equal(t, expected, parsedLog, "%q", line)
+		for f := Field(0); int(f) < numFields; f++ {
+			if s, ok := fieldDefs[f].get(parsedLog).(string); ok {
+				assert.NotContains(t, s, "\r", "%s of %q", f, line)
+			}
+		}
+	}
+}
+
+func TestParse_TabsAndMultipleSpaces(t *testing.T) {
+	type TestCase struct {
+		expected string
+		line     string
+	}
+
+	testCases := []*TestCase{
+		{
+			expected: "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] OUT-LOG: IN= OUT=enp0s3 MAC=00:b3:dd:bc:29:e1:52:54:00:12:35:02:08:00 SRC=10.0.2.15 DST=93.184.216.34 LEN=60 TOS=0x10 PREC=0xA0 TTL=64 ID=64125 DF PROTO=TCP SPT=54832 DPT=80 SEQ=567002889 ACK=0 WINDOW=64240 RES=0x00 SYN URGP=0 OPT (020405B40402080A) UID=1000 GID=1000 MARK=0x1f",
+			line:     "Jul\t21  05:31:48\tubuntu-jammy\tkernel:\t[\t14479.122228]\tOUT-LOG:\tIN=\tOUT=enp0s3\t\tMAC=00:b3:dd:bc:29:e1:52:54:00:12:35:02:08:00\tSRC=10.0.2.15 \tDST=93.184.216.34\tLEN=60\tTOS=0x10\tPREC=0xA0\tTTL=64\tID=64125\tDF\tPROTO=TCP\tSPT=54832\tDPT=80\tSEQ=567002889\tACK=0\tWINDOW=64240\tRES=0x00\tSYN\tURGP=0\tOPT\t(020405B40402080A)\tUID=1000\tGID=1000\tMARK=0x1f",
+		},
+		{
+			expected: "2022-07-21T05:38:28.123456+00:00 ubuntu-jammy kernel: [14879.600492] IN=enp0s3 OUT= SRC=192.0.2.1 DST=10.0.2.15 LEN=576 TOS=0x08 PREC=0xC0 TTL=254 ID=39147 PROTO=ICMP TYPE=3 CODE=3 [SRC=10.0.2.15 DST=198.51.100.7 LEN=56 TOS=0x10 PREC=0x00 TTL=64 ID=1 PROTO=UDP SPT=40000 DPT=53 LEN=36 ] MARK=0x0",
+			line:     "2022-07-21T05:38:28.123456+00:00\t ubuntu-jammy kernel:   [14879.600492] IN=enp0s3\tOUT=\tSRC=192.0.2.1\t DST=10.0.2.15 LEN=576\t\tTOS=0x08\tPREC=0xC0 TTL=254 ID=39147\tPROTO=ICMP\tTYPE=3\tCODE=3\t[SRC=10.0.2.15\tDST=198.51.100.7  LEN=56\tTOS=0x10\tPREC=0x00\tTTL=64\tID=1\tPROTO=UDP\tSPT=40000\tDPT=53\tLEN=36\t]\tMARK=0x0",
+		},
+	}
+
+	for _, testCase := range testCases {
+		expected, err := Parse(testCase.expected)
+		assert.NoError(t, err)
+
+		parsedLog, err := Parse(testCase.line)
+		assert.NoError(t, err, "%q", testCase.line)
+		assert.False(t, parsedLog.TimestampParsed.IsZero(), "%q", testCase.line)
+		// the raw timestamp is kept as it is
+		expected.Timestamp = parsedLog.Timestamp
+		assert.Equal(t, expected, parsedLog, "%q", testCase.line)
+	}
+}
+
+func TestParse_DecimalToSAndPrec(t *testing.T) {
+	type TestCase struct {
+		tos                string
+		prec               string
+		expectedToS        uint8
+		expectedPrecedence uint8
+	}
+
+	testCases := []*TestCase{
+		{tos: "0x10", prec: "0xC0", expectedToS: 0x10, expectedPrecedence: 0xc0},
+		{tos: "16", prec: "192", expectedToS: 0x10, expectedPrecedence: 0xc0},
+		{tos: "0", prec: "0x00", expectedToS: 0, expectedPrecedence: 0},
+		{tos: "0x08", prec: "32", expectedToS: 0x08, expectedPrecedence: 0x20},
+	}
+
+	for _, testCase := range testCases {
+		parsedLog, err := Parse(fmt.Sprintf("Jul 21 05:38:28 ubuntu-jammy kernel: [14879.600492] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=84 TOS=%s PREC=%s TTL=64 ID=6495 DF PROTO=ICMP TYPE=8 CODE=0 ID=1 SEQ=3", testCase.tos, testCase.prec))
+		assert.NoError(t, err)
+		assert.Equal(t, testCase.expectedToS, parsedLog.ToS, "TOS=%s", testCase.tos)
+		assert.Equal(t, testCase.expectedPrecedence, parsedLog.Precedence, "PREC=%s", testCase.prec)
+	}
+}
+
+func TestParse_EmptyAndTruncatedFields(t *testing.T) {
+	const linePrefix = "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] OUT-LOG: IN= OUT=enp0s3 "
+
+	// the empty numeric values are zero-filled
+	parsedLog, err := Parse(linePrefix + "SRC=10.0.2.15 DST=93.184.216.34 LEN= TOS= PREC= TTL= ID= PROTO=TCP SPT= DPT=80 SEQ= ACK= WINDOW= RES= SYN URGP= UID= GID= MARK=")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), parsedLog.Length)
+	assert.Equal(t, uint64(0), parsedLog.TTL)
+	assert.Equal(t, uint64(0), parsedLog.ID)
+	assert.Equal(t, uint16(0), parsedLog.SourcePort)
+	assert.Equal(t, uint16(80), parsedLog.DestinationPort)
+	assert.Equal(t, int64(-1), parsedLog.UID)
+	assert.Equal(t, int64(-1), parsedLog.GID)
+	assert.True(t, parsedLog.Syn)
+
+	// the line truncated in the middle of the transport layer
+	parsedLog, err = Parse(linePrefix + "SRC=10.0.2.15 DST=93.184.216.34 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=64125 DF PROTO=TCP SPT=54832 DPT=")
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(54832), parsedLog.SourcePort)
+	assert.Equal(t, uint16(0), parsedLog.DestinationPort)
+
+	// the line truncated in the middle of the TCP options
+	parsedLog, err = Parse(linePrefix + "SRC=10.0.2.15 DST=93.184.216.34 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=64125 DF PROTO=TCP SPT=54832 DPT=80 WINDOW=64240 RES=0x00 SYN URGP=0 OPT (0204")
+	assert.NoError(t, err)
+	assert.Equal(t, "", parsedLog.TCPOption)
+
+	// the line truncated before the mandatory fields
+	_, err = Parse(linePrefix + "SRC=10.0.2.15 DST=93.184.216.34 LE")
+	var unmatchedErr *UnmatchedError
+	assert.ErrorAs(t, err, &unmatchedErr)
+	assert.Equal(t, "missing mandatory fields: LEN, PROTO, TTL", unmatchedErr.Reason)
+
+	// the non-numeric values fail with the error that carries the field
+	type TestCase struct {
+		fields        string
+		expectedField string
+		expectedValue string
+	}
+	testCases := []*TestCase{
+		{fields: "SRC=10.0.2.15 DST=93.184.216.34 LEN=6O TTL=64 PROTO=UDP", expectedField: "len", expectedValue: "6O"},
+		{fields: "SRC=10.0.2.15 DST=93.184.216.34 LEN=60 TTL=-1 PROTO=UDP", expectedField: "ttl", expectedValue: "-1"},
+		{fields: "SRC=10.0.2.15 DST=93.184.216.34 LEN=60 TTL=64 ID=0x10 PROTO=UDP", expectedField: "id", expectedValue: "0x10"},
+		{fields: "SRC=10.0.2.15 DST=93.184.216.34 LEN=60 TTL=64 PROTO=UDP SPT=53 DPT=53 UID=root", expectedField: "uid", expectedValue: "root"},
+	}
+	for _, testCase := range testCases {
+		_, err := Parse(linePrefix + testCase.fields)
+		var convErr *FieldConversionError
+		assert.ErrorAs(t, err, &convErr, testCase.fields)
+		assert.Equal(t, testCase.expectedField, convErr.Field, testCase.fields)
+		assert.Equal(t, testCase.expectedValue, convErr.Value, testCase.fields)
+	}
+}
+
+func TestParse_WhitespacePaddedNumbers(t *testing.T) {
+	const linePrefix = "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] OUT-LOG: IN= OUT=enp0s3 "
+
+	expected, err := Parse(linePrefix + "SRC=10.0.2.15 DST=93.184.216.34 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=64125 DF PROTO=TCP SPT=54832 DPT=80 SEQ=567002889 ACK=0 WINDOW=64240 RES=0x00 SYN URGP=0 UID=1000 GID=1000 MARK=0x1f")
+	assert.NoError(t, err)
+
+	lines := []string{
+		linePrefix + "SRC=10.0.2.15 DST=93.184.216.34 LEN= 60 TOS=0x00 PREC=0x00 TTL=64  ID=64125 DF PROTO=TCP SPT=54832 DPT=80 SEQ=567002889 ACK=0 WINDOW=64240 RES=0x00 SYN URGP=0 UID=1000 GID=1000 MARK=0x1f",
+		linePrefix + "SRC=10.0.2.15 DST=93.184.216.34 LEN=60  TOS= 0x00 PREC=\t0x00 TTL=64 ID=64125 DF PROTO=TCP SPT= 54832 DPT=  80 SEQ=567002889 ACK= 0 WINDOW=64240 RES= 0x00 SYN URGP= 0 UID= 1000 GID=1000 MARK= 0x1f ",
+		linePrefix + "SRC=10.0.2.15 DST=93.184.216.34 LEN= 60 TOS= 0x00 PREC= 0x00 TTL= 64 ID= 64125 DF PROTO=TCP SPT= 54832 DPT= 80 SEQ= 567002889 ACK= 0 WINDOW= 64240 RES= 0x00 SYN URGP= 0 UID= 1000 GID= 1000 MARK= 0x1f",
+	}
+	for _, line := range lines {
+		parsedLog, err := Parse(line)
+		assert.NoError(t, err, "%q", line)
+		assert.Equal(t, expected, parsedLog, "%q", line)
+	}
+
+	// the padded numbers of ICMP and IPv6
+	parsedLog, err := Parse(linePrefix + "SRC=2001:db8::1 DST=2001:db8::2 LEN= 104 TC= 0 HOPLIMIT= 64 FLOWLBL= 12345 PROTO=ICMPv6 TYPE= 128 CODE= 0 ID= 1 SEQ= 3")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(104), parsedLog.Length)
+	assert.Equal(t, uint64(64), parsedLog.TTL)
+	assert.Equal(t, uint32(12345), parsedLog.FlowLabel)
+	assert.Equal(t, int64(128), parsedLog.Type)
+	assert.Equal(t, uint16(1), parsedLog.ICMPID)
+	assert.Equal(t, uint16(3), parsedLog.ICMPSeq)
+
+	// the empty values are still empty when the next token is not a number
+	parsedLog, err = Parse(linePrefix + "SRC=10.0.2.15 DST=93.184.216.34 LEN=60 TTL=64 ID= DF PROTO=TCP SPT=54832 DPT=80 ACK= SYN URGP=0 UID= GID=")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), parsedLog.ID)
+	assert.True(t, parsedLog.DoNotFragment)
+	assert.Equal(t, uint64(0), parsedLog.AckSequence)
+	assert.True(t, parsedLog.Syn)
+	assert.Equal(t, int64(-1), parsedLog.UID)
+	assert.Equal(t, int64(-1), parsedLog.GID)
+
+	// the padded value that is not a number fails as usual
+	_, err = Parse(linePrefix + "SRC=10.0.2.15 DST=93.184.216.34 LEN= 6O TTL=64 PROTO=UDP")
+	var convErr *FieldConversionError
+	assert.ErrorAs(t, err, &convErr)
+	assert.Equal(t, "len", convErr.Field)
+	assert.Equal(t, "6O", convErr.Value)
+}
+
+func TestParse_WithRegexp(t *testing.T) {
+	// the lines without the hostname
+	re := regexp.MustCompile(`^(?P<ts>\w{3}\s+\d+ [\d:]+) kernel: \[\s*(?P<kts>[^]]+)]\s+`)
+	p, err := NewParser(WithRegexp(re, map[string]Field{"ts": FieldTimestamp, "kts": FieldKernelTimestamp}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsedLog, err := p.Parse("Jul 21 05:31:48 kernel: [14479.122228] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=64 TOS=0x00 PREC=0x00 TTL=64 ID=40000 PROTO=UDP SPT=40000 DPT=53")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "Jul 21 05:31:48", parsedLog.Timestamp)
+	assert.Equal(t, "", parsedLog.Hostname)
+	assert.False(t, parsedLog.Has(FieldHostname))
+	assert.Equal(t, 14479.122228, parsedLog.KernelTimestamp)
+	assert.Equal(t, "OUT-LOG:", parsedLog.Prefix)
+	assert.Equal(t, uint16(53), parsedLog.DestinationPort)
+
+	_, err = p.Parse("Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=64 TOS=0x00 PREC=0x00 TTL=64 ID=40000 PROTO=UDP SPT=40000 DPT=53")
+	assert.ErrorIs(t, err, ErrLogFormatUnmatched)
+
+	// the optional group of the kernel timestamp
+	re = regexp.MustCompile(`^(?P<ts>\S+) (?P<host>\S+) kernel: (?:\[\s*(?P<kts>[^]]+)]\s+)?`)
+	p, err = NewParser(WithRegexp(re, map[string]Field{"ts": FieldTimestamp, "host": FieldHostname, "kts": FieldKernelTimestamp}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsedLog, err = p.Parse("2022-07-12T09:01:27.345918+00:00 ubuntu-jammy kernel: OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=64 TOS=0x00 PREC=0x00 TTL=64 ID=40000 PROTO=UDP SPT=40000 DPT=53")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "ubuntu-jammy", parsedLog.Hostname)
+	assert.Equal(t, time.Date(2022, time.July, 12, 9, 1, 27, 345918000, time.UTC), parsedLog.TimestampParsed)
+	assert.False(t, parsedLog.Has(FieldKernelTimestamp))
+}
+
+func TestNewParser_WithRegexp_Invalid(t *testing.T) {
+	re := regexp.MustCompile(`^(?P<ts>\S+) (?P<host>\S+) kernel: \[\s*(?P<kts>[^]]+)]\s+`)
+
+	type TestCase struct {
+		re            *regexp.Regexp
+		fieldMap      map[string]Field
+		expectedError string
+	}
+
+	testCases := []*TestCase{
+		{
+			re:            nil,
+			fieldMap:      map[string]Field{"ts": FieldTimestamp, "kts": FieldKernelTimestamp},
+			expectedError: "regexp must not be nil",
+		},
+		{
+			re:            re,
+			fieldMap:      map[string]Field{"ts": FieldTimestamp, "kernel": FieldKernelTimestamp},
+			expectedError: `named group "kernel" is not found in the regexp`,
+		},
+		{
+			re:            re,
+			fieldMap:      map[string]Field{"ts": FieldTimestamp, "host": FieldSource, "kts": FieldKernelTimestamp},
+			expectedError: `field "source" cannot be captured by the regexp; only timestamp, hostname, and kernelTimestamp are supported`,
+		},
+		{
+			re:            re,
+			fieldMap:      map[string]Field{"ts": FieldTimestamp, "host": FieldTimestamp},
+			expectedError: `field "timestamp" is mapped more than once`,
+		},
+		{
+			re:            re,
+			fieldMap:      map[string]Field{"host": FieldHostname, "kts": FieldKernelTimestamp},
+			expectedError: "named group of the timestamp is required",
+		},
+		{
+			re:            re,
+			fieldMap:      map[string]Field{"ts": FieldTimestamp, "host": FieldHostname},
+			expectedError: "named group of the kernel timestamp is required",
+		},
+	}
+
+	for _, testCase := range testCases {
+		p, err := NewParser(WithRegexp(testCase.re, testCase.fieldMap))
+		assert.Nil(t, p)
+		assert.EqualError(t, err, testCase.expectedError)
+	}
+}
+
+func TestParse_PrefixPresence(t *testing.T) {
+	type TestCase struct {
+		name            string
+		line            string
+		expectedPrefix  string
+		expectedPresent bool
+	}
+
+	testCases := []*TestCase{
+		{
+			name:            "with prefix",
+			line:            "Jul 21 05:38:28 ubuntu-jammy kernel: [14879.600492] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=84 TOS=0x00 PREC=0x00 TTL=64 ID=6495 DF PROTO=ICMP TYPE=8 CODE=0 ID=1 SEQ=3",
+			expectedPrefix:  "OUT-LOG:",
+			expectedPresent: true,
+		},
+		{
+			name:            "with prefix without trailing space",
+			line:            "Jul 21 05:38:28 ubuntu-jammy kernel: [14879.600492] OUT-LOGIN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=84 TOS=0x00 PREC=0x00 TTL=64 ID=6495 DF PROTO=ICMP TYPE=8 CODE=0 ID=1 SEQ=3",
+			expectedPrefix:  "OUT-LOG",
+			expectedPresent: true,
+		},
+		{
+			name:            "without prefix",
+			line:            "Jul 21 05:38:28 ubuntu-jammy kernel: [14879.600492] IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=84 TOS=0x00 PREC=0x00 TTL=64 ID=6495 DF PROTO=ICMP TYPE=8 CODE=0 ID=1 SEQ=3",
+			expectedPrefix:  "",
+			expectedPresent: false,
+		},
+		{
+			name:            "with blank prefix",
+			line:            "Jul 21 05:38:28 ubuntu-jammy kernel: [14879.600492]  IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=84 TOS=0x00 PREC=0x00 TTL=64 ID=6495 DF PROTO=ICMP TYPE=8 CODE=0 ID=1 SEQ=3",
+			expectedPrefix:  "",
+			expectedPresent: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		parsedLog, err := Parse(testCase.line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, testCase.expectedPrefix, parsedLog.Prefix, testCase.name)
+		assert.Equal(t, testCase.expectedPresent, parsedLog.Has(FieldPrefix), testCase.name)
+
+		// the presence survives the round trip
+		reparsedLog, err := Parse(parsedLog.Format())
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, testCase.expectedPresent, reparsedLog.Has(FieldPrefix), testCase.name)
+	}
+}
+
+func TestParse_MaxLineLength(t *testing.T) {
+	line := "Jul 21 05:38:28 ubuntu-jammy kernel: [14879.600492] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=84 TOS=0x00 PREC=0x00 TTL=64 ID=6495 DF PROTO=ICMP TYPE=8 CODE=0 ID=1 SEQ=3"
+
+	// the default is generous
+	_, err := Parse(line + strings.Repeat(" ", DefaultMaxLineLength-len(line)))
+	assert.NoError(t, err)
+	_, err = Parse(strings.Repeat("x", DefaultMaxLineLength+1))
+	assert.ErrorIs(t, err, ErrLineTooLong)
+
+	p, err := NewParser(WithMaxLineLength(len(line)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = p.Parse(line)
+	assert.NoError(t, err)
+	_, err = p.Parse(line + " ")
+	assert.ErrorIs(t, err, ErrLineTooLong)
+	assert.EqualError(t, err, fmt.Sprintf("%d bytes exceeds the maximum of %d bytes: line is too long", len(line)+1, len(line)))
+	_, err = p.ParseBridge(line + " ")
+	assert.ErrorIs(t, err, ErrLineTooLong)
+
+	// zero disables the limit
+	p, err = NewParser(WithMaxLineLength(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = p.Parse(line + strings.Repeat(" ", DefaultMaxLineLength))
+	assert.NoError(t, err)
+
+	_, err = NewParser(WithMaxLineLength(-1))
+	assert.Error(t, err)
+}
+
+func TestParse_KernelTimestampSpacing(t *testing.T) {
+	const fields = "OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=84 TOS=0x00 PREC=0x00 TTL=64 ID=6495 DF PROTO=ICMP TYPE=8 CODE=0 ID=1 SEQ=3"
+	preambles := []string{
+		"Jul 21 05:38:28 ubuntu-jammy kernel: [12345.678901] ",
+		"Jul 21 05:38:28 ubuntu-jammy kernel:[12345.678901] ",
+		"Jul 21 05:38:28 ubuntu-jammy kernel: [ 12345.678901] ",
+		"Jul 21 05:38:28 ubuntu-jammy kernel:[ 12345.678901] ",
+		"Jul 21 05:38:28 ubuntu-jammy kernel:\t[\t12345.678901]\t",
+		"Jul 21 05:38:28 ubuntu-jammy [12345.678901] ",
+		"2022-07-21T05:38:28.123456+00:00 ubuntu-jammy kernel:[12345.678901] ",
+	}
+
+	withTag, err := NewParser(WithTag("kernel"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, preamble := range preambles {
+		parsedLog, err := Parse(preamble + fields)
+		if err != nil {
+			t.Fatalf("%q: %v", preamble, err)
+		}
+		assert.Equal(t, "ubuntu-jammy", parsedLog.Hostname, preamble)
+		assert.Equal(t, 12345.678901, parsedLog.KernelTimestamp, preamble)
+		assert.Equal(t, "OUT-LOG:", parsedLog.Prefix, preamble)
+		assert.Equal(t, "8.8.8.8", parsedLog.Destination, preamble)
+
+		if strings.Contains(preamble, "kernel:") {
+			parsedLog, err = withTag.Parse(preamble + fields)
+			if err != nil {
+				t.Fatalf("%q: %v", preamble, err)
+			}
+			assert.Equal(t, 12345.678901, parsedLog.KernelTimestamp, preamble)
+		}
+	}
+}
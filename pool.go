@@ -0,0 +1,66 @@
+package iptables
+
+import "sync"
+
+// PooledParser is a parser that recycles the Logs by sync.Pool, which saves the allocation of a Log per line in hot loops without managing the pool by the caller.
+// A PooledParser is safe for concurrent use by multiple goroutines.
+//
+// The lifecycle of a Log handed out by PooledParser.Parse is as follows:
+//  1. Parse takes a Log from the pool, parses the line into it, and hands it out to the caller.
+//  2. The caller uses the Log as usual.
+//  3. The caller calls Release when it is done with the Log, which resets the Log and puts it back into the pool.
+//
+// After Release, the Log must not be used anymore, and neither must the values reached from it (i.e. Extra and Inner), since the Log is reset and handed out again by the subsequent Parse.
+// So do not retain the Log (e.g. in a slice or a channel) beyond Release; use Log.Clone to keep a copy of it instead.
+// Releasing a Log is optional: a Log that is not released is just garbage collected as usual, though it doesn't benefit from the pool.
+type PooledParser struct {
+	parser *Parser
+	pool   sync.Pool
+}
+
+// NewPooledParser makes a new PooledParser with the given options; see NewParser for the options.
+// This returns an error if any of the options is invalid.
+func NewPooledParser(opts ...Option) (*PooledParser, error) {
+	p, err := NewParser(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &PooledParser{
+		parser: p,
+		pool: sync.Pool{
+			New: func() any {
+				return &Log{}
+			},
+		},
+	}, nil
+}
+
+// Parse parses an iptables line into a Log taken from the pool, as well as Parser.Parse does.
+// The caller should pass the returned Log to Release when it is done with the Log; see PooledParser for the lifecycle.
+// This might return the same errors as Parser.Parse, and the Log is put back into the pool on error unless it is returned along with the error (see WithCollectAllErrors).
+func (p *PooledParser) Parse(line string) (*Log, error) {
+	l := p.pool.Get().(*Log)
+	parsedLog, err := p.parser.result(l, p.parser.ParseInto(line, l))
+	if parsedLog == nil {
+		p.Release(l)
+	}
+	return parsedLog, err
+}
+
+// ParseBytes parses an iptables line given as bytes into a Log taken from the pool without copying the bytes; see Parser.ParseBytes and PooledParser.Parse for details.
+// As well as Parser.ParseBytes, the bytes must not be modified until the Log is released.
+func (p *PooledParser) ParseBytes(b []byte) (*Log, error) {
+	return p.Parse(bytesToString(b))
+}
+
+// Release resets the given Log and puts it back into the pool, so that the subsequent Parse reuses it.
+// The Log must be the one returned by Parse, and must not be used after this; see PooledParser for the lifecycle.
+// Releasing nil does nothing.
+func (p *PooledParser) Release(l *Log) {
+	if l == nil {
+		return
+	}
+	// drop the references to the line, Extra, and Inner, so that the pooled Log doesn't keep them alive
+	*l = Log{}
+	p.pool.Put(l)
+}
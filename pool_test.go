@@ -0,0 +1,125 @@
+package iptables
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPooledParser(t *testing.T) {
+	p, err := NewPooledParser(WithYear(2023), WithLocation(time.UTC))
+	assert.NoError(t, err)
+
+	line := "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=93.184.216.34 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=64125 DF PROTO=TCP SPT=54832 DPT=80 SEQ=567002889 ACK=0 WINDOW=64240 RES=0x00 SYN URGP=0 CT=NEW"
+	expected, err := ParseWithOptions(line, WithYear(2023), WithLocation(time.UTC))
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		parsedLog, err := p.Parse(line)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, parsedLog)
+		p.Release(parsedLog)
+		// the released log is reset
+		assert.Equal(t, &Log{}, parsedLog)
+	}
+
+	parsedLog, err := p.ParseBytes([]byte(line))
+	assert.NoError(t, err)
+	assert.Equal(t, expected, parsedLog)
+	p.Release(parsedLog)
+
+	parsedLog, err = p.Parse("this is not an iptables log")
+	assert.ErrorIs(t, err, ErrLogFormatUnmatched)
+	assert.Nil(t, parsedLog)
+
+	// releasing nil does nothing
+	p.Release(nil)
+}
+
+func TestPooledParser_NoStaleData(t *testing.T) {
+	p, err := NewPooledParser()
+	assert.NoError(t, err)
+
+	tcpLog, err := p.Parse("Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=93.184.216.34 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=64125 DF PROTO=TCP SPT=54832 DPT=80 SEQ=567002889 ACK=0 WINDOW=64240 RES=0x00 SYN URGP=0 CT=NEW")
+	assert.NoError(t, err)
+	p.Release(tcpLog)
+
+	icmpLine := "Jul 21 05:38:28 ubuntu-jammy kernel: [14879.600492] IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=84 TOS=0x00 PREC=0x00 TTL=64 ID=6495 DF PROTO=ICMP TYPE=8 CODE=0 ID=1 SEQ=3"
+	expected, err := Parse(icmpLine)
+	assert.NoError(t, err)
+
+	icmpLog, err := p.Parse(icmpLine)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, icmpLog)
+	assert.False(t, icmpLog.Has(FieldPrefix))
+	assert.Nil(t, icmpLog.Extra)
+	p.Release(icmpLog)
+}
+
+func TestPooledParser_Concurrent(t *testing.T) {
+	p, err := NewPooledParser()
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for _, benchmarkLine := range parseBenchmarkLines {
+		expected, err := Parse(benchmarkLine.line)
+		assert.NoError(t, err)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				parsedLog, err := p.Parse(benchmarkLine.line)
+				assert.NoError(t, err)
+				assert.Equal(t, expected, parsedLog)
+				p.Release(parsedLog)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestNewPooledParser_InvalidOption(t *testing.T) {
+	p, err := NewPooledParser(WithLocation(nil))
+	assert.Error(t, err)
+	assert.Nil(t, p)
+}
+
+func BenchmarkPooledParser(b *testing.B) {
+	p, err := NewPooledParser()
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, benchmarkLine := range parseBenchmarkLines {
+		b.Run(benchmarkLine.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				l, err := p.Parse(benchmarkLine.line)
+				if err != nil {
+					b.Fatal(err)
+				}
+				p.Release(l)
+			}
+		})
+	}
+}
+
+func TestPooledParser_WithCollectAllErrors(t *testing.T) {
+	p, err := NewPooledParser(WithCollectAllErrors(true))
+	assert.NoError(t, err)
+
+	line := "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=93.184.216.34 LEN=60 TOS=0xZZ PREC=0x00 TTL=25x ID=64125 DF PROTO=TCP SPT=54832 DPT=80 WINDOW=64240 RES=0x00 SYN URGP=0"
+	expected, expectedErr := ParseWithOptions(line, WithCollectAllErrors(true))
+	assert.Error(t, expectedErr)
+
+	parsedLog, err := p.Parse(line)
+	assert.Equal(t, expectedErr, err)
+	assert.Equal(t, expected, parsedLog)
+	p.Release(parsedLog)
+
+	parsedLog, err = p.Parse("this is not an iptables log")
+	assert.ErrorIs(t, err, ErrLogFormatUnmatched)
+	assert.Nil(t, parsedLog)
+}
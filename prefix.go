@@ -0,0 +1,21 @@
+package iptables
+
+import (
+	"strings"
+)
+
+// ParsePrefixKV extracts the KEY=VALUE pairs embedded in the prefix, e.g. map[string]string{"table": "filter", "chain": "INPUT"} for "table=filter chain=INPUT:".
+// The pairs are separated by the whitespaces, and the trailing colon of the prefix (i.e. the conventional terminator) is trimmed. The words without "=" are ignored, and the later pair wins if a key is duplicated.
+// This returns an empty map (not nil) if the prefix has no pair.
+func (l *Log) ParsePrefixKV() map[string]string {
+	kv := map[string]string{}
+	prefix := strings.TrimSuffix(strings.TrimSpace(l.Prefix), ":")
+	for _, word := range strings.Fields(prefix) {
+		key, value, ok := strings.Cut(word, "=")
+		if !ok || key == "" {
+			continue
+		}
+		kv[key] = value
+	}
+	return kv
+}
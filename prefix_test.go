@@ -0,0 +1,52 @@
+package iptables
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLog_ParsePrefixKV(t *testing.T) {
+	type TestCase struct {
+		prefix   string
+		expected map[string]string
+	}
+
+	testCases := []*TestCase{
+		{
+			prefix:   "table=filter chain=INPUT:",
+			expected: map[string]string{"table": "filter", "chain": "INPUT"},
+		},
+		{
+			prefix:   "DROP table=filter chain=INPUT rule-id=42 ",
+			expected: map[string]string{"table": "filter", "chain": "INPUT", "rule-id": "42"},
+		},
+		{
+			prefix:   "chain=INPUT chain=FORWARD empty= =orphan",
+			expected: map[string]string{"chain": "FORWARD", "empty": ""},
+		},
+		{
+			prefix:   "OUT-LOG:",
+			expected: map[string]string{},
+		},
+		{
+			prefix:   "",
+			expected: map[string]string{},
+		},
+	}
+
+	for _, testCase := range testCases {
+		l := &Log{Prefix: testCase.prefix}
+		assert.Equal(t, testCase.expected, l.ParsePrefixKV(), testCase.prefix)
+	}
+}
+
+func TestLog_ParsePrefixKVOfParsedLog(t *testing.T) {
+	parsedLog, err := Parse("Jul 21 05:38:28 ubuntu-jammy kernel: [14879.600492] table=filter chain=INPUT: IN=enp0s3 OUT= SRC=10.0.2.2 DST=10.0.2.15 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=1234 PROTO=UDP SPT=53 DPT=40000 LEN=40")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "table=filter chain=INPUT:", parsedLog.Prefix)
+	assert.Equal(t, map[string]string{"table": "filter", "chain": "INPUT"}, parsedLog.ParsePrefixKV())
+	assert.Nil(t, parsedLog.Extra)
+}
@@ -0,0 +1,98 @@
+package iptables
+
+import (
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// maxPriority is the maximum value of the syslog priority, i.e. the facility 23 (local7) and the severity 7 (debug).
+const maxPriority = 23*8 + 7
+
+// parsePriority parses the leading syslog priority (e.g. "<4>") of the line into the facility and the severity, i.e. PRI = facility * 8 + severity.
+// This returns the rest of the line. The line without the priority is returned as it is, and so is the rest of the line along with *FieldConversionError.
+func parsePriority(line string, l *Log) (string, error) {
+	if !strings.HasPrefix(line, "<") {
+		return line, nil
+	}
+	end := strings.IndexByte(line, '>')
+	if end < 0 {
+		return "", &UnmatchedError{Reason: "syslog priority is not closed"}
+	}
+
+	rawPriority := line[1:end]
+	if rawPriority == "" {
+		return "", &UnmatchedError{Reason: "syslog priority is empty"}
+	}
+	priority, err := strconv.ParseUint(rawPriority, 10, 8)
+	if err == nil && priority > maxPriority {
+		err = strconv.ErrRange
+	}
+	if err != nil {
+		return line[end+1:], &FieldConversionError{Field: "pri", Value: rawPriority, Err: err}
+	}
+
+	l.Facility = uint8(priority / 8)
+	l.Severity = uint8(priority % 8)
+	l.HasPriority = true
+	l.present.add(FieldFacility)
+	l.present.add(FieldSeverity)
+	l.present.add(FieldHasPriority)
+	return line[end+1:], nil
+}
+
+// facilityCodes maps the names of the syslog facilities to the codes, as the facility marker (e.g. "kern.warn") names them.
+var facilityCodes = map[string]uint8{
+	"kern":     0,
+	"user":     1,
+	"mail":     2,
+	"daemon":   3,
+	"auth":     4,
+	"security": 4,
+	"syslog":   5,
+	"lpr":      6,
+	"news":     7,
+	"uucp":     8,
+	"cron":     9,
+	"authpriv": 10,
+	"ftp":      11,
+	"local0":   16,
+	"local1":   17,
+	"local2":   18,
+	"local3":   19,
+	"local4":   20,
+	"local5":   21,
+	"local6":   22,
+	"local7":   23,
+}
+
+// severityCodes maps the names of the syslog severities to the codes, as the facility marker (e.g. "kern.warn") names them.
+var severityCodes = map[string]uint8{
+	"emerg":   0,
+	"panic":   0,
+	"alert":   1,
+	"crit":    2,
+	"err":     3,
+	"error":   3,
+	"warn":    4,
+	"warning": 4,
+	"notice":  5,
+	"info":    6,
+	"debug":   7,
+}
+
+// facilityMarkerPattern matches the facility marker, i.e. the names of the facility and the severity joined by a dot (e.g. "kern.warn").
+// Only the known names are accepted, so that a hostname that contains a dot is not mistaken for the marker.
+var facilityMarkerPattern = `(?:` + strings.Join(slices.Sorted(maps.Keys(facilityCodes)), "|") + `)\.(?:` + strings.Join(slices.Sorted(maps.Keys(severityCodes)), "|") + `)`
+
+// parseFacilityMarker parses the facility marker (e.g. "kern.warn") that is matched by facilityMarkerPattern into the facility and the severity.
+func parseFacilityMarker(marker string, l *Log) {
+	facility, severity, _ := strings.Cut(marker, ".")
+	l.Facility = facilityCodes[facility]
+	l.Severity = severityCodes[severity]
+	l.HasPriority = true
+	l.present.add(FieldFacility)
+	l.present.add(FieldSeverity)
+	l.present.add(FieldHasPriority)
+}
@@ -0,0 +1,86 @@
+package iptables
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseWithOptions_Priority(t *testing.T) {
+	type TestCase struct {
+		line                string
+		expectedFacility    uint8
+		expectedSeverity    uint8
+		expectedHasPriority bool
+	}
+
+	testCases := []*TestCase{
+		{
+			line:                "<4>Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=64 TOS=0x00 PREC=0x00 TTL=64 ID=40000 PROTO=UDP SPT=40000 DPT=53 LEN=44",
+			expectedFacility:    0,
+			expectedSeverity:    4,
+			expectedHasPriority: true,
+		},
+		{
+			line:                "<134>Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=64 TOS=0x00 PREC=0x00 TTL=64 ID=40000 PROTO=UDP SPT=40000 DPT=53 LEN=44",
+			expectedFacility:    16,
+			expectedSeverity:    6,
+			expectedHasPriority: true,
+		},
+		{
+			// no priority
+			line:                "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=64 TOS=0x00 PREC=0x00 TTL=64 ID=40000 PROTO=UDP SPT=40000 DPT=53 LEN=44",
+			expectedHasPriority: false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		parsedLog, err := ParseWithOptions(testCase.line, WithPriority(true))
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, testCase.expectedFacility, parsedLog.Facility)
+		assert.Equal(t, testCase.expectedSeverity, parsedLog.Severity)
+		assert.Equal(t, testCase.expectedHasPriority, parsedLog.HasPriority)
+		assert.Equal(t, "Jul 21 05:31:48", parsedLog.Timestamp)
+	}
+}
+
+func TestParseWithOptions_MalformedPriority(t *testing.T) {
+	type TestCase struct {
+		line        string
+		expectedErr error
+	}
+
+	testCases := []*TestCase{
+		{
+			line:        "<192>Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=64 TOS=0x00 PREC=0x00 TTL=64 ID=40000 PROTO=UDP SPT=40000 DPT=53 LEN=44",
+			expectedErr: ErrStringToNumberConversionFailed,
+		},
+		{
+			line:        "<abc>Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=64 TOS=0x00 PREC=0x00 TTL=64 ID=40000 PROTO=UDP SPT=40000 DPT=53 LEN=44",
+			expectedErr: ErrStringToNumberConversionFailed,
+		},
+		{
+			line:        "<>Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=64 TOS=0x00 PREC=0x00 TTL=64 ID=40000 PROTO=UDP SPT=40000 DPT=53 LEN=44",
+			expectedErr: ErrLogFormatUnmatched,
+		},
+	}
+
+	for _, testCase := range testCases {
+		_, err := ParseWithOptions(testCase.line, WithPriority(true))
+		assert.True(t, errors.Is(err, testCase.expectedErr))
+	}
+}
+
+func TestParse_PriorityIsOptIn(t *testing.T) {
+	line := "<4>Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=64 TOS=0x00 PREC=0x00 TTL=64 ID=40000 PROTO=UDP SPT=40000 DPT=53 LEN=44"
+
+	parsedLog, err := Parse(line)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.False(t, parsedLog.HasPriority)
+	assert.Equal(t, "<4>Jul 21 05:31:48", parsedLog.Timestamp)
+}
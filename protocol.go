@@ -0,0 +1,159 @@
+package iptables
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ProtocolNumbers is the table that maps the protocol names appearing in the PROTO field to the IANA assigned protocol numbers.
+// This table can be extended by the users, though it is not safe to modify it concurrently with the lookups.
+var ProtocolNumbers = map[string]uint8{
+	"ICMP":    1,
+	"IGMP":    2,
+	"TCP":     6,
+	"UDP":     17,
+	"DCCP":    33,
+	"GRE":     47,
+	"ESP":     50,
+	"AH":      51,
+	"ICMPv6":  58,
+	"SCTP":    132,
+	"UDPLITE": 136,
+}
+
+// ProtocolNumber returns the IANA assigned protocol number of the Protocol.
+// The Protocol can be either a name (e.g. "TCP") that is looked up from ProtocolNumbers case-insensitively, or a bare number (e.g. "47").
+// The second return value is false if the Protocol is unknown.
+func (l *Log) ProtocolNumber() (uint8, bool) {
+	return lookupProtocolNumber(l.Protocol)
+}
+
+func lookupProtocolNumber(protocol string) (uint8, bool) {
+	if n, ok := ProtocolNumbers[protocol]; ok {
+		return n, true
+	}
+	if n, err := strconv.ParseUint(protocol, 10, 8); err == nil {
+		return uint8(n), true
+	}
+	for name, n := range ProtocolNumbers {
+		if strings.EqualFold(name, protocol) {
+			return n, true
+		}
+	}
+	return 0, false
+}
+
+// ProtocolName returns the name of the Protocol in ProtocolNumbers, e.g. "GRE" for "47" and "TCP" for "tcp"; this is the reverse of ProtocolNumber.
+// The Protocol is returned unchanged if it is unknown, e.g. "253" for the experimental protocol.
+// If more than one name is mapped to the same number in ProtocolNumbers, the lexicographically smallest one is returned.
+func (l *Log) ProtocolName() string {
+	return lookupProtocolName(l.Protocol)
+}
+
+func lookupProtocolName(protocol string) string {
+	n, ok := lookupProtocolNumber(protocol)
+	if !ok {
+		return protocol
+	}
+	if _, found := ProtocolNumbers[protocol]; found {
+		return protocol
+	}
+
+	name := ""
+	for candidate, m := range ProtocolNumbers {
+		if m == n && (name == "" || candidate < name) {
+			name = candidate
+		}
+	}
+	if name == "" {
+		return protocol
+	}
+	return name
+}
+
+// portProtocolNumbers is the set of the numbers of the protocols that have the ports.
+var portProtocolNumbers = map[uint8]struct{}{
+	6:   {}, // TCP
+	17:  {}, // UDP
+	33:  {}, // DCCP
+	132: {}, // SCTP
+	136: {}, // UDPLITE
+}
+
+// HasTransportPorts reports whether the protocol has the transport layer ports, i.e. it is one of TCP, UDP, UDPLITE, SCTP, and DCCP.
+// For the other protocols (e.g. ICMP, IGMP, and GRE), SourcePort and DestinationPort are always zero.
+func (l *Log) HasTransportPorts() bool {
+	n, ok := l.ProtocolNumber()
+	if !ok {
+		return false
+	}
+	_, hasPorts := portProtocolNumbers[n]
+	return hasPorts
+}
+
+// isProtocol reports whether the protocol is the one of the given name in ProtocolNumbers, whereas the Protocol can be given either by the name (case-insensitively) or by the number, e.g. "TCP", "tcp", and "6".
+func (l *Log) isProtocol(name string) bool {
+	expected, known := ProtocolNumbers[name]
+	n, ok := l.ProtocolNumber()
+	return known && ok && n == expected
+}
+
+// isGRE reports whether the protocol is GRE, given either by the name or by the number.
+func (l *Log) isGRE() bool {
+	return l.isProtocol("GRE")
+}
+
+// transportFields is the set of the fields that are specific to some protocols, i.e. the fields following PROTO; the other fields are common to every protocol.
+var transportFields = newFieldSet(
+	FieldType, FieldCode, FieldICMPID, FieldICMPSeq, FieldMTU, FieldInner,
+	FieldSPI, FieldIPsecSequence,
+	FieldGREKey, FieldGREProtocol,
+	FieldSourcePort, FieldDestinationPort, FieldChecksumCoverage,
+	FieldSequence, FieldAckSequence, FieldWindowSize, FieldRes,
+	FieldUrgent, FieldAck, FieldPush, FieldReset, FieldSyn, FieldFin, FieldECE, FieldCWR, FieldNS,
+	FieldUrgp, FieldTCPOption,
+)
+
+// protocolTransportFields maps the protocol numbers to the fields in transportFields that are relevant to the protocols.
+// The protocols that are not in this map (e.g. IGMP) have no relevant field in transportFields.
+var protocolTransportFields = func() map[uint8]FieldSet {
+	icmp := newFieldSet(FieldType, FieldCode, FieldICMPID, FieldICMPSeq, FieldMTU, FieldInner)
+	tcp := newFieldSet(
+		FieldSourcePort, FieldDestinationPort,
+		FieldSequence, FieldAckSequence, FieldWindowSize, FieldRes,
+		FieldUrgent, FieldAck, FieldPush, FieldReset, FieldSyn, FieldFin, FieldECE, FieldCWR, FieldNS,
+		FieldUrgp, FieldTCPOption,
+	)
+	ports := newFieldSet(FieldSourcePort, FieldDestinationPort)
+	udplite := newFieldSet(FieldSourcePort, FieldDestinationPort, FieldChecksumCoverage)
+	ipsec := newFieldSet(FieldSPI, FieldIPsecSequence)
+	gre := newFieldSet(FieldGREKey, FieldGREProtocol)
+	return map[uint8]FieldSet{
+		1:   icmp,    // ICMP
+		6:   tcp,     // TCP
+		17:  ports,   // UDP
+		33:  ports,   // DCCP
+		47:  gre,     // GRE
+		50:  ipsec,   // ESP
+		51:  ipsec,   // AH
+		58:  icmp,    // ICMPv6
+		132: ports,   // SCTP
+		136: udplite, // UDPLITE
+	}
+}()
+
+// ProtocolFields returns the set of the fields that are relevant to the protocol, e.g. the type and the code for ICMP, and the ports but not the flags for UDP.
+// The fields common to every protocol (e.g. the addresses and TTL) are always included, and every field is included if the protocol is unknown (see ProtocolNumber).
+// A protocol that is given by the number but has no fields following PROTO (e.g. "253") has only the common fields.
+// Note that this tells the fields that the protocol can have, rather than the fields that the line has; see Has for the latter.
+func (l *Log) ProtocolFields() FieldSet {
+	var s FieldSet
+	n, ok := l.ProtocolNumber()
+	relevant := protocolTransportFields[n]
+	for f := Field(0); int(f) < numFields; f++ {
+		if !ok || !transportFields.Has(f) || relevant.Has(f) {
+			s.add(f)
+		}
+	}
+	return s
+}
@@ -0,0 +1,252 @@
+package iptables
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLog_ProtocolNumber(t *testing.T) {
+	type TestCase struct {
+		protocol       string
+		expectedNumber uint8
+		expectedOK     bool
+	}
+
+	testCases := []*TestCase{
+		{protocol: "ICMP", expectedNumber: 1, expectedOK: true},
+		{protocol: "TCP", expectedNumber: 6, expectedOK: true},
+		{protocol: "UDP", expectedNumber: 17, expectedOK: true},
+		{protocol: "GRE", expectedNumber: 47, expectedOK: true},
+		{protocol: "ESP", expectedNumber: 50, expectedOK: true},
+		{protocol: "AH", expectedNumber: 51, expectedOK: true},
+		{protocol: "ICMPv6", expectedNumber: 58, expectedOK: true},
+		{protocol: "ICMPV6", expectedNumber: 58, expectedOK: true},
+		{protocol: "SCTP", expectedNumber: 132, expectedOK: true},
+		{protocol: "UDPLITE", expectedNumber: 136, expectedOK: true},
+		{protocol: "udplite", expectedNumber: 136, expectedOK: true},
+		{protocol: "2", expectedNumber: 2, expectedOK: true},
+		{protocol: "255", expectedNumber: 255, expectedOK: true},
+		{protocol: "256", expectedNumber: 0, expectedOK: false},
+		{protocol: "UNKNOWN", expectedNumber: 0, expectedOK: false},
+		{protocol: "", expectedNumber: 0, expectedOK: false},
+	}
+
+	for _, testCase := range testCases {
+		l := &Log{Protocol: testCase.protocol}
+		n, ok := l.ProtocolNumber()
+		assert.Equal(t, testCase.expectedNumber, n, testCase.protocol)
+		assert.Equal(t, testCase.expectedOK, ok, testCase.protocol)
+	}
+}
+
+func TestLog_ProtocolName(t *testing.T) {
+	type TestCase struct {
+		protocol     string
+		expectedName string
+	}
+
+	testCases := []*TestCase{
+		{protocol: "47", expectedName: "GRE"},
+		{protocol: "6", expectedName: "TCP"},
+		{protocol: "58", expectedName: "ICMPv6"},
+		{protocol: "GRE", expectedName: "GRE"},
+		{protocol: "tcp", expectedName: "TCP"},
+		// unknown protocols are returned unchanged
+		{protocol: "253", expectedName: "253"},
+		{protocol: "UNKNOWN", expectedName: "UNKNOWN"},
+		{protocol: "", expectedName: ""},
+	}
+
+	for _, testCase := range testCases {
+		l := &Log{Protocol: testCase.protocol}
+		assert.Equal(t, testCase.expectedName, l.ProtocolName(), testCase.protocol)
+	}
+}
+
+func TestLog_ProtocolNameWithExtendedTable(t *testing.T) {
+	ProtocolNumbers["OSPF"] = 89
+	ProtocolNumbers["IPIP"] = 4
+	ProtocolNumbers["IPV4"] = 4
+	defer func() {
+		delete(ProtocolNumbers, "OSPF")
+		delete(ProtocolNumbers, "IPIP")
+		delete(ProtocolNumbers, "IPV4")
+	}()
+
+	assert.Equal(t, "OSPF", (&Log{Protocol: "89"}).ProtocolName())
+	// the lexicographically smallest one among the aliases
+	assert.Equal(t, "IPIP", (&Log{Protocol: "4"}).ProtocolName())
+	assert.Equal(t, "IPV4", (&Log{Protocol: "IPV4"}).ProtocolName())
+}
+
+func TestLog_HasTransportPorts(t *testing.T) {
+	type TestCase struct {
+		protocol string
+		expected bool
+	}
+
+	testCases := []*TestCase{
+		{protocol: "TCP", expected: true},
+		{protocol: "UDP", expected: true},
+		{protocol: "UDPLITE", expected: true},
+		{protocol: "SCTP", expected: true},
+		{protocol: "DCCP", expected: true},
+		{protocol: "17", expected: true},
+		{protocol: "ICMP", expected: false},
+		{protocol: "2", expected: false},
+		{protocol: "47", expected: false},
+		{protocol: "ESP", expected: false},
+		{protocol: "", expected: false},
+	}
+
+	for _, testCase := range testCases {
+		l := &Log{Protocol: testCase.protocol}
+		assert.Equal(t, testCase.expected, l.HasTransportPorts(), testCase.protocol)
+	}
+}
+
+func TestParse_NonPortProtocols(t *testing.T) {
+	type TestCase struct {
+		line                   string
+		expectedProtocol       string
+		expectedProtocolNumber uint8
+		expectedExtra          map[string]string
+	}
+
+	testCases := []*TestCase{
+		{
+			// IGMP membership report with the router alert option
+			line:                   "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] IGMP: IN=enp0s3 OUT= MAC=01:00:5e:00:00:16:52:54:00:12:35:02:08:00 SRC=10.0.2.2 DST=224.0.0.22 LEN=40 TOS=0x00 PREC=0xC0 TTL=1 ID=0 DF OPT (94040000) PROTO=2",
+			expectedProtocol:       "2",
+			expectedProtocolNumber: 2,
+		},
+		{
+			// GRE, which is logged with the number
+			line:                   "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] GRE: IN=enp0s3 OUT= SRC=192.0.2.1 DST=10.0.2.15 LEN=88 TOS=0x00 PREC=0x00 TTL=64 ID=0 DF PROTO=47 ",
+			expectedProtocol:       "47",
+			expectedProtocolNumber: 47,
+		},
+		{
+			// the trailing fields after the protocol without the L4 fields
+			line:                   "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] GRE: IN=enp0s3 OUT= SRC=192.0.2.1 DST=10.0.2.15 LEN=88 TOS=0x00 PREC=0x00 TTL=64 ID=0 DF PROTO=GRE MARK=0x1 CT=NEW",
+			expectedProtocol:       "GRE",
+			expectedProtocolNumber: 47,
+			expectedExtra:          map[string]string{"CT": "NEW"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		parsedLog, err := Parse(testCase.line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, testCase.expectedProtocol, parsedLog.Protocol)
+		n, ok := parsedLog.ProtocolNumber()
+		assert.True(t, ok)
+		assert.Equal(t, testCase.expectedProtocolNumber, n)
+		assert.False(t, parsedLog.HasTransportPorts())
+		assert.False(t, parsedLog.Has(FieldSourcePort))
+		assert.False(t, parsedLog.Has(FieldDestinationPort))
+		assert.Equal(t, testCase.expectedExtra, parsedLog.Extra)
+		assert.NoError(t, parsedLog.Validate())
+	}
+}
+
+func TestParse_NumericProtocol(t *testing.T) {
+	// the protocol-specific fields are interpreted by the number as well as by the name
+	parsedLog, err := Parse("Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] IN=enp0s3 OUT= SRC=10.0.2.2 DST=10.0.2.15 LEN=44 TOS=0x00 PREC=0x00 TTL=64 ID=1 PROTO=6 SPT=40000 DPT=22 SEQ=100 ACK=0 WINDOW=65535 RES=0x00 SYN URGP=0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, parsedLog.Syn)
+	assert.Equal(t, uint64(100), parsedLog.Sequence)
+	assert.NoError(t, parsedLog.Validate())
+
+	parsedLog, err = Parse("Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] IN=enp0s3 OUT= SRC=10.0.2.2 DST=10.0.2.15 LEN=84 TOS=0x00 PREC=0x00 TTL=64 ID=1 PROTO=1 TYPE=8 CODE=0 ID=7 SEQ=3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, parsedLog.IsICMP())
+	id, seq, ok := parsedLog.ICMPEcho()
+	assert.True(t, ok)
+	assert.Equal(t, uint16(7), id)
+	assert.Equal(t, uint16(3), seq)
+	assert.False(t, parsedLog.Has(FieldSequence))
+
+	parsedLog, err = Parse("Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] IN=enp0s3 OUT= SRC=10.0.2.2 DST=10.0.2.15 LEN=100 TOS=0x00 PREC=0x00 TTL=64 ID=0 PROTO=50 SPI=0x1000 SEQ=42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, uint32(42), parsedLog.IPsecSequence)
+	assert.False(t, parsedLog.Has(FieldSequence))
+
+	parsedLog, err = Parse("Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] IN=enp0s3 OUT= SRC=10.0.2.2 DST=10.0.2.15 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=0 PROTO=136 SPT=5000 DPT=5001 LEN=8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, uint16(8), parsedLog.ChecksumCoverage)
+}
+
+func TestLog_ProtocolFields(t *testing.T) {
+	type TestCase struct {
+		protocol   string
+		expected   []Field
+		unexpected []Field
+	}
+
+	testCases := []*TestCase{
+		{
+			protocol:   "TCP",
+			expected:   []Field{FieldSourcePort, FieldSequence, FieldSyn, FieldNS, FieldTCPOption},
+			unexpected: []Field{FieldType, FieldICMPID, FieldChecksumCoverage, FieldSPI, FieldInner},
+		},
+		{
+			protocol:   "udp",
+			expected:   []Field{FieldSourcePort, FieldDestinationPort},
+			unexpected: []Field{FieldSequence, FieldSyn, FieldUrgp, FieldType, FieldChecksumCoverage},
+		},
+		{
+			protocol:   "UDPLITE",
+			expected:   []Field{FieldSourcePort, FieldChecksumCoverage},
+			unexpected: []Field{FieldSyn},
+		},
+		{
+			protocol:   "ICMPv6",
+			expected:   []Field{FieldType, FieldCode, FieldICMPID, FieldICMPSeq, FieldMTU, FieldInner},
+			unexpected: []Field{FieldSourcePort, FieldSyn},
+		},
+		{
+			protocol:   "AH",
+			expected:   []Field{FieldSPI, FieldIPsecSequence},
+			unexpected: []Field{FieldSourcePort, FieldGREKey},
+		},
+		{
+			protocol:   "47",
+			expected:   []Field{FieldGREKey, FieldGREProtocol},
+			unexpected: []Field{FieldSourcePort, FieldSPI},
+		},
+		{
+			protocol:   "IGMP",
+			unexpected: []Field{FieldSourcePort, FieldType, FieldSyn},
+		},
+		{
+			protocol: "",
+			expected: []Field{FieldSourcePort, FieldType, FieldSyn, FieldSPI, FieldGREKey, FieldInner},
+		},
+	}
+
+	for _, testCase := range testCases {
+		fields := (&Log{Protocol: testCase.protocol}).ProtocolFields()
+		// the common fields
+		for _, f := range []Field{FieldTimestamp, FieldSource, FieldDestination, FieldTTL, FieldProtocol, FieldMark, FieldExtra} {
+			assert.True(t, fields.Has(f), "%s: %s", testCase.protocol, f)
+		}
+		for _, f := range testCase.expected {
+			assert.True(t, fields.Has(f), "%s: %s", testCase.protocol, f)
+		}
+		for _, f := range testCase.unexpected {
+			assert.False(t, fields.Has(f), "%s: %s", testCase.protocol, f)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package iptables
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"iter"
+	"strings"
+)
+
+// DefaultMaxLineSize is the default maximum size of a line that ParseReader can handle.
+const DefaultMaxLineSize = bufio.MaxScanTokenSize
+
+// LineError is an error that occurs on parsing a line of a multi-line input.
+type LineError struct {
+	// Line is the 1-based line number of the line that failed to parse.
+	Line int
+	// Err is the underlying error.
+	Err error
+}
+
+// Error returns the error message with the line number.
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// ParseReader parses iptables lines read from the given reader lazily.
+// This is equivalent to ParseReaderSize(r, DefaultMaxLineSize).
+func ParseReader(r io.Reader) iter.Seq2[*Log, error] {
+	return defaultParser.ParseReaderSize(r, DefaultMaxLineSize)
+}
+
+// ParseReaderSize parses iptables lines read from the given reader lazily, with the maximum size of a line.
+// See Parser.ParseReaderSize for details.
+func ParseReaderSize(r io.Reader, maxLineSize int) iter.Seq2[*Log, error] {
+	return defaultParser.ParseReaderSize(r, maxLineSize)
+}
+
+// ParseReader parses iptables lines read from the given reader lazily.
+// This is equivalent to p.ParseReaderSize(r, DefaultMaxLineSize).
+func (p *Parser) ParseReader(r io.Reader) iter.Seq2[*Log, error] {
+	return p.ParseReaderSize(r, DefaultMaxLineSize)
+}
+
+// ParseReaderSize parses iptables lines read from the given reader lazily, with the maximum size of a line.
+// The sequence yields a parsed log, or a *LineError for a line that cannot be parsed; the caller can decide whether to continue by breaking the loop or not.
+// The *LineError is yielded along with the partially populated Log when WithCollectAllErrors is enabled and any field fails to be converted, as well as Parse.
+// Blank lines are skipped.
+// If reading from the reader fails (e.g. a line exceeds maxLineSize, which causes bufio.ErrTooLong), the sequence yields the error at last.
+// The maximum line length of the Parser (see WithMaxLineLength) still applies to each line, i.e. a line that fits in maxLineSize but exceeds that yields a *LineError of ErrLineTooLong.
+func (p *Parser) ParseReaderSize(r io.Reader, maxLineSize int) iter.Seq2[*Log, error] {
+	return func(yield func(*Log, error) bool) {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, min(maxLineSize, 4096)), maxLineSize)
+
+		lineNum := 0
+		for scanner.Scan() {
+			lineNum++
+
+			line := scanner.Text()
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+
+			parsedLog, err := p.Parse(line)
+			if err != nil {
+				if !yield(parsedLog, &LineError{Line: lineNum, Err: err}) {
+					return
+				}
+				continue
+			}
+			if !yield(parsedLog, nil) {
+				return
+			}
+		}
+
+		if err := scanner.Err(); err != nil {
+			yield(nil, &LineError{Line: lineNum + 1, Err: err})
+		}
+	}
+}
+
+// ParseReaderFunc parses iptables lines read from the given reader lazily, yielding only the logs that the keep predicate reports true for.
+// See Parser.ParseReaderFunc for details.
+func ParseReaderFunc(r io.Reader, keep func(*Log) bool) iter.Seq2[*Log, error] {
+	return defaultParser.ParseReaderFunc(r, keep)
+}
+
+// ParseReaderFunc parses iptables lines read from the given reader lazily, yielding only the logs that the keep predicate reports true for.
+// The predicate is called with each fully parsed log, so it can inspect any field.
+// The errors are yielded regardless of the predicate, as well as ParseReader does.
+func (p *Parser) ParseReaderFunc(r io.Reader, keep func(*Log) bool) iter.Seq2[*Log, error] {
+	return func(yield func(*Log, error) bool) {
+		for parsedLog, err := range p.ParseReader(r) {
+			if err == nil && !keep(parsedLog) {
+				continue
+			}
+			if !yield(parsedLog, err) {
+				return
+			}
+		}
+	}
+}
+
+// ParseReaderContext parses iptables lines read from the given reader lazily until the context is done; see Parser.ParseReaderContext for details.
+func ParseReaderContext(ctx context.Context, r io.Reader) iter.Seq2[*Log, error] {
+	return defaultParser.ParseReaderContext(ctx, r)
+}
+
+// ParseReaderContext parses iptables lines read from the given reader lazily until the context is done.
+// The context is checked between the lines, and the sequence ends with ctx.Err() when it is done; the logs yielded before that remain usable.
+// Note that a blocking read of the reader cannot be interrupted by the context, so close the reader as well to stop such a read promptly.
+func (p *Parser) ParseReaderContext(ctx context.Context, r io.Reader) iter.Seq2[*Log, error] {
+	return func(yield func(*Log, error) bool) {
+		if err := ctx.Err(); err != nil {
+			yield(nil, err)
+			return
+		}
+		for parsedLog, err := range p.ParseReader(r) {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				yield(nil, ctxErr)
+				return
+			}
+			if !yield(parsedLog, err) {
+				return
+			}
+		}
+	}
+}
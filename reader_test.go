@@ -0,0 +1,228 @@
+package iptables
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	readerTestTCPLine  = "Jul 20 13:24:22 ubuntu-jammy kernel: [  396.854443] IN=enp0s3 OUT= MAC=00:b3:dd:bc:29:e1:52:54:00:12:35:02:08:00 SRC=10.0.2.2 DST=10.0.2.15 LEN=76 TOS=0x00 PREC=0x00 TTL=64 ID=5525 PROTO=TCP SPT=59076 DPT=22 WINDOW=65535 RES=0x00 ACK PSH URGP=0"
+	readerTestICMPLine = "Jul 21 05:38:28 ubuntu-jammy kernel: [14879.600492] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=84 TOS=0x00 PREC=0x00 TTL=64 ID=6495 DF PROTO=ICMP TYPE=8 CODE=0 ID=1 SEQ=3"
+)
+
+func TestParseReader(t *testing.T) {
+	input := strings.Join([]string{
+		readerTestTCPLine,
+		"",
+		"this is not an iptables log",
+		readerTestICMPLine,
+	}, "\n")
+
+	var logs []*Log
+	var errs []error
+	for parsedLog, err := range ParseReader(strings.NewReader(input)) {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		logs = append(logs, parsedLog)
+	}
+
+	assert.Len(t, logs, 2)
+	assert.Equal(t, "TCP", logs[0].Protocol)
+	assert.Equal(t, "ICMP", logs[1].Protocol)
+
+	assert.Len(t, errs, 1)
+	var lineErr *LineError
+	assert.True(t, errors.As(errs[0], &lineErr))
+	assert.Equal(t, 3, lineErr.Line)
+	assert.True(t, errors.Is(errs[0], ErrLogFormatUnmatched))
+}
+
+func TestParseReader_Break(t *testing.T) {
+	input := strings.Join([]string{
+		"this is not an iptables log",
+		readerTestTCPLine,
+	}, "\n")
+
+	count := 0
+	for _, err := range ParseReader(strings.NewReader(input)) {
+		count++
+		if err != nil {
+			break
+		}
+	}
+	assert.Equal(t, 1, count)
+}
+
+func TestParseReaderSize(t *testing.T) {
+	longLine := readerTestTCPLine + " OPT (" + strings.Repeat("01", bufio.MaxScanTokenSize) + ")"
+
+	for _, err := range ParseReader(strings.NewReader(longLine)) {
+		assert.True(t, errors.Is(err, bufio.ErrTooLong))
+	}
+
+	// the maximum line length of the parser still applies
+	count := 0
+	for _, err := range ParseReaderSize(strings.NewReader(longLine), len(longLine)+1) {
+		count++
+		assert.ErrorIs(t, err, ErrLineTooLong)
+	}
+	assert.Equal(t, 1, count)
+
+	p, err := NewParser(WithMaxLineLength(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	count = 0
+	for parsedLog, err := range p.ParseReaderSize(strings.NewReader(longLine), len(longLine)+1) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		count++
+		assert.Equal(t, uint16(22), parsedLog.DestinationPort)
+	}
+	assert.Equal(t, 1, count)
+}
+
+func TestParseReader_MaxLineLength(t *testing.T) {
+	p, err := NewParser(WithMaxLineLength(len(readerTestICMPLine)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	longLine := readerTestICMPLine + strings.Repeat(" ", 300)
+	input := strings.Join([]string{readerTestICMPLine, longLine, readerTestICMPLine}, "\n")
+
+	var logs []*Log
+	var errs []error
+	for parsedLog, err := range p.ParseReader(strings.NewReader(input)) {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		logs = append(logs, parsedLog)
+	}
+	assert.Len(t, logs, 2)
+	if assert.Len(t, errs, 1) {
+		var lineErr *LineError
+		assert.ErrorAs(t, errs[0], &lineErr)
+		assert.Equal(t, 2, lineErr.Line)
+		assert.ErrorIs(t, errs[0], ErrLineTooLong)
+	}
+
+	// the other entry points by the reader respect the limit as well
+	errs = nil
+	for _, err := range p.ParseReaderFunc(strings.NewReader(input), func(*Log) bool { return true }) {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if assert.Len(t, errs, 1) {
+		assert.ErrorIs(t, errs[0], ErrLineTooLong)
+	}
+}
+
+func TestParseReader_WithCollectAllErrors(t *testing.T) {
+	p, err := NewParser(WithCollectAllErrors(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	malformedLine := strings.Replace(readerTestTCPLine, "TTL=64", "TTL=6x4", 1)
+	input := strings.Join([]string{malformedLine, "this is not an iptables log"}, "\n")
+
+	var logs []*Log
+	var errs []error
+	for parsedLog, err := range p.ParseReader(strings.NewReader(input)) {
+		logs = append(logs, parsedLog)
+		errs = append(errs, err)
+	}
+	if assert.Len(t, errs, 2) {
+		// the partially populated log is yielded along with the error
+		var lineErr *LineError
+		assert.ErrorAs(t, errs[0], &lineErr)
+		assert.Equal(t, 1, lineErr.Line)
+		assert.ErrorIs(t, errs[0], ErrStringToNumberConversionFailed)
+		if assert.NotNil(t, logs[0]) {
+			assert.Equal(t, uint16(22), logs[0].DestinationPort)
+		}
+
+		assert.ErrorIs(t, errs[1], ErrLogFormatUnmatched)
+		assert.Nil(t, logs[1])
+	}
+}
+
+func TestParseReaderFunc(t *testing.T) {
+	input := strings.Join([]string{
+		readerTestTCPLine,
+		"this is not an iptables log",
+		readerTestICMPLine,
+	}, "\n")
+
+	var logs []*Log
+	var errs []error
+	for parsedLog, err := range ParseReaderFunc(strings.NewReader(input), func(l *Log) bool {
+		return l.Protocol == "ICMP"
+	}) {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		logs = append(logs, parsedLog)
+	}
+
+	assert.Len(t, logs, 1)
+	assert.Equal(t, "ICMP", logs[0].Protocol)
+
+	// the errors are yielded regardless of the predicate
+	assert.Len(t, errs, 1)
+	assert.True(t, errors.Is(errs[0], ErrLogFormatUnmatched))
+}
+
+func TestParseReaderContext(t *testing.T) {
+	input := strings.Join([]string{
+		readerTestTCPLine,
+		readerTestICMPLine,
+		readerTestTCPLine,
+	}, "\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var logs []*Log
+	var errs []error
+	for parsedLog, err := range ParseReaderContext(ctx, strings.NewReader(input)) {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		logs = append(logs, parsedLog)
+		if len(logs) == 2 {
+			cancel()
+		}
+	}
+
+	// the logs before the cancellation remain usable
+	assert.Len(t, logs, 2)
+	assert.Equal(t, "TCP", logs[0].Protocol)
+	assert.Equal(t, "ICMP", logs[1].Protocol)
+	assert.Len(t, errs, 1)
+	assert.True(t, errors.Is(errs[0], context.Canceled))
+}
+
+func TestParseReaderContext_AlreadyDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	count := 0
+	for parsedLog, err := range ParseReaderContext(ctx, strings.NewReader(readerTestTCPLine)) {
+		count++
+		assert.Nil(t, parsedLog)
+		assert.True(t, errors.Is(err, context.Canceled))
+	}
+	assert.Equal(t, 1, count)
+}
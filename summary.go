@@ -0,0 +1,74 @@
+package iptables
+
+import (
+	"net"
+	"strconv"
+	"strings"
+)
+
+// String returns a concise one-line summary of the log for the human readers, e.g. "13:55:36 eth0→ DROP 1.2.3.4:1234 → 5.6.7.8:80 TCP SYN len=60".
+// The summary consists of the time, the interfaces (i.e. "IN→OUT"), the action (or the prefix if no action is recognized; see Log.Action), the endpoints, the protocol, and the protocol-specific fields (i.e. the TCP flags or the ICMP type and code), and the length. The absent fields are omitted.
+// This is not meant to be parsed by the machines; use Format for the round trip instead.
+// Unlike the tabular outputs, this doesn't follow DefaultFieldOrder, since the summary is not a list of the fields but the sentence-like flow of the packet that combines some of them (e.g. "IN→OUT" and "SRC:SPT → DST:DPT") and omits the others.
+func (l *Log) String() string {
+	if l == nil {
+		return "<nil>"
+	}
+
+	parts := make([]string, 0, 8)
+	if l.Has(FieldTimestamp) {
+		if !l.TimestampParsed.IsZero() {
+			parts = append(parts, l.TimestampParsed.Format("15:04:05"))
+		} else if l.Timestamp != "" {
+			parts = append(parts, l.Timestamp)
+		}
+	}
+	if l.InputInterface != "" || l.OutputInterface != "" {
+		parts = append(parts, l.InputInterface+"→"+l.OutputInterface)
+	}
+	if action := l.Action(); action != "" {
+		parts = append(parts, action)
+	} else if prefix := strings.TrimSpace(l.Prefix); prefix != "" {
+		parts = append(parts, prefix)
+	}
+
+	if l.Source != "" || l.Destination != "" {
+		hasSourcePort := l.HasTransportPorts() && l.Has(FieldSourcePort)
+		hasDestinationPort := l.HasTransportPorts() && l.Has(FieldDestinationPort)
+		parts = append(
+			parts,
+			summarizeEndpoint(l.Source, l.SourcePort, hasSourcePort),
+			"→",
+			summarizeEndpoint(l.Destination, l.DestinationPort, hasDestinationPort),
+		)
+	}
+
+	if l.Protocol != "" {
+		parts = append(parts, l.Protocol)
+	}
+	if flags := l.TCPFlags(); len(flags) > 0 {
+		parts = append(parts, strings.Join(flags, ","))
+	}
+	if l.IsICMP() && l.Has(FieldType) {
+		parts = append(parts, "type="+strconv.FormatInt(l.Type, 10))
+		if l.Has(FieldCode) {
+			parts = append(parts, "code="+strconv.FormatInt(l.Code, 10))
+		}
+	}
+	if l.Has(FieldLength) {
+		parts = append(parts, "len="+strconv.FormatUint(l.Length, 10))
+	}
+	return strings.Join(parts, " ")
+}
+
+// summarizeEndpoint returns the address with the port if it has the one, e.g. "1.2.3.4:1234" and "[2001:db8::1]:80".
+// The empty address is summarized as "?".
+func summarizeEndpoint(addr string, port uint16, hasPort bool) string {
+	if addr == "" {
+		addr = "?"
+	}
+	if !hasPort {
+		return addr
+	}
+	return net.JoinHostPort(addr, strconv.FormatUint(uint64(port), 10))
+}
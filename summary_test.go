@@ -0,0 +1,60 @@
+package iptables
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLog_String(t *testing.T) {
+	type TestCase struct {
+		name     string
+		line     string
+		expected string
+	}
+
+	testCases := []*TestCase{
+		{
+			name:     "TCP",
+			line:     "Jul 20 13:55:36 ubuntu-jammy kernel: [  396.854443] DROP: IN=eth0 OUT= SRC=1.2.3.4 DST=5.6.7.8 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=5525 DF PROTO=TCP SPT=1234 DPT=80 WINDOW=65535 RES=0x00 SYN URGP=0",
+			expected: "13:55:36 eth0→ DROP 1.2.3.4:1234 → 5.6.7.8:80 TCP SYN len=60",
+		},
+		{
+			name:     "TCP with the flags and without the action",
+			line:     "Jul 20 13:55:36 ubuntu-jammy kernel: [  396.854443] IN= OUT=eth0 SRC=2001:db8::1 DST=2001:db8::2 LEN=52 TC=0 HOPLIMIT=64 FLOWLBL=0 PROTO=TCP SPT=40000 DPT=443 WINDOW=512 RES=0x00 ACK FIN URGP=0",
+			expected: "13:55:36 →eth0 [2001:db8::1]:40000 → [2001:db8::2]:443 TCP FIN,ACK len=52",
+		},
+		{
+			name:     "ICMP",
+			line:     "Jul 21 05:38:28 ubuntu-jammy kernel: [14879.600492] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=84 TOS=0x00 PREC=0x00 TTL=64 ID=6495 DF PROTO=ICMP TYPE=8 CODE=0 ID=1 SEQ=3",
+			expected: "05:38:28 →enp0s3 OUT-LOG: 10.0.2.15 → 8.8.8.8 ICMP type=8 code=0 len=84",
+		},
+		{
+			name:     "forwarded UDP",
+			line:     "Jul 21 05:38:28 ubuntu-jammy kernel: [14879.600492] [UFW ALLOW] IN=eth0 OUT=eth1 SRC=10.0.2.15 DST=8.8.8.8 LEN=72 TOS=0x00 PREC=0x00 TTL=64 ID=6495 PROTO=UDP SPT=53000 DPT=53 LEN=52",
+			expected: "05:38:28 eth0→eth1 ACCEPT 10.0.2.15:53000 → 8.8.8.8:53 UDP len=72",
+		},
+	}
+
+	p, err := NewParser(WithYear(2022), WithLocation(time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, testCase := range testCases {
+		parsedLog, err := p.Parse(testCase.line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, testCase.expected, parsedLog.String(), testCase.name)
+		assert.Equal(t, testCase.expected, fmt.Sprint(parsedLog), testCase.name)
+	}
+}
+
+func TestLog_StringOmitsAbsentFields(t *testing.T) {
+	assert.Equal(t, "", (&Log{}).String())
+	assert.Equal(t, "ICMP", (&Log{Protocol: "ICMP"}).String())
+	assert.Equal(t, "? → 10.0.2.15", (&Log{Destination: "10.0.2.15"}).String())
+	assert.Equal(t, "<nil>", (*Log)(nil).String())
+}
@@ -0,0 +1,146 @@
+package iptables
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"iter"
+	"os"
+	"strings"
+	"time"
+)
+
+// tailPollInterval is the interval to check whether the file being tailed has grown or been rotated.
+var tailPollInterval = 250 * time.Millisecond
+
+// Tail follows the file of the given path like "tail -f"; see Parser.Tail for details.
+func Tail(ctx context.Context, path string) iter.Seq2[*Log, error] {
+	return defaultParser.Tail(ctx, path)
+}
+
+// Tail follows the file of the given path like "tail -f", i.e. it parses the existing lines of the file, and then waits for the new lines to be appended.
+// The sequence yields a parsed log, or a *LineError for a line that cannot be parsed, as well as ParseReader does.
+// A partial line at the end of the file is buffered until its newline arrives.
+// The rotation of the file is handled: when the file is truncated, it is read from the beginning again, and when the file is replaced (e.g. renamed and recreated), the new file at the path is opened.
+// The sequence ends with ctx.Err() when the context is done, or with the error of the file operation.
+func (p *Parser) Tail(ctx context.Context, path string) iter.Seq2[*Log, error] {
+	return func(yield func(*Log, error) bool) {
+		f, err := os.Open(path)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		defer func() {
+			_ = f.Close()
+		}()
+
+		ticker := time.NewTicker(tailPollInterval)
+		defer ticker.Stop()
+
+		reader := bufio.NewReader(f)
+		var offset int64
+		var partial strings.Builder
+		lineNum := 0
+
+		// emit parses the given line and yields the result; this returns false when the caller stops the iteration
+		emit := func(line string) bool {
+			lineNum++
+			line = strings.TrimRight(line, "\r\n")
+			if strings.TrimSpace(line) == "" {
+				return true
+			}
+			parsedLog, err := p.Parse(line)
+			if err != nil {
+				return yield(parsedLog, &LineError{Line: lineNum, Err: err})
+			}
+			return yield(parsedLog, nil)
+		}
+
+		for {
+			if err := ctx.Err(); err != nil {
+				yield(nil, err)
+				return
+			}
+
+			chunk, err := reader.ReadString('\n')
+			offset += int64(len(chunk))
+			if err == nil {
+				line := chunk
+				if partial.Len() > 0 {
+					partial.WriteString(chunk)
+					line = partial.String()
+					partial.Reset()
+				}
+				if !emit(line) {
+					return
+				}
+				continue
+			}
+			if !errors.Is(err, io.EOF) {
+				yield(nil, err)
+				return
+			}
+			partial.WriteString(chunk)
+
+			// reached the end of the file; wait for the file to grow or be rotated
+			select {
+			case <-ctx.Done():
+				yield(nil, ctx.Err())
+				return
+			case <-ticker.C:
+			}
+
+			current, err := f.Stat()
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			latest, err := os.Stat(path)
+			if err != nil {
+				// the file might be being rotated; wait for the new one to be created
+				continue
+			}
+
+			if os.SameFile(current, latest) {
+				if latest.Size() < offset {
+					// truncated
+					if _, err := f.Seek(0, io.SeekStart); err != nil {
+						yield(nil, err)
+						return
+					}
+					reader.Reset(f)
+					offset = 0
+					partial.Reset()
+					lineNum = 0
+				}
+				continue
+			}
+
+			// replaced; read the rest of the old file before switching to the new one
+			rest, err := io.ReadAll(reader)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			partial.Write(rest)
+			for _, line := range strings.SplitAfter(partial.String(), "\n") {
+				if line != "" && !emit(line) {
+					return
+				}
+			}
+			partial.Reset()
+
+			newFile, err := os.Open(path)
+			if err != nil {
+				// the new file might have been rotated again; retry at the next tick
+				continue
+			}
+			_ = f.Close()
+			f = newFile
+			reader.Reset(f)
+			offset = 0
+			lineNum = 0
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package iptables
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type tailResult struct {
+	log *Log
+	err error
+}
+
+func TestTail(t *testing.T) {
+	defer func(interval time.Duration) {
+		tailPollInterval = interval
+	}(tailPollInterval)
+	tailPollInterval = 10 * time.Millisecond
+
+	path := filepath.Join(t.TempDir(), "kern.log")
+	half := len(readerTestICMPLine) / 2
+	// the existing content that ends with a partial line
+	writeFile(t, path, readerTestTCPLine+"\n"+readerTestICMPLine[:half], os.O_CREATE|os.O_WRONLY)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	results := make(chan tailResult)
+	go func() {
+		defer close(results)
+		for l, err := range Tail(ctx, path) {
+			results <- tailResult{log: l, err: err}
+		}
+	}()
+
+	receive := func() tailResult {
+		select {
+		case r := <-results:
+			return r
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out")
+			return tailResult{}
+		}
+	}
+
+	r := receive()
+	assert.NoError(t, r.err)
+	assert.Equal(t, "TCP", r.log.Protocol)
+
+	// the partial line is completed
+	writeFile(t, path, readerTestICMPLine[half:]+"\n", os.O_APPEND|os.O_WRONLY)
+	r = receive()
+	assert.NoError(t, r.err)
+	assert.Equal(t, "ICMP", r.log.Protocol)
+	assert.Equal(t, int64(8), r.log.Type)
+
+	// a malformed line is yielded as an error
+	writeFile(t, path, "this is not an iptables log\n", os.O_APPEND|os.O_WRONLY)
+	r = receive()
+	assert.True(t, errors.Is(r.err, ErrLogFormatUnmatched))
+
+	// truncated
+	writeFile(t, path, readerTestTCPLine[:len(readerTestTCPLine)-len(" URGP=0")]+"\n", os.O_TRUNC|os.O_WRONLY)
+	r = receive()
+	assert.NoError(t, r.err)
+	assert.Equal(t, "TCP", r.log.Protocol)
+	assert.False(t, r.log.Has(FieldUrgp))
+
+	// rotated by renaming and recreating
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, path, readerTestICMPLine+"\n", os.O_CREATE|os.O_WRONLY)
+	r = receive()
+	assert.NoError(t, r.err)
+	assert.Equal(t, "ICMP", r.log.Protocol)
+
+	cancel()
+	r = receive()
+	assert.True(t, errors.Is(r.err, context.Canceled))
+	_, ok := <-results
+	assert.False(t, ok)
+}
+
+func TestTail_NotExist(t *testing.T) {
+	for _, err := range Tail(context.Background(), filepath.Join(t.TempDir(), "not-exist.log")) {
+		assert.True(t, errors.Is(err, os.ErrNotExist))
+	}
+}
+
+func writeFile(t *testing.T, path string, content string, flag int) {
+	t.Helper()
+	f, err := os.OpenFile(path, flag, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,100 @@
+package iptables
+
+// The bits of TCPFlagBits, which are the standard bit positions of the flags in the TCP header.
+const (
+	TCPFlagFIN uint16 = 1 << iota
+	TCPFlagSYN
+	TCPFlagRST
+	TCPFlagPSH
+	TCPFlagACK
+	TCPFlagURG
+	TCPFlagECE
+	TCPFlagCWR
+	TCPFlagNS
+)
+
+var tcpFlagNames = []struct {
+	bit  uint16
+	name string
+}{
+	{bit: TCPFlagFIN, name: "FIN"},
+	{bit: TCPFlagSYN, name: "SYN"},
+	{bit: TCPFlagRST, name: "RST"},
+	{bit: TCPFlagPSH, name: "PSH"},
+	{bit: TCPFlagACK, name: "ACK"},
+	{bit: TCPFlagURG, name: "URG"},
+	{bit: TCPFlagECE, name: "ECE"},
+	{bit: TCPFlagCWR, name: "CWR"},
+	{bit: TCPFlagNS, name: "NS"},
+}
+
+// TCPFlagBits returns the TCP flags as a bitmask; see the TCPFlag* constants for each bit.
+func (l *Log) TCPFlagBits() uint16 {
+	var bits uint16
+	if l.Fin {
+		bits |= TCPFlagFIN
+	}
+	if l.Syn {
+		bits |= TCPFlagSYN
+	}
+	if l.Reset {
+		bits |= TCPFlagRST
+	}
+	if l.Push {
+		bits |= TCPFlagPSH
+	}
+	if l.Ack {
+		bits |= TCPFlagACK
+	}
+	if l.Urgent {
+		bits |= TCPFlagURG
+	}
+	if l.ECE {
+		bits |= TCPFlagECE
+	}
+	if l.CWR {
+		bits |= TCPFlagCWR
+	}
+	if l.NS {
+		bits |= TCPFlagNS
+	}
+	return bits
+}
+
+// TCPFlags returns the names of the TCP flags that are set, e.g. []string{"SYN", "ACK"}.
+// The names are ordered by the bit position, from the lowest bit (i.e. FIN) to the highest one.
+// This returns an empty slice if no flag is set.
+func (l *Log) TCPFlags() []string {
+	bits := l.TCPFlagBits()
+	flags := make([]string, 0, len(tcpFlagNames))
+	for _, f := range tcpFlagNames {
+		if bits&f.bit != 0 {
+			flags = append(flags, f.name)
+		}
+	}
+	return flags
+}
+
+// The layout of the RES field.
+// The kernel logs the 4 reserved bits of the TCP header (i.e. the bits between the data offset and the CWR flag) shifted by 2, e.g. "RES=0x3C" when all of them are set.
+const (
+	// resShift is the shift of the reserved bits in the RES field.
+	resShift = 2
+	// reservedBitsMask is the mask of the reserved bits after the shift.
+	reservedBitsMask = 0x0f
+	// ReservedBitNS is the lowest reserved bit, which is the NS (ECN-nonce sum) flag of RFC 3540, reused as the AE (accurate ECN) flag.
+	ReservedBitNS uint8 = 1 << 0
+)
+
+// ReservedBits returns the 4 reserved bits of the TCP header that are decoded from the RES field, as the lowest 4 bits of the value.
+// The lowest bit is the NS flag (see ReservedBitNS), and the others are reserved for the future use, i.e. they should be zero.
+// Note that the ECE and CWR flags are not part of the RES field; they are logged as the bare flags (see Log.ECE and Log.CWR).
+func (l *Log) ReservedBits() uint8 {
+	return uint8(l.Res>>resShift) & reservedBitsMask
+}
+
+// HasReservedNS reports whether the NS flag is set in the RES field; see ReservedBitNS.
+// This is true even if the kernel doesn't log the NS flag as a bare flag (i.e. Log.NS is false).
+func (l *Log) HasReservedNS() bool {
+	return l.ReservedBits()&ReservedBitNS != 0
+}
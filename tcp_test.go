@@ -0,0 +1,191 @@
+package iptables
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLog_TCPFlags(t *testing.T) {
+	type TestCase struct {
+		log           *Log
+		expectedFlags []string
+		expectedBits  uint16
+	}
+
+	testCases := []*TestCase{
+		{
+			log:           &Log{Syn: true},
+			expectedFlags: []string{"SYN"},
+			expectedBits:  0x02,
+		},
+		{
+			log:           &Log{Syn: true, Ack: true},
+			expectedFlags: []string{"SYN", "ACK"},
+			expectedBits:  0x12,
+		},
+		{
+			log:           &Log{Urgent: true, Ack: true, Push: true, Reset: true, Syn: true, Fin: true},
+			expectedFlags: []string{"FIN", "SYN", "RST", "PSH", "ACK", "URG"},
+			expectedBits:  0x3f,
+		},
+		{
+			log:           &Log{Syn: true, ECE: true, CWR: true},
+			expectedFlags: []string{"SYN", "ECE", "CWR"},
+			expectedBits:  0xc2,
+		},
+		{
+			log:           &Log{Ack: true, NS: true},
+			expectedFlags: []string{"ACK", "NS"},
+			expectedBits:  0x110,
+		},
+		{
+			log:           &Log{},
+			expectedFlags: []string{},
+			expectedBits:  0,
+		},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.expectedFlags, testCase.log.TCPFlags())
+		assert.Equal(t, testCase.expectedBits, testCase.log.TCPFlagBits())
+	}
+}
+
+func TestLog_TCPFlags_Parsed(t *testing.T) {
+	parsedLog, err := Parse("2022-07-12T09:01:27.345918+00:00 ubuntu-jammy kernel: [ 1269.733882] IN=enp0s3 OUT= MAC=00:b3:dd:bc:29:e1:52:54:00:12:35:02:08:00 SRC=93.184.216.34 DST=10.0.2.15 LEN=44 TOS=0x00 PREC=0x00 TTL=64 ID=15989 PROTO=TCP SPT=80 DPT=54830 SEQ=153856001 ACK=1134538652 WINDOW=65535 RES=0x00 ACK SYN URGP=0 OPT (020405B4)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"SYN", "ACK"}, parsedLog.TCPFlags())
+	assert.Equal(t, TCPFlagSYN|TCPFlagACK, parsedLog.TCPFlagBits())
+}
+
+func TestParse_ECNFlags(t *testing.T) {
+	type TestCase struct {
+		line          string
+		expectedFlags []string
+	}
+
+	testCases := []*TestCase{
+		{
+			// ECN-setup SYN
+			line:          "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=93.184.216.34 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=64125 DF PROTO=TCP SPT=54832 DPT=80 SEQ=567002889 ACK=0 WINDOW=64240 RES=0x00 CWR ECE SYN URGP=0 OPT (020405B40402080A12A016080000000001030307)",
+			expectedFlags: []string{"SYN", "ECE", "CWR"},
+		},
+		{
+			// ECN-setup SYN-ACK
+			line:          "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.150112] IN-LOG: IN=enp0s3 OUT= MAC=00:b3:dd:bc:29:e1:52:54:00:12:35:02:08:00 SRC=93.184.216.34 DST=10.0.2.15 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=0 DF PROTO=TCP SPT=80 DPT=54832 SEQ=1134538651 ACK=567002890 WINDOW=65160 RES=0x00 ECE ACK SYN URGP=0 OPT (020405B40402080A2D6A1FE112A016080103030A)",
+			expectedFlags: []string{"SYN", "ACK", "ECE"},
+		},
+		{
+			// congestion window reduced
+			line:          "Jul 21 05:31:49 ubuntu-jammy kernel: [14480.002211] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=93.184.216.34 LEN=52 TOS=0x02 PREC=0x00 TTL=64 ID=64126 DF PROTO=TCP SPT=54832 DPT=80 SEQ=567002890 ACK=1134538652 WINDOW=502 RES=0x00 CWR ACK URGP=0",
+			expectedFlags: []string{"ACK", "CWR"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		parsedLog, err := Parse(testCase.line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, testCase.expectedFlags, parsedLog.TCPFlags())
+	}
+}
+
+func TestLog_ReservedBits(t *testing.T) {
+	type TestCase struct {
+		res                   uint64
+		expectedBits          uint8
+		expectedHasReservedNS bool
+	}
+
+	testCases := []*TestCase{
+		{res: 0x00, expectedBits: 0x00, expectedHasReservedNS: false},
+		{res: 0x04, expectedBits: 0x01, expectedHasReservedNS: true},
+		{res: 0x08, expectedBits: 0x02, expectedHasReservedNS: false},
+		{res: 0x3C, expectedBits: 0x0f, expectedHasReservedNS: true},
+	}
+
+	for _, testCase := range testCases {
+		l := &Log{Res: testCase.res}
+		assert.Equal(t, testCase.expectedBits, l.ReservedBits(), "RES=0x%02X", testCase.res)
+		assert.Equal(t, testCase.expectedHasReservedNS, l.HasReservedNS(), "RES=0x%02X", testCase.res)
+	}
+
+	parsedLog, err := Parse("Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=93.184.216.34 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=64125 DF PROTO=TCP SPT=54832 DPT=80 WINDOW=64240 RES=0x04 SYN URGP=0")
+	assert.NoError(t, err)
+	assert.True(t, parsedLog.HasReservedNS())
+}
+
+func TestParse_AckSequenceAndAckFlag(t *testing.T) {
+	type TestCase struct {
+		name                string
+		fields              string
+		expectedAckSequence uint64
+		expectedHasAckSeq   bool
+		expectedAck         bool
+		expectedSyn         bool
+	}
+
+	testCases := []*TestCase{
+		{
+			name:                "SYN-ACK has both",
+			fields:              "SEQ=153856001 ACK=1134538652 WINDOW=65535 RES=0x00 ACK SYN URGP=0",
+			expectedAckSequence: 1134538652,
+			expectedHasAckSeq:   true,
+			expectedAck:         true,
+			expectedSyn:         true,
+		},
+		{
+			name:                "SYN has the zero acknowledgment number without the flag",
+			fields:              "SEQ=567002889 ACK=0 WINDOW=64240 RES=0x00 SYN URGP=0",
+			expectedAckSequence: 0,
+			expectedHasAckSeq:   true,
+			expectedAck:         false,
+			expectedSyn:         true,
+		},
+		{
+			name:                "SYN without the acknowledgment number",
+			fields:              "SEQ=567002889 WINDOW=64240 RES=0x00 SYN URGP=0",
+			expectedAckSequence: 0,
+			expectedHasAckSeq:   false,
+			expectedAck:         false,
+			expectedSyn:         true,
+		},
+		{
+			name:                "the flag without the acknowledgment number",
+			fields:              "WINDOW=502 RES=0x00 ACK URGP=0",
+			expectedAckSequence: 0,
+			expectedHasAckSeq:   false,
+			expectedAck:         true,
+			expectedSyn:         false,
+		},
+		{
+			name:                "the flag precedes the acknowledgment number",
+			fields:              "WINDOW=502 ACK RES=0x00 URGP=0 SEQ=1 ACK=2",
+			expectedAckSequence: 2,
+			expectedHasAckSeq:   true,
+			expectedAck:         true,
+			expectedSyn:         false,
+		},
+		{
+			name:                "the empty acknowledgment number is not the flag",
+			fields:              "SEQ=1 ACK= WINDOW=502 RES=0x00 URGP=0",
+			expectedAckSequence: 0,
+			expectedHasAckSeq:   true,
+			expectedAck:         false,
+			expectedSyn:         false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		parsedLog, err := Parse("Jul 12 09:01:27 ubuntu-jammy kernel: [ 1269.733882] IN=enp0s3 OUT= SRC=93.184.216.34 DST=10.0.2.15 LEN=44 TOS=0x00 PREC=0x00 TTL=64 ID=15989 PROTO=TCP SPT=80 DPT=54830 " + testCase.fields)
+		assert.NoError(t, err, testCase.name)
+		assert.Equal(t, testCase.expectedAckSequence, parsedLog.AckSequence, testCase.name)
+		assert.Equal(t, testCase.expectedHasAckSeq, parsedLog.Has(FieldAckSequence), testCase.name)
+		assert.Equal(t, testCase.expectedAck, parsedLog.Ack, testCase.name)
+		assert.Equal(t, testCase.expectedSyn, parsedLog.Syn, testCase.name)
+	}
+}
@@ -0,0 +1,138 @@
+package iptables
+
+import (
+	"encoding/binary"
+)
+
+// The kinds of the TCP options.
+const (
+	TCPOptionEndOfList     uint8 = 0
+	TCPOptionNoOperation   uint8 = 1
+	TCPOptionMSS           uint8 = 2
+	TCPOptionWindowScale   uint8 = 3
+	TCPOptionSACKPermitted uint8 = 4
+	TCPOptionSACK          uint8 = 5
+	TCPOptionTimestamps    uint8 = 8
+)
+
+var tcpOptionNames = map[uint8]string{
+	TCPOptionEndOfList:     "End of Option List",
+	TCPOptionNoOperation:   "No Operation",
+	TCPOptionMSS:           "Maximum Segment Size",
+	TCPOptionWindowScale:   "Window Scale",
+	TCPOptionSACKPermitted: "SACK Permitted",
+	TCPOptionSACK:          "SACK",
+	TCPOptionTimestamps:    "Timestamps",
+}
+
+// SACKBlock is a block of the SACK option, i.e. the range of the received sequence numbers.
+type SACKBlock struct {
+	Left  uint32
+	Right uint32
+}
+
+// TCPOption is an option of the TCP header.
+// The fields of the known kinds are decoded only when the length of the option is valid for the kind; otherwise, only Kind, Length, and Data are populated.
+type TCPOption struct {
+	// Kind is the kind of the option; see the TCPOption* constants.
+	Kind uint8
+	// Length is the length of the option including the kind and the length octets, as it is declared in the option.
+	// This is 1 for the single-octet options, i.e. End of Option List and No Operation.
+	Length uint8
+	// Data is the raw data of the option, which excludes the kind and the length octets.
+	Data []byte
+	// MSS is the maximum segment size of the MSS option.
+	MSS uint16
+	// WindowScale is the shift count of the Window Scale option.
+	WindowScale uint8
+	// SACKBlocks is the blocks of the SACK option.
+	SACKBlocks []SACKBlock
+	// TSval is the timestamp value of the Timestamps option.
+	TSval uint32
+	// TSecr is the timestamp echo reply of the Timestamps option.
+	TSecr uint32
+}
+
+// Name returns the name of the option kind, e.g. "Window Scale". This returns an empty string for the unknown kind.
+func (o TCPOption) Name() string {
+	return tcpOptionNames[o.Kind]
+}
+
+// TCPOptionsDecoded decodes Log.TCPOption (i.e. the hex of "OPT (...)" after PROTO) into the TCP options.
+// The options of the unknown kinds and the malformed ones (e.g. truncated) are returned with their raw data, rather than failing.
+// This returns nil when there is no option or the hex is invalid.
+func (l *Log) TCPOptionsDecoded() []TCPOption {
+	tlvs := decodeOptionTLVs(l.TCPOption)
+	if tlvs == nil {
+		return nil
+	}
+	tcpOptions := make([]TCPOption, len(tlvs))
+	for i, tlv := range tlvs {
+		tcpOptions[i] = decodeTCPOption(tlv)
+	}
+	return tcpOptions
+}
+
+func decodeTCPOption(tlv optionTLV) TCPOption {
+	o := TCPOption{Kind: tlv.kind, Length: tlv.length, Data: tlv.data}
+	if int(tlv.length) != len(tlv.data)+2 {
+		// the single-octet options, or the malformed ones whose data is truncated
+		return o
+	}
+
+	data := tlv.data
+	switch tlv.kind {
+	case TCPOptionMSS:
+		if len(data) == 2 {
+			o.MSS = binary.BigEndian.Uint16(data)
+		}
+	case TCPOptionWindowScale:
+		if len(data) == 1 {
+			o.WindowScale = data[0]
+		}
+	case TCPOptionSACK:
+		if len(data) > 0 && len(data)%8 == 0 {
+			o.SACKBlocks = make([]SACKBlock, 0, len(data)/8)
+			for i := 0; i < len(data); i += 8 {
+				o.SACKBlocks = append(o.SACKBlocks, SACKBlock{
+					Left:  binary.BigEndian.Uint32(data[i : i+4]),
+					Right: binary.BigEndian.Uint32(data[i+4 : i+8]),
+				})
+			}
+		}
+	case TCPOptionTimestamps:
+		if len(data) == 8 {
+			o.TSval = binary.BigEndian.Uint32(data[0:4])
+			o.TSecr = binary.BigEndian.Uint32(data[4:8])
+		}
+	}
+	return o
+}
+
+// maxWindowScale is the maximum shift count of the Window Scale option (RFC 7323).
+const maxWindowScale = 14
+
+// ScaledWindow returns Log.WindowSize shifted by the given shift count, which is the one negotiated by the Window Scale options in the handshake of the connection (see AdvertisedWindowScale).
+// The window of a SYN segment (including SYN/ACK) is never scaled (RFC 7323, Section 2.2), so this returns the raw Log.WindowSize for it regardless of the shift count.
+// The shift count greater than 14 is regarded as 14, as RFC 7323 specifies.
+func (l *Log) ScaledWindow(shift uint) uint64 {
+	if l.Syn {
+		return l.WindowSize
+	}
+	if shift > maxWindowScale {
+		shift = maxWindowScale
+	}
+	return l.WindowSize << shift
+}
+
+// AdvertisedWindowScale returns the shift count of the Window Scale option in the TCP options, which is carried only by SYN segments.
+// The second return value is false if the option is absent.
+// Note that the shift count applies to the subsequent segments of the sender only when both sides of the connection advertise the option; pass it to ScaledWindow of those segments.
+func (l *Log) AdvertisedWindowScale() (uint8, bool) {
+	for _, o := range l.TCPOptionsDecoded() {
+		if o.Kind == TCPOptionWindowScale && len(o.Data) == 1 {
+			return o.WindowScale, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,169 @@
+package iptables
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLog_TCPOptionsDecoded(t *testing.T) {
+	type TestCase struct {
+		tcpOption string
+		expected  []TCPOption
+	}
+
+	testCases := []*TestCase{
+		{
+			// MSS, SACK Permitted, Timestamps, No Operation, and Window Scale of a typical SYN of Linux
+			tcpOption: "020405B40402080A0016A1B2000000000103030A",
+			expected: []TCPOption{
+				{Kind: TCPOptionMSS, Length: 4, Data: []byte{0x05, 0xb4}, MSS: 1460},
+				{Kind: TCPOptionSACKPermitted, Length: 2, Data: []byte{}},
+				{Kind: TCPOptionTimestamps, Length: 10, Data: []byte{0x00, 0x16, 0xa1, 0xb2, 0x00, 0x00, 0x00, 0x00}, TSval: 1483186, TSecr: 0},
+				{Kind: TCPOptionNoOperation, Length: 1, Data: []byte{}},
+				{Kind: TCPOptionWindowScale, Length: 3, Data: []byte{0x0a}, WindowScale: 10},
+			},
+		},
+		{
+			// No Operation, No Operation, and SACK with two blocks
+			tcpOption: "0101051200000064000000C8000001F4000003E8",
+			expected: []TCPOption{
+				{Kind: TCPOptionNoOperation, Length: 1, Data: []byte{}},
+				{Kind: TCPOptionNoOperation, Length: 1, Data: []byte{}},
+				{
+					Kind:       TCPOptionSACK,
+					Length:     18,
+					Data:       []byte{0x00, 0x00, 0x00, 0x64, 0x00, 0x00, 0x00, 0xc8, 0x00, 0x00, 0x01, 0xf4, 0x00, 0x00, 0x03, 0xe8},
+					SACKBlocks: []SACKBlock{{Left: 100, Right: 200}, {Left: 500, Right: 1000}},
+				},
+			},
+		},
+		{
+			// unknown kind (TCP Fast Open cookie)
+			tcpOption: "220A0102030405060708",
+			expected: []TCPOption{
+				{Kind: 0x22, Length: 10, Data: []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08}},
+			},
+		},
+		{
+			// MSS with the wrong length
+			tcpOption: "020305",
+			expected: []TCPOption{
+				{Kind: TCPOptionMSS, Length: 3, Data: []byte{0x05}},
+			},
+		},
+		{
+			// truncated Timestamps
+			tcpOption: "080A0016A1B2",
+			expected: []TCPOption{
+				{Kind: TCPOptionTimestamps, Length: 10, Data: []byte{0x00, 0x16, 0xa1, 0xb2}},
+			},
+		},
+		{
+			tcpOption: "",
+			expected:  nil,
+		},
+	}
+
+	for _, testCase := range testCases {
+		l := &Log{TCPOption: testCase.tcpOption}
+		assert.Equal(t, testCase.expected, l.TCPOptionsDecoded())
+	}
+}
+
+func TestTCPOption_Name(t *testing.T) {
+	assert.Equal(t, "Window Scale", TCPOption{Kind: TCPOptionWindowScale}.Name())
+	assert.Equal(t, "Timestamps", TCPOption{Kind: TCPOptionTimestamps}.Name())
+	assert.Equal(t, "", TCPOption{Kind: 0x22}.Name())
+}
+
+func TestParse_TCPOptionsDecoded(t *testing.T) {
+	line := "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] IN= OUT=enp0s3 SRC=10.0.2.15 DST=93.184.216.34 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=51234 DF PROTO=TCP SPT=54830 DPT=80 WINDOW=64240 RES=0x00 SYN URGP=0 OPT (020405B40402080A0016A1B2000000000103030A)"
+
+	parsedLog, err := Parse(line)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tcpOptions := parsedLog.TCPOptionsDecoded()
+	assert.Len(t, tcpOptions, 5)
+	assert.Equal(t, uint16(1460), tcpOptions[0].MSS)
+	assert.Equal(t, uint32(1483186), tcpOptions[2].TSval)
+	assert.Equal(t, uint8(10), tcpOptions[4].WindowScale)
+}
+
+func TestLog_ScaledWindow(t *testing.T) {
+	type TestCase struct {
+		windowSize uint64
+		syn        bool
+		shift      uint
+		expected   uint64
+	}
+
+	testCases := []*TestCase{
+		{windowSize: 502, shift: 0, expected: 502},
+		{windowSize: 502, shift: 7, expected: 64256},
+		// the shift count is capped at 14
+		{windowSize: 1, shift: 20, expected: 16384},
+		// the window of SYN is never scaled
+		{windowSize: 64240, syn: true, shift: 7, expected: 64240},
+	}
+
+	for _, testCase := range testCases {
+		l := &Log{WindowSize: testCase.windowSize, Syn: testCase.syn}
+		assert.Equal(t, testCase.expected, l.ScaledWindow(testCase.shift))
+	}
+}
+
+func TestLog_AdvertisedWindowScale(t *testing.T) {
+	type TestCase struct {
+		line          string
+		expected      uint8
+		expectedFound bool
+	}
+
+	testCases := []*TestCase{
+		{
+			line:          "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] IN= OUT=enp0s3 SRC=10.0.2.15 DST=93.184.216.34 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=51234 DF PROTO=TCP SPT=54830 DPT=80 WINDOW=64240 RES=0x00 SYN URGP=0 OPT (020405B40402080A0016A1B20000000001030307)",
+			expected:      7,
+			expectedFound: true,
+		},
+		{
+			// without the Window Scale option
+			line:          "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] IN= OUT=enp0s3 SRC=10.0.2.15 DST=93.184.216.34 LEN=44 TOS=0x00 PREC=0x00 TTL=64 ID=51234 DF PROTO=TCP SPT=54830 DPT=80 WINDOW=64240 RES=0x00 SYN URGP=0 OPT (020405B4)",
+			expected:      0,
+			expectedFound: false,
+		},
+		{
+			line:          "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] IN= OUT=enp0s3 SRC=10.0.2.15 DST=93.184.216.34 LEN=40 TOS=0x00 PREC=0x00 TTL=64 ID=51234 DF PROTO=TCP SPT=54830 DPT=80 WINDOW=502 RES=0x00 ACK URGP=0",
+			expected:      0,
+			expectedFound: false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		parsedLog, err := Parse(testCase.line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		shift, found := parsedLog.AdvertisedWindowScale()
+		assert.Equal(t, testCase.expected, shift)
+		assert.Equal(t, testCase.expectedFound, found)
+	}
+}
+
+func TestLog_ScaledWindow_Handshake(t *testing.T) {
+	syn, err := Parse("Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] IN= OUT=enp0s3 SRC=10.0.2.15 DST=93.184.216.34 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=51234 DF PROTO=TCP SPT=54830 DPT=80 WINDOW=64240 RES=0x00 SYN URGP=0 OPT (020405B40402080A0016A1B20000000001030307)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ack, err := Parse("Jul 21 05:31:48 ubuntu-jammy kernel: [14479.142228] IN= OUT=enp0s3 SRC=10.0.2.15 DST=93.184.216.34 LEN=40 TOS=0x00 PREC=0x00 TTL=64 ID=51235 DF PROTO=TCP SPT=54830 DPT=80 WINDOW=502 RES=0x00 ACK URGP=0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	shift, found := syn.AdvertisedWindowScale()
+	assert.True(t, found)
+	// the window of the SYN itself is not scaled, but the subsequent segments are
+	assert.Equal(t, uint64(64240), syn.ScaledWindow(uint(shift)))
+	assert.Equal(t, uint64(502<<7), ack.ScaledWindow(uint(shift)))
+}
@@ -0,0 +1,121 @@
+package iptables
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// syslogTimestampLayout is the layout of the classic BSD syslog timestamp (RFC 3164).
+// Fractional seconds that follow the seconds field are accepted as well on parsing.
+const syslogTimestampLayout = "Jan _2 15:04:05"
+
+// parseTimestamp parses the syslog timestamp, which is either of the RFC 5424 (ISO 8601) one (e.g. "2024-10-10T13:55:36.123456+09:00") or the classic one.
+// The RFC 5424 timestamp carries the year and the offset by itself, so the year and the location of the options are not used for it.
+func parseTimestamp(timestamp string, o *options) (time.Time, error) {
+	if isRFC5424Timestamp(timestamp) {
+		t, err := time.Parse(time.RFC3339Nano, timestamp)
+		if err != nil {
+			return time.Time{}, err
+		}
+		// time.Parse uses time.Local when the offset agrees with it; this makes the location independent of the environment
+		_, offset := t.Zone()
+		if offset == 0 {
+			return t.In(time.UTC), nil
+		}
+		return t.In(time.FixedZone("", offset)), nil
+	}
+
+	// the collectors can re-indent the timestamp with the tabs and the multiple spaces, e.g. "Jul\t21  05:38:28"
+	t, err := time.ParseInLocation(syslogTimestampLayout, strings.Join(strings.Fields(timestamp), " "), o.location)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return completeYear(t, o.year, time.Now().In(o.location))
+}
+
+// completeYear returns the given time of the classic syslog timestamp, which lacks the year, in the given year.
+// If the year is zero, it is inferred from now: the current year, or the previous one if the time is more than a day ahead of now (e.g. "Dec 31" parsed on Jan 1), since a log is not from the future; the day of grace absorbs the skew of the clocks and the time zones.
+// This returns an error for the date that doesn't exist in the year (i.e. "Feb 29" in a non-leap year), rather than normalizing it into "Mar 1".
+func completeYear(t time.Time, year int, now time.Time) (time.Time, error) {
+	date := func(year int) time.Time {
+		return time.Date(year, t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	}
+
+	completed := date(year)
+	if year == 0 {
+		completed = date(now.Year())
+		if completed.Sub(now) > 24*time.Hour {
+			completed = date(now.Year() - 1)
+		}
+	}
+	if completed.Month() != t.Month() || completed.Day() != t.Day() {
+		return time.Time{}, fmt.Errorf("%s %d doesn't exist in %d", t.Month(), t.Day(), completed.Year())
+	}
+	return completed, nil
+}
+
+// isRFC5424Timestamp reports whether the given timestamp looks like the RFC 5424 one, i.e. it begins with the full date "YYYY-MM-DD".
+func isRFC5424Timestamp(timestamp string) bool {
+	if len(timestamp) < len("2006-01-02") {
+		return false
+	}
+	for i := 0; i < len("2006-01-02"); i++ {
+		c := timestamp[i]
+		if i == 4 || i == 7 {
+			if c != '-' {
+				return false
+			}
+			continue
+		}
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// AbsoluteTime returns the time when the packet was logged, which is computed from the given boot time and the kernel timestamp (i.e. the seconds since boot).
+// This preserves the sub-second precision of the kernel timestamp, which the syslog timestamp might lack.
+// Note that the kernel timestamp doesn't advance while the system is suspended, so the result drifts after suspension.
+func (l *Log) AbsoluteTime(bootTime time.Time) time.Time {
+	return bootTime.Add(time.Duration(math.Round(l.KernelTimestamp * float64(time.Second))))
+}
+
+// BootTime returns the boot time of the running system, which is read from /proc/stat.
+// This is available only on Linux; on the other systems this returns an error.
+func BootTime() (time.Time, error) {
+	f, err := os.Open("/proc/stat")
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer f.Close()
+	return parseBootTime(f)
+}
+
+// parseBootTime reads the boot time from the "btime" line of the content of /proc/stat.
+func parseBootTime(r io.Reader) (time.Time, error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		rawBootTime, found := strings.CutPrefix(scanner.Text(), "btime ")
+		if !found {
+			continue
+		}
+		bootTime, err := strconv.ParseInt(strings.TrimSpace(rawBootTime), 10, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.Unix(bootTime, 0), nil
+	}
+	if err := scanner.Err(); err != nil {
+		return time.Time{}, err
+	}
+	return time.Time{}, errors.New("btime is not found in /proc/stat")
+}
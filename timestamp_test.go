@@ -0,0 +1,216 @@
+package iptables
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseWithOptions_Timestamp(t *testing.T) {
+	jst := time.FixedZone("JST", 9*60*60)
+
+	type TestCase struct {
+		line     string
+		opts     []Option
+		expected time.Time
+	}
+
+	testCases := []*TestCase{
+		{
+			line:     "Jul 21 05:38:28 ubuntu-jammy kernel: [14879.600492] IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=84 TOS=0x00 PREC=0x00 TTL=64 ID=6495 DF PROTO=ICMP TYPE=8 CODE=0 ID=1 SEQ=3",
+			opts:     []Option{WithYear(2022), WithLocation(time.UTC)},
+			expected: time.Date(2022, time.July, 21, 5, 38, 28, 0, time.UTC),
+		},
+		{
+			// the day of month is padded with a space
+			line:     "Jul  1 05:38:28 ubuntu-jammy kernel: [14879.600492] IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=84 TOS=0x00 PREC=0x00 TTL=64 ID=6495 DF PROTO=ICMP TYPE=8 CODE=0 ID=1 SEQ=3",
+			opts:     []Option{WithYear(2021), WithLocation(jst)},
+			expected: time.Date(2021, time.July, 1, 5, 38, 28, 0, jst),
+		},
+		{
+			// high precision timestamp
+			line:     "Jul 21 05:38:28.123456 ubuntu-jammy kernel: [14879.600492] IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=84 TOS=0x00 PREC=0x00 TTL=64 ID=6495 DF PROTO=ICMP TYPE=8 CODE=0 ID=1 SEQ=3",
+			opts:     []Option{WithYear(2022), WithLocation(time.UTC)},
+			expected: time.Date(2022, time.July, 21, 5, 38, 28, 123456000, time.UTC),
+		},
+		{
+			// RFC 5424 timestamp; the year and the location of the options are not used
+			line:     "2024-10-10T13:55:36.123456+09:00 ubuntu-jammy kernel: [14879.600492] IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=84 TOS=0x00 PREC=0x00 TTL=64 ID=6495 DF PROTO=ICMP TYPE=8 CODE=0 ID=1 SEQ=3",
+			opts:     []Option{WithYear(2022), WithLocation(time.UTC)},
+			expected: time.Date(2024, time.October, 10, 13, 55, 36, 123456000, time.FixedZone("", 9*60*60)),
+		},
+		{
+			line:     "2024-10-10T04:55:36Z ubuntu-jammy kernel: [14879.600492] IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=84 TOS=0x00 PREC=0x00 TTL=64 ID=6495 DF PROTO=ICMP TYPE=8 CODE=0 ID=1 SEQ=3",
+			expected: time.Date(2024, time.October, 10, 4, 55, 36, 0, time.UTC),
+		},
+		{
+			// uninterpretable timestamp
+			line:     "yesterday ubuntu-jammy kernel: [14879.600492] IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=84 TOS=0x00 PREC=0x00 TTL=64 ID=6495 DF PROTO=ICMP TYPE=8 CODE=0 ID=1 SEQ=3",
+			opts:     []Option{WithYear(2022), WithLocation(time.UTC)},
+			expected: time.Time{},
+		},
+	}
+
+	for _, testCase := range testCases {
+		parsedLog, err := ParseWithOptions(testCase.line, testCase.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, testCase.expected, parsedLog.TimestampParsed)
+	}
+}
+
+func TestParseWithOptions_StrictTimestamp(t *testing.T) {
+	line := "yesterday ubuntu-jammy kernel: [14879.600492] IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=84 TOS=0x00 PREC=0x00 TTL=64 ID=6495 DF PROTO=ICMP TYPE=8 CODE=0 ID=1 SEQ=3"
+
+	_, err := ParseWithOptions(line, WithStrict(true))
+	assert.True(t, errors.Is(err, ErrTimestampParseFailed))
+}
+
+func TestParseWithOptions_StrictRFC5424Timestamp(t *testing.T) {
+	line := "2024-10-10T25:55:36+09:00 ubuntu-jammy kernel: [14879.600492] IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=84 TOS=0x00 PREC=0x00 TTL=64 ID=6495 DF PROTO=ICMP TYPE=8 CODE=0 ID=1 SEQ=3"
+
+	_, err := ParseWithOptions(line, WithStrict(true))
+	assert.True(t, errors.Is(err, ErrTimestampParseFailed))
+}
+
+func TestParseWithOptions_NilLocation(t *testing.T) {
+	_, err := ParseWithOptions("", WithLocation(nil))
+	assert.Error(t, err)
+}
+
+func TestLog_AbsoluteTime(t *testing.T) {
+	bootTime := time.Date(2022, time.July, 21, 1, 30, 0, 0, time.UTC)
+
+	type TestCase struct {
+		kernelTimestamp float64
+		expected        time.Time
+	}
+
+	testCases := []*TestCase{
+		{
+			kernelTimestamp: 14879.600492,
+			expected:        time.Date(2022, time.July, 21, 5, 37, 59, 600492000, time.UTC),
+		},
+		{
+			kernelTimestamp: 0,
+			expected:        bootTime,
+		},
+	}
+
+	for _, testCase := range testCases {
+		l := &Log{KernelTimestamp: testCase.kernelTimestamp}
+		assert.Equal(t, testCase.expected, l.AbsoluteTime(bootTime))
+	}
+}
+
+func TestParseBootTime(t *testing.T) {
+	stat := "cpu  1 2 3 4 5 6 7 0 0 0\nintr 12345\nctxt 67890\nbtime 1658367000\nprocesses 42\n"
+
+	bootTime, err := parseBootTime(strings.NewReader(stat))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int64(1658367000), bootTime.Unix())
+
+	_, err = parseBootTime(strings.NewReader("cpu  1 2 3 4 5 6 7 0 0 0\n"))
+	assert.Error(t, err)
+}
+
+func TestCompleteYear(t *testing.T) {
+	jst := time.FixedZone("JST", 9*60*60)
+	at := func(month time.Month, day int) time.Time {
+		return time.Date(0, month, day, 23, 59, 59, 0, jst)
+	}
+
+	type TestCase struct {
+		t           time.Time
+		year        int
+		now         time.Time
+		expected    time.Time
+		expectedErr bool
+	}
+
+	testCases := []*TestCase{
+		{
+			t:        at(time.July, 21),
+			now:      time.Date(2024, time.October, 15, 12, 0, 0, 0, jst),
+			expected: time.Date(2024, time.July, 21, 23, 59, 59, 0, jst),
+		},
+		{
+			// the last day of the previous year, parsed on New Year
+			t:        at(time.December, 31),
+			now:      time.Date(2025, time.January, 1, 0, 0, 10, 0, jst),
+			expected: time.Date(2024, time.December, 31, 23, 59, 59, 0, jst),
+		},
+		{
+			// within the day of grace for the skew of the clocks
+			t:        at(time.October, 16),
+			now:      time.Date(2024, time.October, 16, 0, 0, 0, 0, jst),
+			expected: time.Date(2024, time.October, 16, 23, 59, 59, 0, jst),
+		},
+		{
+			// beyond the day of grace
+			t:        at(time.October, 16),
+			now:      time.Date(2024, time.October, 15, 12, 0, 0, 0, jst),
+			expected: time.Date(2023, time.October, 16, 23, 59, 59, 0, jst),
+		},
+		{
+			// the given year is used as it is
+			t:        at(time.December, 31),
+			year:     2025,
+			now:      time.Date(2025, time.January, 1, 0, 0, 10, 0, jst),
+			expected: time.Date(2025, time.December, 31, 23, 59, 59, 0, jst),
+		},
+		{
+			t:        at(time.February, 29),
+			now:      time.Date(2024, time.March, 1, 0, 0, 0, 0, jst),
+			expected: time.Date(2024, time.February, 29, 23, 59, 59, 0, jst),
+		},
+		{
+			// the last leap day, parsed before the date of the current year
+			t:        at(time.February, 29),
+			now:      time.Date(2025, time.January, 1, 0, 0, 0, 0, jst),
+			expected: time.Date(2024, time.February, 29, 23, 59, 59, 0, jst),
+		},
+		{
+			t:           at(time.February, 29),
+			now:         time.Date(2025, time.July, 1, 0, 0, 0, 0, jst),
+			expectedErr: true,
+		},
+		{
+			t:           at(time.February, 29),
+			year:        2023,
+			expectedErr: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		completed, err := completeYear(testCase.t, testCase.year, testCase.now)
+		if testCase.expectedErr {
+			assert.Error(t, err, "%s, now = %s", testCase.t, testCase.now)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, testCase.expected, completed, "%s, now = %s", testCase.t, testCase.now)
+	}
+}
+
+func TestParseWithOptions_LeapDay(t *testing.T) {
+	line := "Feb 29 05:38:28 ubuntu-jammy kernel: [14879.600492] IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=84 TOS=0x00 PREC=0x00 TTL=64 ID=6495 DF PROTO=ICMP TYPE=8 CODE=0 ID=1 SEQ=3"
+
+	parsedLog, err := ParseWithOptions(line, WithYear(2024), WithLocation(time.UTC))
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, time.February, 29, 5, 38, 28, 0, time.UTC), parsedLog.TimestampParsed)
+
+	// it is not normalized into Mar 1
+	parsedLog, err = ParseWithOptions(line, WithYear(2023), WithLocation(time.UTC))
+	assert.NoError(t, err)
+	assert.True(t, parsedLog.TimestampParsed.IsZero())
+
+	_, err = ParseWithOptions(line, WithYear(2023), WithStrict(true))
+	assert.ErrorIs(t, err, ErrTimestampParseFailed)
+}
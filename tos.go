@@ -0,0 +1,62 @@
+package iptables
+
+import (
+	"fmt"
+)
+
+// The ECN code points of the lower 2 bits of the ToS (RFC 3168), which ECN returns.
+const (
+	ECNNotECT uint8 = 0b00
+	ECNECT1   uint8 = 0b01
+	ECNECT0   uint8 = 0b10
+	ECNCE     uint8 = 0b11
+)
+
+// trafficClass returns the ToS byte of IPv4 or the traffic class of IPv6, which share the layout of DSCP and ECN.
+// The kernel splits the ToS byte of IPv4 into TOS (masked by IPTOS_TOS_MASK, i.e. 0x1E) and PREC (masked by IPTOS_PREC_MASK, i.e. 0xE0), so the byte is rebuilt from both of them.
+func (l *Log) trafficClass() uint8 {
+	if l.IsIPv6 {
+		return l.TrafficClass
+	}
+	return l.ToS | l.Precedence
+}
+
+// DSCP returns the differentiated services code point (RFC 2474), i.e. the upper 6 bits of the ToS byte, which is rebuilt from TOS and PREC; e.g. 46 (EF) of "TOS=0x18 PREC=0xA0".
+// For IPv6, this is derived from the traffic class (i.e. TC) instead, which has the same layout.
+func (l *Log) DSCP() uint8 {
+	return l.trafficClass() >> 2
+}
+
+// ECN returns the explicit congestion notification (RFC 3168), i.e. the lower 2 bits of the ToS byte; see the ECN* constants.
+// For IPv4, the kernel masks out the lowest bit of the ToS byte on logging, so ECNECT1 reads as ECNNotECT and ECNCE reads as ECNECT0; i.e. ECT(1) and CE cannot be told apart from the others.
+// For IPv6, this is derived from the traffic class (i.e. TC) instead, which has the same layout and is logged as it is.
+func (l *Log) ECN() uint8 {
+	return l.trafficClass() & 0b11
+}
+
+// DSCPName returns the name of the well-known DSCP, i.e. one of "CS0" to "CS7", "AF11" to "AF43", "EF", "VOICE-ADMIT", and "LE".
+// This returns an empty string if the DSCP is not well-known.
+func (l *Log) DSCPName() string {
+	return dscpName(l.DSCP())
+}
+
+func dscpName(dscp uint8) string {
+	switch dscp {
+	case 46:
+		return "EF"
+	case 44:
+		return "VOICE-ADMIT"
+	case 1:
+		return "LE"
+	}
+	if dscp&0b111 == 0 {
+		// the class selectors (RFC 2474), which are compatible with the IP precedence
+		return fmt.Sprintf("CS%d", dscp>>3)
+	}
+	// the assured forwarding (RFC 2597): the class in the upper 3 bits, and the drop precedence in the next 2 bits
+	class, dropPrecedence := dscp>>3, (dscp>>1)&0b11
+	if class >= 1 && class <= 4 && dropPrecedence >= 1 && dscp&1 == 0 {
+		return fmt.Sprintf("AF%d%d", class, dropPrecedence)
+	}
+	return ""
+}
@@ -0,0 +1,83 @@
+package iptables
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLog_DSCPAndECN(t *testing.T) {
+	type TestCase struct {
+		tos              string
+		prec             string
+		expectedDSCP     uint8
+		expectedECN      uint8
+		expectedDSCPName string
+	}
+
+	// the kernel logs the ToS byte split into TOS (masked by 0x1E) and PREC (masked by 0xE0)
+	testCases := []*TestCase{
+		{tos: "0x00", prec: "0x00", expectedDSCP: 0, expectedECN: ECNNotECT, expectedDSCPName: "CS0"},
+		// 0xB8
+		{tos: "0x18", prec: "0xA0", expectedDSCP: 46, expectedECN: ECNNotECT, expectedDSCPName: "EF"},
+		// 0xBA
+		{tos: "0x1A", prec: "0xA0", expectedDSCP: 46, expectedECN: ECNECT0, expectedDSCPName: "EF"},
+		// 0x29, whose ECT(1) is masked out
+		{tos: "0x08", prec: "0x20", expectedDSCP: 10, expectedECN: ECNNotECT, expectedDSCPName: "AF11"},
+		// 0x9B, whose CE reads as ECT(0) since the lowest bit is masked out
+		{tos: "0x1A", prec: "0x80", expectedDSCP: 38, expectedECN: ECNECT0, expectedDSCPName: "AF43"},
+		// 0xC0
+		{tos: "0x00", prec: "0xC0", expectedDSCP: 48, expectedECN: ECNNotECT, expectedDSCPName: "CS6"},
+		// 0xE0
+		{tos: "0x00", prec: "0xE0", expectedDSCP: 56, expectedECN: ECNNotECT, expectedDSCPName: "CS7"},
+		// 0xB0
+		{tos: "0x10", prec: "0xA0", expectedDSCP: 44, expectedECN: ECNNotECT, expectedDSCPName: "VOICE-ADMIT"},
+		// 0x04
+		{tos: "0x04", prec: "0x00", expectedDSCP: 1, expectedECN: ECNNotECT, expectedDSCPName: "LE"},
+		// 0x08
+		{tos: "0x08", prec: "0x00", expectedDSCP: 2, expectedECN: ECNNotECT, expectedDSCPName: ""},
+		// 0xA8
+		{tos: "0x08", prec: "0xA0", expectedDSCP: 42, expectedECN: ECNNotECT, expectedDSCPName: ""},
+	}
+
+	for _, testCase := range testCases {
+		line := "Jul 21 05:38:28 ubuntu-jammy kernel: [14879.600492] IN= OUT=enp0s3 SRC=10.0.2.15 DST=198.51.100.7 LEN=200 TOS=" + testCase.tos + " PREC=" + testCase.prec + " TTL=64 ID=6495 DF PROTO=UDP SPT=5004 DPT=5004 LEN=180"
+		parsedLog, err := Parse(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, testCase.expectedDSCP, parsedLog.DSCP(), line)
+		assert.Equal(t, testCase.expectedECN, parsedLog.ECN(), line)
+		assert.Equal(t, testCase.expectedDSCPName, parsedLog.DSCPName(), line)
+	}
+}
+
+func TestParse_IPv6TrafficClassDSCP(t *testing.T) {
+	type TestCase struct {
+		tc                   string
+		expectedTrafficClass uint8
+		expectedDSCP         uint8
+		expectedECN          uint8
+		expectedDSCPName     string
+	}
+
+	testCases := []*TestCase{
+		{tc: "184", expectedTrafficClass: 0xb8, expectedDSCP: 46, expectedECN: ECNNotECT, expectedDSCPName: "EF"},
+		{tc: "0xba", expectedTrafficClass: 0xba, expectedDSCP: 46, expectedECN: ECNECT0, expectedDSCPName: "EF"},
+		{tc: "75", expectedTrafficClass: 0x4b, expectedDSCP: 18, expectedECN: ECNCE, expectedDSCPName: "AF21"},
+		{tc: "0", expectedTrafficClass: 0, expectedDSCP: 0, expectedECN: ECNNotECT, expectedDSCPName: "CS0"},
+	}
+
+	for _, testCase := range testCases {
+		parsedLog, err := Parse("Jul 21 05:38:28 ubuntu-jammy kernel: [14879.600492] IN=enp0s3 OUT= SRC=2001:db8::1 DST=2001:db8::2 LEN=72 TC=" + testCase.tc + " HOPLIMIT=64 FLOWLBL=327680 PROTO=UDP SPT=5004 DPT=5004 LEN=32")
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.True(t, parsedLog.IsIPv6, testCase.tc)
+		assert.Equal(t, uint8(0), parsedLog.ToS, testCase.tc)
+		assert.Equal(t, testCase.expectedTrafficClass, parsedLog.TrafficClass, testCase.tc)
+		assert.Equal(t, testCase.expectedDSCP, parsedLog.DSCP(), testCase.tc)
+		assert.Equal(t, testCase.expectedECN, parsedLog.ECN(), testCase.tc)
+		assert.Equal(t, testCase.expectedDSCPName, parsedLog.DSCPName(), testCase.tc)
+	}
+}
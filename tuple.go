@@ -0,0 +1,38 @@
+package iptables
+
+import "strings"
+
+// FiveTuple returns the 5-tuple of the packet as a string key, i.e. the protocol, the source address and port, and the destination address and port, e.g. "TCP 10.0.2.15:54832 93.184.216.34:80".
+// The key is normalized to correlate the logs: the protocol is named by ProtocolName (e.g. "TCP" for "tcp" and "6"), the addresses are canonicalized (see CanonicalSource), and the IPv6 addresses are bracketed with the ports (e.g. "[2001:db8::1]:443").
+// The protocols without the ports (e.g. ICMP; see HasTransportPorts) have the addresses alone, e.g. "ICMP 10.0.2.15 8.8.8.8". The empty protocol and addresses are "?".
+// See FiveTupleBidirectional for the key that is shared by the both directions of a connection.
+func (l *Log) FiveTuple() string {
+	source, destination := l.fiveTupleEndpoints()
+	return l.fiveTupleProtocol() + " " + source + " " + destination
+}
+
+// FiveTupleBidirectional returns the 5-tuple of the packet as a string key that is the same for the both directions of a connection, e.g. for the request and the response.
+// This is FiveTuple with the endpoints sorted, so that the source and the destination are interchangeable; e.g. the both directions between 10.0.2.15:54832 and 93.184.216.34:80 are "TCP 10.0.2.15:54832 93.184.216.34:80".
+func (l *Log) FiveTupleBidirectional() string {
+	source, destination := l.fiveTupleEndpoints()
+	if strings.Compare(source, destination) > 0 {
+		source, destination = destination, source
+	}
+	return l.fiveTupleProtocol() + " " + source + " " + destination
+}
+
+// fiveTupleProtocol returns the normalized protocol of FiveTuple.
+func (l *Log) fiveTupleProtocol() string {
+	if protocol := l.ProtocolName(); protocol != "" {
+		return protocol
+	}
+	return "?"
+}
+
+// fiveTupleEndpoints returns the normalized source and destination endpoints of FiveTuple.
+func (l *Log) fiveTupleEndpoints() (string, string) {
+	hasPorts := l.HasTransportPorts()
+	source := summarizeEndpoint(canonicalAddr(l.Source), l.SourcePort, hasPorts)
+	destination := summarizeEndpoint(canonicalAddr(l.Destination), l.DestinationPort, hasPorts)
+	return source, destination
+}
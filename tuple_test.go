@@ -0,0 +1,79 @@
+package iptables
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLog_FiveTuple(t *testing.T) {
+	type TestCase struct {
+		log                   *Log
+		expected              string
+		expectedBidirectional string
+	}
+
+	testCases := []*TestCase{
+		{
+			log:                   &Log{Protocol: "TCP", Source: "93.184.216.34", SourcePort: 80, Destination: "10.0.2.15", DestinationPort: 54832},
+			expected:              "TCP 93.184.216.34:80 10.0.2.15:54832",
+			expectedBidirectional: "TCP 10.0.2.15:54832 93.184.216.34:80",
+		},
+		{
+			log:                   &Log{Protocol: "udp", Source: "10.0.2.15", SourcePort: 40000, Destination: "8.8.8.8", DestinationPort: 53},
+			expected:              "UDP 10.0.2.15:40000 8.8.8.8:53",
+			expectedBidirectional: "UDP 10.0.2.15:40000 8.8.8.8:53",
+		},
+		{
+			log:                   &Log{Protocol: "6", Source: "2001:0DB8::2", SourcePort: 443, Destination: "2001:db8::1", DestinationPort: 54832},
+			expected:              "TCP [2001:db8::2]:443 [2001:db8::1]:54832",
+			expectedBidirectional: "TCP [2001:db8::1]:54832 [2001:db8::2]:443",
+		},
+		{
+			// the ports of the same addresses
+			log:                   &Log{Protocol: "UDP", Source: "10.0.2.15", SourcePort: 5353, Destination: "10.0.2.15", DestinationPort: 53},
+			expected:              "UDP 10.0.2.15:5353 10.0.2.15:53",
+			expectedBidirectional: "UDP 10.0.2.15:53 10.0.2.15:5353",
+		},
+		{
+			// the protocols without the ports
+			log:                   &Log{Protocol: "ICMP", Source: "8.8.8.8", Destination: "10.0.2.15", Type: 0, ICMPID: 1},
+			expected:              "ICMP 8.8.8.8 10.0.2.15",
+			expectedBidirectional: "ICMP 10.0.2.15 8.8.8.8",
+		},
+		{
+			log:                   &Log{Protocol: "47", Source: "192.0.2.1", Destination: "10.0.2.15"},
+			expected:              "GRE 192.0.2.1 10.0.2.15",
+			expectedBidirectional: "GRE 10.0.2.15 192.0.2.1",
+		},
+		{
+			log:                   &Log{},
+			expected:              "? ? ?",
+			expectedBidirectional: "? ? ?",
+		},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.expected, testCase.log.FiveTuple())
+		assert.Equal(t, testCase.expectedBidirectional, testCase.log.FiveTupleBidirectional())
+	}
+}
+
+func TestLog_FiveTupleBidirectional_RequestAndResponse(t *testing.T) {
+	request, err := Parse("Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=93.184.216.34 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=64125 DF PROTO=TCP SPT=54832 DPT=80 WINDOW=64240 RES=0x00 SYN URGP=0")
+	assert.NoError(t, err)
+	response, err := Parse("Jul 21 05:31:48 ubuntu-jammy kernel: [14479.142228] IN-LOG: IN=enp0s3 OUT= SRC=93.184.216.34 DST=10.0.2.15 LEN=60 TOS=0x00 PREC=0x00 TTL=53 ID=0 DF PROTO=TCP SPT=80 DPT=54832 WINDOW=65535 RES=0x00 ACK SYN URGP=0")
+	assert.NoError(t, err)
+
+	assert.NotEqual(t, request.FiveTuple(), response.FiveTuple())
+	assert.Equal(t, request.FiveTupleBidirectional(), response.FiveTupleBidirectional())
+	assert.Equal(t, request.FiveTuple(), request.FiveTupleBidirectional())
+}
+
+func TestLog_FiveTuple_IPv4MappedAddresses(t *testing.T) {
+	mapped := &Log{Protocol: "TCP", Source: "::ffff:10.0.2.15", SourcePort: 54832, Destination: "::ffff:93.184.216.34", DestinationPort: 80}
+	plain := &Log{Protocol: "TCP", Source: "93.184.216.34", SourcePort: 80, Destination: "10.0.2.15", DestinationPort: 54832}
+
+	assert.Equal(t, "TCP 10.0.2.15:54832 93.184.216.34:80", mapped.FiveTuple())
+	assert.Equal(t, plain.FiveTupleBidirectional(), mapped.FiveTupleBidirectional())
+}
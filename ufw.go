@@ -0,0 +1,32 @@
+package iptables
+
+import (
+	"strings"
+)
+
+// ParseUFW parses an iptables line that is logged by UFW; see Parser.ParseUFW for details.
+func ParseUFW(line string) (*Log, error) {
+	return defaultParser.ParseUFW(line)
+}
+
+// ParseUFW parses an iptables line that is logged by UFW (Uncomplicated Firewall).
+// UFW puts the bracketed tag like "[UFW BLOCK] " as the prefix; this method strips the brackets so that Log.Prefix becomes the clean tag, e.g. "UFW BLOCK".
+// The lines without the UFW tag are parsed as well as Parse does.
+func (p *Parser) ParseUFW(line string) (*Log, error) {
+	l, err := p.Parse(line)
+	if l == nil {
+		return nil, err
+	}
+	if tag, ok := ufwTag(l.Prefix); ok {
+		l.Prefix = tag
+	}
+	return l, err
+}
+
+// ufwTag returns the UFW tag without the brackets, e.g. "UFW BLOCK" of "[UFW BLOCK]".
+func ufwTag(prefix string) (string, bool) {
+	if !strings.HasPrefix(prefix, "[UFW ") || !strings.HasSuffix(prefix, "]") {
+		return "", false
+	}
+	return prefix[1 : len(prefix)-1], true
+}
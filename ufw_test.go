@@ -0,0 +1,72 @@
+package iptables
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseUFW(t *testing.T) {
+	type TestCase struct {
+		line           string
+		expectedPrefix string
+		expectedAction string
+	}
+
+	testCases := []*TestCase{
+		{
+			line:           "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] [UFW BLOCK] IN=enp0s3 OUT= MAC=00:b3:dd:bc:29:e1:52:54:00:12:35:02:08:00 SRC=198.51.100.1 DST=10.0.2.15 LEN=40 TOS=0x00 PREC=0x00 TTL=244 ID=54321 PROTO=TCP SPT=51234 DPT=23 WINDOW=65535 RES=0x00 SYN URGP=0",
+			expectedPrefix: "UFW BLOCK",
+			expectedAction: ActionDrop,
+		},
+		{
+			line:           "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] [UFW ALLOW] IN= OUT=enp0s3 SRC=10.0.2.15 DST=93.184.216.34 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=64125 DF PROTO=TCP SPT=54832 DPT=80 WINDOW=64240 RES=0x00 SYN URGP=0",
+			expectedPrefix: "UFW ALLOW",
+			expectedAction: ActionAccept,
+		},
+		{
+			line:           "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] [UFW AUDIT] IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=64 TOS=0x00 PREC=0x00 TTL=64 ID=40000 PROTO=UDP SPT=40000 DPT=53 LEN=44",
+			expectedPrefix: "UFW AUDIT",
+			expectedAction: "",
+		},
+		{
+			// without the UFW tag
+			line:           "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=64 TOS=0x00 PREC=0x00 TTL=64 ID=40000 PROTO=UDP SPT=40000 DPT=53 LEN=44",
+			expectedPrefix: "OUT-LOG:",
+			expectedAction: "",
+		},
+	}
+
+	for _, testCase := range testCases {
+		parsedLog, err := ParseUFW(testCase.line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, testCase.expectedPrefix, parsedLog.Prefix)
+		assert.Equal(t, testCase.expectedAction, parsedLog.Action())
+		assert.NotEmpty(t, parsedLog.Source)
+		assert.NotEmpty(t, parsedLog.DestinationPort)
+	}
+
+	_, err := ParseUFW("this is not an iptables log")
+	assert.True(t, errors.Is(err, ErrLogFormatUnmatched))
+}
+
+func TestParseUFW_WithCollectAllErrors(t *testing.T) {
+	p, err := NewParser(WithCollectAllErrors(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsedLog, err := p.ParseUFW("Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] [UFW BLOCK] IN=enp0s3 OUT= SRC=198.51.100.1 DST=10.0.2.15 LEN=40 TOS=0x00 PREC=0x00 TTL=2x4 ID=54321 PROTO=TCP SPT=51234 DPT=23 WINDOW=65535 RES=0x00 SYN URGP=0")
+	assert.ErrorIs(t, err, ErrStringToNumberConversionFailed)
+	if assert.NotNil(t, parsedLog) {
+		// the tag is stripped from the partially populated log as well
+		assert.Equal(t, "UFW BLOCK", parsedLog.Prefix)
+		assert.Equal(t, uint16(23), parsedLog.DestinationPort)
+	}
+
+	parsedLog, err = p.ParseUFW("this is not an iptables log")
+	assert.ErrorIs(t, err, ErrLogFormatUnmatched)
+	assert.Nil(t, parsedLog)
+}
@@ -0,0 +1,98 @@
+package iptables
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+)
+
+// The errors of Validate, each of which corresponds to a check.
+var (
+	// ErrInvalidAddress is an error that occurs when the source or destination address is not a valid IP address.
+	ErrInvalidAddress = errors.New("invalid IP address")
+	// ErrAddressFamilyMismatch is an error that occurs when the source and destination addresses are of the different families, i.e. IPv4 and IPv6.
+	ErrAddressFamilyMismatch = errors.New("source and destination addresses are of the different families")
+	// ErrZeroLength is an error that occurs when the length of the packet is zero.
+	ErrZeroLength = errors.New("length is zero")
+	// ErrUnexpectedPorts is an error that occurs when the ports are set for the protocol that has no port, i.e. the one other than TCP, UDP, UDPLITE, SCTP, and DCCP.
+	ErrUnexpectedPorts = errors.New("ports are set for the protocol that has no port")
+	// ErrUnexpectedTCPFlags is an error that occurs when the TCP flags are set for the protocol other than TCP.
+	ErrUnexpectedTCPFlags = errors.New("TCP flags are set for the protocol other than TCP")
+	// ErrUnexpectedICMPFields is an error that occurs when the ICMP fields (i.e. TYPE, CODE, the echo ID and SEQ, and MTU) are set for the protocol other than ICMP and ICMPv6.
+	ErrUnexpectedICMPFields = errors.New("ICMP fields are set for the protocol other than ICMP")
+)
+
+// Validate checks whether the log is internally consistent, which is useful as a guard against untrusted logs.
+// This returns the errors of all the failed checks joined by errors.Join, or nil if there is no problem. The checks are the following:
+//
+//   - the source and destination addresses are valid IP addresses of the same family (ErrInvalidAddress and ErrAddressFamilyMismatch)
+//   - the length is non-zero (ErrZeroLength)
+//   - the ports are set only for TCP, UDP, UDPLITE, SCTP, and DCCP (ErrUnexpectedPorts)
+//   - the TCP flags are set only for TCP (ErrUnexpectedTCPFlags)
+//   - the ICMP fields are set only for ICMP and ICMPv6 (ErrUnexpectedICMPFields)
+//
+// Each error can be examined by errors.Is.
+func (l *Log) Validate() error {
+	return errors.Join(
+		l.validateAddresses(),
+		l.validateLength(),
+		l.validatePorts(),
+		l.validateTCPFlags(),
+		l.validateICMPFields(),
+	)
+}
+
+func (l *Log) validateAddresses() error {
+	src, srcErr := netip.ParseAddr(l.Source)
+	if srcErr != nil {
+		srcErr = fmt.Errorf("source = %q: %w", l.Source, ErrInvalidAddress)
+	}
+	dst, dstErr := netip.ParseAddr(l.Destination)
+	if dstErr != nil {
+		dstErr = fmt.Errorf("destination = %q: %w", l.Destination, ErrInvalidAddress)
+	}
+	if srcErr != nil || dstErr != nil {
+		return errors.Join(srcErr, dstErr)
+	}
+
+	// an IPv4-mapped IPv6 address is of the same family as the plain IPv4 one
+	if src.Unmap().Is4() != dst.Unmap().Is4() {
+		return fmt.Errorf("source = %q, destination = %q: %w", l.Source, l.Destination, ErrAddressFamilyMismatch)
+	}
+	return nil
+}
+
+func (l *Log) validateLength() error {
+	if l.Length == 0 {
+		return ErrZeroLength
+	}
+	return nil
+}
+
+func (l *Log) validatePorts() error {
+	if l.SourcePort == 0 && l.DestinationPort == 0 && !l.Has(FieldSourcePort) && !l.Has(FieldDestinationPort) {
+		return nil
+	}
+	if l.HasTransportPorts() {
+		return nil
+	}
+	return fmt.Errorf("protocol = %q: %w", l.Protocol, ErrUnexpectedPorts)
+}
+
+func (l *Log) validateTCPFlags() error {
+	if l.TCPFlagBits() == 0 || l.isProtocol("TCP") {
+		return nil
+	}
+	return fmt.Errorf("protocol = %q: %w", l.Protocol, ErrUnexpectedTCPFlags)
+}
+
+func (l *Log) validateICMPFields() error {
+	if l.IsICMP() {
+		return nil
+	}
+	if l.Type == 0 && l.Code == 0 && l.ICMPID == 0 && l.ICMPSeq == 0 && l.MTU == 0 &&
+		!l.Has(FieldType) && !l.Has(FieldCode) && !l.Has(FieldICMPID) && !l.Has(FieldICMPSeq) && !l.Has(FieldMTU) {
+		return nil
+	}
+	return fmt.Errorf("protocol = %q: %w", l.Protocol, ErrUnexpectedICMPFields)
+}
@@ -0,0 +1,90 @@
+package iptables
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLog_Validate(t *testing.T) {
+	type TestCase struct {
+		line string
+	}
+
+	testCases := []*TestCase{
+		{line: readerTestTCPLine},
+		{line: readerTestICMPLine},
+		{line: "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] IN=enp0s3 OUT= SRC=2001:db8::1 DST=2001:db8::2 LEN=72 TC=0 HOPLIMIT=64 FLOWLBL=0 PROTO=UDP SPT=40000 DPT=53 LEN=32"},
+		// the IPv4-mapped IPv6 address is of the same family as the plain IPv4 one
+		{line: "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] IN=enp0s3 OUT= SRC=::ffff:192.0.2.1 DST=10.0.2.15 LEN=72 TC=0 HOPLIMIT=64 FLOWLBL=0 PROTO=UDP SPT=40000 DPT=53 LEN=32"},
+		{line: "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] IN=enp0s3 OUT= SRC=10.0.2.2 DST=10.0.2.15 LEN=100 TOS=0x00 PREC=0x00 TTL=64 ID=0 PROTO=SCTP SPT=2905 DPT=2905"},
+		{line: "Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] IN=enp0s3 OUT= SRC=10.0.2.2 DST=10.0.2.15 LEN=100 TOS=0x00 PREC=0x00 TTL=64 ID=0 PROTO=ESP SPI=0x1000 SEQ=1"},
+	}
+
+	for _, testCase := range testCases {
+		parsedLog, err := Parse(testCase.line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.NoError(t, parsedLog.Validate(), testCase.line)
+	}
+}
+
+func TestLog_ValidateFailed(t *testing.T) {
+	type TestCase struct {
+		log          *Log
+		expectedErrs []error
+	}
+
+	testCases := []*TestCase{
+		{
+			log:          &Log{Source: "10.0.2.999", Destination: "", Length: 60, Protocol: "TCP"},
+			expectedErrs: []error{ErrInvalidAddress},
+		},
+		{
+			log:          &Log{Source: "10.0.2.15", Destination: "2001:db8::1", Length: 60, Protocol: "TCP"},
+			expectedErrs: []error{ErrAddressFamilyMismatch},
+		},
+		{
+			log:          &Log{Source: "10.0.2.15", Destination: "8.8.8.8", Length: 0, Protocol: "UDP"},
+			expectedErrs: []error{ErrZeroLength},
+		},
+		{
+			log:          &Log{Source: "10.0.2.15", Destination: "8.8.8.8", Length: 84, Protocol: "ICMP", DestinationPort: 53},
+			expectedErrs: []error{ErrUnexpectedPorts},
+		},
+		{
+			log:          &Log{Source: "10.0.2.15", Destination: "8.8.8.8", Length: 84, Protocol: "UDP", Syn: true},
+			expectedErrs: []error{ErrUnexpectedTCPFlags},
+		},
+		{
+			log:          &Log{Source: "10.0.2.15", Destination: "8.8.8.8", Length: 84, Protocol: "TCP", Type: 8},
+			expectedErrs: []error{ErrUnexpectedICMPFields},
+		},
+		{
+			// all the problems are reported at once
+			log:          &Log{Source: "bogus", Destination: "8.8.8.8", Protocol: "GRE", SourcePort: 1, Fin: true, MTU: 1400},
+			expectedErrs: []error{ErrInvalidAddress, ErrZeroLength, ErrUnexpectedPorts, ErrUnexpectedTCPFlags, ErrUnexpectedICMPFields},
+		},
+	}
+
+	allErrs := []error{ErrInvalidAddress, ErrAddressFamilyMismatch, ErrZeroLength, ErrUnexpectedPorts, ErrUnexpectedTCPFlags, ErrUnexpectedICMPFields}
+	for _, testCase := range testCases {
+		err := testCase.log.Validate()
+		assert.Error(t, err)
+		for _, target := range allErrs {
+			assert.Equal(t, slices.Contains(testCase.expectedErrs, target), errors.Is(err, target), "%v: %v", target, err)
+		}
+	}
+}
+
+func TestLog_ValidatePortZero(t *testing.T) {
+	// the port zero is regarded as set when it is present in the line
+	parsedLog, err := Parse("Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] IN=enp0s3 OUT= SRC=10.0.2.2 DST=10.0.2.15 LEN=100 TOS=0x00 PREC=0x00 TTL=64 ID=0 PROTO=GRE SPT=0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, errors.Is(parsedLog.Validate(), ErrUnexpectedPorts))
+}
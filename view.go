@@ -0,0 +1,87 @@
+package iptables
+
+// TCPView is the narrowed view of a TCP log, which has only the fields that are meaningful for TCP.
+type TCPView struct {
+	Source          string
+	Destination     string
+	SourcePort      uint16
+	DestinationPort uint16
+	Sequence        uint64
+	AckSequence     uint64
+	WindowSize      uint64
+	Res             uint64
+	Urgent          bool
+	Ack             bool
+	Push            bool
+	Reset           bool
+	Syn             bool
+	Fin             bool
+	ECE             bool
+	CWR             bool
+	NS              bool
+	Urgp            uint64
+	// Options is the hex-encoded TCP options (i.e. Log.TCPOption); see Log.TCPOptionsDecoded to decode them.
+	Options string
+}
+
+// ICMPView is the narrowed view of an ICMP (or ICMPv6) log, which has only the fields that are meaningful for ICMP.
+type ICMPView struct {
+	Source      string
+	Destination string
+	IsIPv6      bool
+	Type        int64
+	Code        int64
+	// ID and Seq are the identifier and the sequence number of the echo request/reply.
+	ID  uint16
+	Seq uint16
+	// MTU is the next-hop MTU of "fragmentation needed" (or "packet too big" of ICMPv6).
+	MTU uint16
+	// Inner is the original packet embedded in the ICMP error message, which is nil if there is no such packet.
+	Inner *Log
+}
+
+// AsTCP returns the TCP view of the log. The second return value is false if the protocol is not TCP.
+func (l *Log) AsTCP() (*TCPView, bool) {
+	if n, ok := l.ProtocolNumber(); !ok || n != ProtocolNumbers["TCP"] {
+		return nil, false
+	}
+	return &TCPView{
+		Source:          l.Source,
+		Destination:     l.Destination,
+		SourcePort:      l.SourcePort,
+		DestinationPort: l.DestinationPort,
+		Sequence:        l.Sequence,
+		AckSequence:     l.AckSequence,
+		WindowSize:      l.WindowSize,
+		Res:             l.Res,
+		Urgent:          l.Urgent,
+		Ack:             l.Ack,
+		Push:            l.Push,
+		Reset:           l.Reset,
+		Syn:             l.Syn,
+		Fin:             l.Fin,
+		ECE:             l.ECE,
+		CWR:             l.CWR,
+		NS:              l.NS,
+		Urgp:            l.Urgp,
+		Options:         l.TCPOption,
+	}, true
+}
+
+// AsICMP returns the ICMP view of the log. The second return value is false if the protocol is neither ICMP nor ICMPv6.
+func (l *Log) AsICMP() (*ICMPView, bool) {
+	if !l.IsICMP() {
+		return nil, false
+	}
+	return &ICMPView{
+		Source:      l.Source,
+		Destination: l.Destination,
+		IsIPv6:      l.IsIPv6,
+		Type:        l.Type,
+		Code:        l.Code,
+		ID:          l.ICMPID,
+		Seq:         l.ICMPSeq,
+		MTU:         l.MTU,
+		Inner:       l.Inner,
+	}, true
+}
@@ -0,0 +1,53 @@
+package iptables
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLog_AsTCP(t *testing.T) {
+	parsedLog, err := Parse("Jul 21 05:31:48 ubuntu-jammy kernel: [14479.122228] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=93.184.216.34 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=64125 DF PROTO=TCP SPT=54832 DPT=80 SEQ=567002889 ACK=0 WINDOW=64240 RES=0x00 SYN URGP=0 OPT (020405B4)")
+	assert.NoError(t, err)
+
+	view, ok := parsedLog.AsTCP()
+	assert.True(t, ok)
+	assert.Equal(t, &TCPView{
+		Source:          "10.0.2.15",
+		Destination:     "93.184.216.34",
+		SourcePort:      54832,
+		DestinationPort: 80,
+		Sequence:        567002889,
+		WindowSize:      64240,
+		Syn:             true,
+		Options:         "020405B4",
+	}, view)
+
+	icmpView, ok := parsedLog.AsICMP()
+	assert.False(t, ok)
+	assert.Nil(t, icmpView)
+}
+
+func TestLog_AsICMP(t *testing.T) {
+	parsedLog, err := Parse("Jul 21 05:38:28 ubuntu-jammy kernel: [14879.600492] IN=enp0s3 OUT= SRC=192.0.2.1 DST=10.0.2.15 LEN=576 TOS=0x00 PREC=0xC0 TTL=254 ID=39147 PROTO=ICMP TYPE=3 CODE=4 MTU=1400 [SRC=10.0.2.15 DST=198.51.100.7 LEN=56 PROTO=UDP SPT=40000 DPT=0 ]")
+	assert.NoError(t, err)
+
+	view, ok := parsedLog.AsICMP()
+	assert.True(t, ok)
+	assert.Equal(t, &ICMPView{
+		Source:      "192.0.2.1",
+		Destination: "10.0.2.15",
+		Type:        3,
+		Code:        4,
+		MTU:         1400,
+		Inner:       parsedLog.Inner,
+	}, view)
+
+	tcpView, ok := parsedLog.AsTCP()
+	assert.False(t, ok)
+	assert.Nil(t, tcpView)
+
+	// the inner packet is not TCP either
+	_, ok = parsedLog.Inner.AsTCP()
+	assert.False(t, ok)
+}
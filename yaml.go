@@ -0,0 +1,52 @@
+package iptables
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MarshalYAML implements yaml.Marshaler of gopkg.in/yaml.v3; this encodes the log as a YAML mapping of the fields.
+// The keys and the order of the fields are the same as MarshalJSON's ones, and the empty Extra and the nil Inner are omitted as well as omitempty of JSON does.
+func (l *Log) MarshalYAML() (any, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for f := Field(0); int(f) < numFields; f++ {
+		if (f == FieldExtra && len(l.Extra) == 0) || (f == FieldInner && l.Inner == nil) {
+			// omitempty
+			continue
+		}
+
+		value := &yaml.Node{}
+		if err := value.Encode(fieldDefs[f].get(l)); err != nil {
+			return nil, err
+		}
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: fieldDefs[f].name}, value)
+	}
+	return node, nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler of gopkg.in/yaml.v3; this decodes a YAML mapping of the fields into the log, as the counterpart of MarshalYAML.
+// The unknown keys are ignored. A YAML string is parsed as the iptables line as well as UnmarshalText does.
+func (l *Log) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		var line string
+		if err := value.Decode(&line); err != nil {
+			return err
+		}
+		return l.UnmarshalText([]byte(line))
+	}
+	if value.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: cannot unmarshal %s into iptables.Log", value.Line, value.ShortTag())
+	}
+
+	for i := 0; i+1 < len(value.Content); i += 2 {
+		f, ok := fieldsByName[value.Content[i].Value]
+		if !ok {
+			continue
+		}
+		if err := value.Content[i+1].Decode(fieldDefs[f].ptr(l)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package iptables
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestLog_MarshalYAML(t *testing.T) {
+	p, err := NewParser(WithYear(2022), WithLocation(time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsedLog, err := p.Parse("Jul 21 05:38:28 ubuntu-jammy kernel: [14879.600492] OUT-LOG: IN= OUT=enp0s3 SRC=10.0.2.15 DST=8.8.8.8 LEN=84 TOS=0x00 PREC=0x00 TTL=64 ID=6495 DF PROTO=ICMP TYPE=8 CODE=0 ID=1 SEQ=3")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := yaml.Marshal(parsedLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, `facility: 0
+severity: 0
+hasPriority: false
+timestamp: Jul 21 05:38:28
+timestampParsed: 2022-07-21T05:38:28Z
+hostname: ubuntu-jammy
+kernelTimestamp: 14879.600492
+prefix: 'OUT-LOG:'
+inputInterface: ""
+outputInterface: enp0s3
+physInputInterface: ""
+physOutputInterface: ""
+macAddress: ""
+source: 10.0.2.15
+destination: 8.8.8.8
+length: 84
+tos: 0
+precedence: 0
+ttl: 64
+id: 6495
+congestionExperienced: false
+doNotFragment: true
+moreFragmentsFollowing: false
+frag: 0
+ipOptions: ""
+isIPv6: false
+ipVersion: 4
+trafficClass: 0
+flowLabel: 0
+protocol: ICMP
+type: 8
+code: 0
+icmpId: 1
+icmpSeq: 3
+mtu: 0
+spi: 0
+ipsecSequence: 0
+greKey: 0
+greProtocol: 0
+sourcePort: 0
+destinationPort: 0
+checksumCoverage: 0
+sequence: 0
+ackSequence: 0
+windowSize: 0
+res: 0
+urgent: false
+ack: false
+push: false
+reset: false
+syn: false
+fin: false
+ece: false
+cwr: false
+ns: false
+urgp: 0
+tcpOption: ""
+incomplete: false
+incompleteBytes: 0
+uid: -1
+gid: -1
+mark: 0
+hasMark: false
+`, string(out))
+}
+
+func TestLog_UnmarshalYAML(t *testing.T) {
+	p, err := NewParser(WithYear(2022), WithLocation(time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsedLog, err := p.Parse("Jul 21 05:38:28 ubuntu-jammy kernel: [14879.600492] IN=enp0s3 OUT= SRC=192.0.2.1 DST=10.0.2.15 LEN=576 TOS=0x00 PREC=0xC0 TTL=254 ID=39147 PROTO=ICMP TYPE=3 CODE=3 [SRC=10.0.2.15 DST=198.51.100.7 LEN=56 PROTO=UDP SPT=40000 DPT=0 ] MARK=0x0 CT=NEW")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := yaml.Marshal(parsedLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, string(out), "extra:\n    CT: NEW\ninner:\n    facility: 0\n")
+
+	var decoded Log
+	if err := yaml.Unmarshal(out, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	// the presence is not encoded
+	assert.Empty(t, Diff(parsedLog, &decoded))
+	assert.Equal(t, parsedLog.Inner.DestinationPort, decoded.Inner.DestinationPort)
+	assert.Equal(t, map[string]string{"CT": "NEW"}, decoded.Extra)
+
+	// the unknown keys are ignored, and a string is parsed as the line
+	var fromLine struct {
+		Log     *Log `yaml:"log"`
+		Unknown int  `yaml:"unknown"`
+	}
+	if err := yaml.Unmarshal([]byte("log: "+strconv.Quote(parsedLog.Format())+"\nunknown: 1\n"), &fromLine); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "198.51.100.7", fromLine.Log.Inner.Destination)
+
+	assert.Error(t, yaml.Unmarshal([]byte("[1, 2]"), &decoded))
+}